@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var planFile string
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply a saved instance stack plan",
+	Long: `Execute exactly the actions recorded in a plan created by the plan command.
+
+The apply is refused if the live state of the instance stack has drifted since the plan was created.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		planYaml, err := os.ReadFile(planFile)
+		if err != nil {
+			return err
+		}
+
+		var plan types.Plan
+		if err = yaml.Unmarshal(planYaml, &plan); err != nil {
+			return err
+		}
+
+		if plan.Action != types.ActionShutdown && plan.Action != types.ActionStartup {
+			return fmt.Errorf("unsupported plan action %q", plan.Action)
+		}
+
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		if plan.Stack == nil || *plan.Stack != *stack.Name {
+			return fmt.Errorf("plan %v does not belong to instance stack %v", planFile, *stack.Name)
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, plan.Action)
+		if err := beginRun(ctx, plan.Action, cfg); err != nil {
+			return err
+		}
+
+		live, err := buildPlan(ctx, plan.Action, clients)
+		if err != nil {
+			return err
+		}
+
+		if drift := diffPlans(&plan, live); len(drift) > 0 {
+			out().Summaryf("Instance stack %v has drifted since %v: %v\n", *stack.Name, plan.CreatedAt, drift)
+			return fmt.Errorf("refusing to apply plan %v: live state has drifted", planFile)
+		}
+
+		for _, g := range plan.Groups {
+			group, ok := curator.FindGroup(&stack, *g.Name)
+			if !ok {
+				return fmt.Errorf("group %v of plan %v is not defined in instance stack %v", *g.Name, planFile, *stack.Name)
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if len(g.Instances) == 0 && len(g.Clusters) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds := make([]string, 0, len(g.Instances))
+			for _, i := range g.Instances {
+				instanceIds = append(instanceIds, *i.InstanceId)
+			}
+			if runTracker != nil {
+				runTracker.SetGroupInstances(instanceIds)
+			}
+
+			if err := out().CheckInstanceGroupGuards(ctx, clients.ec2, clients.autoscaling, group, instanceIds); err != nil {
+				return err
+			}
+
+			if plan.Action == types.ActionStartup {
+				if err := c.BeginGroupStartup(ctx, group, instanceIds); err != nil {
+					return err
+				}
+
+				if err := c.StartGroup(ctx, group, instanceIds); err != nil {
+					return err
+				}
+
+				if err := c.ApplyGroupStartupPlan(ctx, group, g.AutoScalingGroups); err != nil {
+					return err
+				}
+
+				if err := c.CompleteGroupStartup(ctx, group, instanceIds); err != nil {
+					return err
+				}
+
+				if err := pauseAfterCanary(ctx, group); err != nil {
+					return err
+				}
+			} else {
+				if err := c.BeginGroupShutdown(ctx, group, instanceIds); err != nil {
+					return err
+				}
+
+				if err := c.ApplyGroupShutdownPlan(ctx, group, g.AutoScalingGroups); err != nil {
+					return err
+				}
+
+				if err := c.StopGroup(ctx, group, instanceIds); err != nil {
+					return err
+				}
+
+				if err := c.CompleteGroupShutdown(ctx, group, instanceIds); err != nil {
+					return err
+				}
+			}
+
+			out().Printf("Instance group %v: %v has been completed\n", *group.Name, plan.Action)
+		}
+
+		out().Summaryf("Instance stack %v: %v has been completed\n", *stack.Name, plan.Action)
+		return nil
+	},
+}
+
+// diffPlans describes the differences between a saved plan and the live state
+func diffPlans(planned, live *types.Plan) []string {
+	drift := make([]string, 0)
+	if len(planned.Groups) != len(live.Groups) {
+		return append(drift, fmt.Sprintf("expected %v groups, got %v", len(planned.Groups), len(live.Groups)))
+	}
+
+	for i := range planned.Groups {
+		p, l := planned.Groups[i], live.Groups[i]
+		if *p.Name != *l.Name {
+			drift = append(drift, fmt.Sprintf("group %v: expected group %v at this position", *l.Name, *p.Name))
+			continue
+		}
+
+		plannedStates := make(map[string]string, len(p.Instances))
+		for _, i := range p.Instances {
+			plannedStates[*i.InstanceId] = string(i.State)
+		}
+		liveStates := make(map[string]string, len(l.Instances))
+		for _, i := range l.Instances {
+			liveStates[*i.InstanceId] = string(i.State)
+		}
+		for id, state := range plannedStates {
+			if liveState, ok := liveStates[id]; !ok {
+				drift = append(drift, fmt.Sprintf("group %v: instance %v is no longer matched", *p.Name, id))
+			} else if liveState != state {
+				drift = append(drift, fmt.Sprintf("group %v: instance %v state changed from %v to %v", *p.Name, id, state, liveState))
+			}
+		}
+		for id := range liveStates {
+			if _, ok := plannedStates[id]; !ok {
+				drift = append(drift, fmt.Sprintf("group %v: instance %v was not planned", *p.Name, id))
+			}
+		}
+
+		plannedClusters := make(map[string]string, len(p.Clusters))
+		for _, c := range p.Clusters {
+			plannedClusters[*c.Identifier] = aws.ToString(c.Status)
+		}
+		for _, c := range l.Clusters {
+			if status, ok := plannedClusters[*c.Identifier]; ok && status != aws.ToString(c.Status) {
+				drift = append(drift, fmt.Sprintf("group %v: cluster %v status changed from %v to %v", *p.Name, *c.Identifier, status, aws.ToString(c.Status)))
+			}
+		}
+
+		plannedChanges := mapAutoScalingGroupChanges(p.AutoScalingGroups)
+		liveChanges := mapAutoScalingGroupChanges(l.AutoScalingGroups)
+		for name, change := range plannedChanges {
+			if liveChange, ok := liveChanges[name]; !ok {
+				drift = append(drift, fmt.Sprintf("group %v: ASG %v change is no longer required", *p.Name, name))
+			} else if !reflect.DeepEqual(change, liveChange) {
+				drift = append(drift, fmt.Sprintf("group %v: ASG %v state has changed", *p.Name, name))
+			}
+		}
+		for name := range liveChanges {
+			if _, ok := plannedChanges[name]; !ok {
+				drift = append(drift, fmt.Sprintf("group %v: ASG %v change was not planned", *p.Name, name))
+			}
+		}
+	}
+
+	sort.Strings(drift)
+	return drift
+}
+
+func mapAutoScalingGroupChanges(changes []types.AutoScalingGroupChange) map[string]types.AutoScalingGroupChange {
+	m := make(map[string]types.AutoScalingGroupChange, len(changes))
+	for _, c := range changes {
+		c.InstanceIds = append([]string{}, c.InstanceIds...)
+		sort.Strings(c.InstanceIds)
+		m[*c.AutoScalingGroupName] = c
+	}
+	return m
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	// Local flags which will only run when this command is called directly
+	applyCmd.Flags().StringVar(&planFile, "plan", "", "Path to a plan created by the plan command")
+	applyCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+	applyCmd.Flags().BoolVar(&confirmCanaries, "confirm-canaries", false, "Continue past canary groups without an interactive confirmation")
+	applyCmd.MarkFlagRequired("plan")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+)
+
+// auditUploadTimeout bounds the upload of the audit record, so that a command never hangs on S3 or KMS
+const auditUploadTimeout = time.Minute
+
+var auditRecorder *audit.Recorder
+var auditConfig aws.Config
+
+// initAudit starts recording the mutating calls made with the AWS config
+func initAudit(ctx context.Context, cfg *aws.Config) {
+	auditRecorder = audit.NewRecorder(*stack.Name, commandPath, runId, cfg.Region)
+	cfg.APIOptions = append(cfg.APIOptions, auditRecorder.AddMiddleware)
+	auditConfig = *cfg
+
+	if output, err := sts.NewFromConfig(*cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to resolve the AWS principal for the audit record: %v\n", err)
+	} else {
+		auditRecorder.SetPrincipal(aws.ToString(output.Arn))
+	}
+}
+
+// finishAudit uploads the audit record of a run which made mutating calls, a cancelled run included,
+// within auditUploadTimeout
+func finishAudit(ctx context.Context, runErr error) error {
+	if auditRecorder.Len() == 0 {
+		return runErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditUploadTimeout)
+	defer cancel()
+
+	auditRecorder.Finish(runErr)
+	location, err := auditRecorder.Upload(ctx, s3.NewFromConfig(auditConfig), kms.NewFromConfig(auditConfig), stack.Audit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return errors.Join(runErr, err)
+	}
+
+	if runTracker != nil {
+		runTracker.AddLink("audit record", location)
+	}
+	out().Summaryf("Instance stack %v: audit record has been uploaded to %v\n", *stack.Name, location)
+	return runErr
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var confirmCanaries bool
+
+// confirmCanary asks on the terminal whether the run should continue past the canary group
+func confirmCanary(ctx context.Context, group types.Group) (bool, error) {
+	if confirmCanaries {
+		return true, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("canary instance group %v requires an interactive terminal or --confirm-canaries", *group.Name)
+	}
+
+	fmt.Fprintf(os.Stderr, "Instance group %v: continue the run past the canary? [y/N] ", *group.Name)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func pauseAfterCanary(ctx context.Context, group types.Group) error {
+	return out().PauseAfterCanary(ctx, group, confirmCanary)
+}
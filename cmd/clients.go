@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ec2Client is the EC2 client of the commands, satisfied by *ec2.Client and by the in-memory fake
+type ec2Client interface {
+	curator.EC2API
+	curator.EC2ImagesAPI
+	curator.EC2TagsAPI
+	curator.EC2AddressesAPI
+}
+
+// autoscalingTagsClient is the Auto Scaling tagging and scaling policies client of the commands,
+// satisfied by *autoscaling.Client and by the tagging view of the in-memory fake
+type autoscalingTagsClient interface {
+	curator.AutoScalingTagsAPI
+	curator.AutoScalingPoliciesAPI
+}
+
+// awsClients holds the AWS service clients used by the commands
+type awsClients struct {
+	ec2             ec2Client
+	autoscaling     curator.AutoScalingAPI
+	autoscalingTags autoscalingTagsClient
+	elbv2           *elbv2.Client
+	route53         *route53.Client
+	rds             *rds.Client
+	ssm             *ssm.Client
+	cloudwatch      *cloudwatch.Client
+	ecs             *ecs.Client
+}
+
+func newAWSClients(cfg aws.Config) *awsClients {
+	autoscalingClient := autoscaling.NewFromConfig(cfg)
+	clients := &awsClients{
+		ec2:             ec2.NewFromConfig(cfg),
+		autoscaling:     autoscalingClient,
+		autoscalingTags: autoscalingClient,
+		elbv2:           elbv2.NewFromConfig(cfg),
+		route53:         route53.NewFromConfig(cfg),
+		rds:             rds.NewFromConfig(cfg),
+		ssm:             ssm.NewFromConfig(cfg),
+		cloudwatch:      cloudwatch.NewFromConfig(cfg),
+		ecs:             ecs.NewFromConfig(cfg),
+	}
+	// the rehearsal with injected failures changes the in-memory fake seeded with the replayed responses
+	if chaosCloud != nil {
+		clients.ec2 = chaosCloud
+		clients.autoscaling = chaosCloud
+		clients.autoscalingTags = chaosCloud.AutoScalingTags()
+	}
+	return clients
+}
+
+// newCurator returns the curator of the action, creating the group images before the shutdown
+// and writing the run metadata tags once a group is shut down or started up
+func newCurator(clients *awsClients, action types.Action) *curator.Curator {
+	return curator.New(append(
+		outputOptions(),
+		curator.WithEC2(clients.ec2),
+		curator.WithEC2Addresses(clients.ec2),
+		curator.WithAutoScaling(clients.autoscaling),
+		curator.WithAutoScalingPolicies(clients.autoscalingTags),
+		curator.WithELBv2(clients.elbv2),
+		curator.WithRoute53(clients.route53),
+		curator.WithRDS(clients.rds),
+		curator.WithSSM(clients.ssm),
+		curator.WithCloudWatch(clients.cloudwatch),
+		curator.WithECS(clients.ecs),
+		curator.WithDryRun(dryRun),
+		curator.WithSizeRecorder(stateSizeRecorder{}),
+		curator.WithHooks(curator.Hooks{
+			BeforeShutdown: func(ctx context.Context, group types.Group, instanceIds []string) error {
+				_, err := out().CreateInstanceGroupImages(ctx, clients.ec2, group, instanceIds, curator.RunTagsPrefix(&stack), runMetadata(types.ActionShutdown))
+				return err
+			},
+			AfterShutdown: func(ctx context.Context, group types.Group, instanceIds []string) error {
+				return tagGroup(ctx, clients, group, instanceIds, types.ActionShutdown)
+			},
+			AfterStartup: func(ctx context.Context, group types.Group, instanceIds []string) error {
+				return tagGroup(ctx, clients, group, instanceIds, action)
+			},
+		}),
+	)...)
+}
+
+// tagGroup writes the run metadata tags of the completed action, if enabled for the stack
+func tagGroup(ctx context.Context, clients *awsClients, group types.Group, instanceIds []string, action types.Action) error {
+	if stack.RunTags == nil {
+		return nil
+	}
+
+	return out().TagInstanceGroup(ctx, clients.ec2, clients.autoscalingTags, group, instanceIds, curator.RunTagsPrefix(&stack), runMetadata(action))
+}
+
+// runMetadata describes the current run of the action
+func runMetadata(action types.Action) curator.RunMetadata {
+	return curator.RunMetadata{
+		Action:    action,
+		RunId:     runId,
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Names of the instance table columns, besides the tag columns prefixed with tagColumnPrefix
+const (
+	columnGroup            = "Group"
+	columnInstanceId       = "InstanceId"
+	columnName             = "Name"
+	columnPrivateIp        = "PrivateIp"
+	columnPublicIp         = "PublicIp"
+	columnState            = "State"
+	columnAvailabilityZone = "AvailabilityZone"
+	columnInstanceType     = "InstanceType"
+	columnLaunchTime       = "LaunchTime"
+	columnAutoScalingGroup = "AutoScalingGroup"
+
+	tagColumnPrefix = "tag:"
+)
+
+// instanceColumn is a column of the instance tables
+type instanceColumn struct {
+	name   string
+	header string
+	value  func(group types.Group, i ec2Types.Instance) string
+}
+
+// instanceColumns are the instance table columns by the name
+var instanceColumns = map[string]instanceColumn{
+	columnGroup: {columnGroup, "Group", func(group types.Group, i ec2Types.Instance) string {
+		return aws.ToString(group.Name)
+	}},
+	columnInstanceId: {columnInstanceId, "Instance ID", func(group types.Group, i ec2Types.Instance) string {
+		return aws.ToString(i.InstanceId)
+	}},
+	columnName: {columnName, "Name", func(group types.Group, i ec2Types.Instance) string {
+		return instanceTag(i, "Name")
+	}},
+	columnPrivateIp: {columnPrivateIp, "Private IP", func(group types.Group, i ec2Types.Instance) string {
+		return aws.ToString(i.PrivateIpAddress)
+	}},
+	columnPublicIp: {columnPublicIp, "Public IP", func(group types.Group, i ec2Types.Instance) string {
+		return aws.ToString(i.PublicIpAddress)
+	}},
+	columnState: {columnState, "State", func(group types.Group, i ec2Types.Instance) string {
+		return instanceState(i)
+	}},
+	columnAvailabilityZone: {columnAvailabilityZone, "Availability zone", func(group types.Group, i ec2Types.Instance) string {
+		if i.Placement == nil {
+			return ""
+		}
+		return aws.ToString(i.Placement.AvailabilityZone)
+	}},
+	columnInstanceType: {columnInstanceType, "Instance type", func(group types.Group, i ec2Types.Instance) string {
+		return string(i.InstanceType)
+	}},
+	columnLaunchTime: {columnLaunchTime, "Launch time", func(group types.Group, i ec2Types.Instance) string {
+		if i.LaunchTime == nil {
+			return ""
+		}
+		return i.LaunchTime.UTC().Format(time.RFC3339)
+	}},
+	columnAutoScalingGroup: {columnAutoScalingGroup, "Auto Scaling Group", func(group types.Group, i ec2Types.Instance) string {
+		return instanceTag(i, "aws:autoscaling:groupName")
+	}},
+}
+
+// defaultColumns are the columns of the instance tables unless given with --columns
+var defaultColumns = []string{columnGroup, columnInstanceId, columnName, columnPrivateIp, columnState}
+
+// wideColumns are the columns --wide adds to the default ones
+var wideColumns = []string{columnAvailabilityZone, columnInstanceType, columnLaunchTime, columnAutoScalingGroup}
+
+var columns []string
+
+// wide adds the columns of the instance placement, type, launch time and Auto Scaling Group to the instance tables
+var wide bool
+
+// tableColumns are the resolved columns of the instance tables
+var tableColumns []instanceColumn
+
+// initColumns resolves the columns of the instance tables from --columns or --wide
+func initColumns() error {
+	names := columns
+	if len(names) == 0 {
+		names = defaultColumns
+		if wide {
+			names = append(append([]string{}, defaultColumns...), wideColumns...)
+		}
+	}
+
+	tableColumns = make([]instanceColumn, 0, len(names))
+	for _, name := range names {
+		column, err := parseColumn(name)
+		if err != nil {
+			return err
+		}
+		tableColumns = append(tableColumns, column)
+	}
+	return nil
+}
+
+// parseColumn returns the instance table column of the name, matched case-insensitively, or of the tag:Key
+func parseColumn(name string) (instanceColumn, error) {
+	name = strings.TrimSpace(name)
+	if len(name) > len(tagColumnPrefix) && strings.EqualFold(name[:len(tagColumnPrefix)], tagColumnPrefix) {
+		key := name[len(tagColumnPrefix):]
+		return instanceColumn{name, key, func(group types.Group, i ec2Types.Instance) string {
+			return instanceTag(i, key)
+		}}, nil
+	}
+
+	for n, column := range instanceColumns {
+		if strings.EqualFold(n, name) {
+			return column, nil
+		}
+	}
+	return instanceColumn{}, fmt.Errorf("unsupported column %q, expected any of %v or %vKey", name, columnNames(), tagColumnPrefix)
+}
+
+// columnNames returns the names of the instance table columns in the order of the defaults and the wide ones
+func columnNames() []string {
+	names := append(append([]string{}, defaultColumns...), wideColumns...)
+	return append(names, columnPublicIp)
+}
+
+// instanceTag returns the value of the instance tag, empty if it is not tagged
+func instanceTag(i ec2Types.Instance, key string) string {
+	for _, t := range i.Tags {
+		if aws.ToString(t.Key) == key {
+			return aws.ToString(t.Value)
+		}
+	}
+	return ""
+}
+
+// instanceState returns the name of the instance state, empty if unknown
+func instanceState(i ec2Types.Instance) string {
+	if i.State == nil {
+		return ""
+	}
+	return string(i.State.Name)
+}
+
+// Keys the instance table rows are sorted by
+const (
+	sortByName       = "name"
+	sortByInstanceId = "instance-id"
+	sortByIp         = "ip"
+	sortByState      = "state"
+	sortByLaunchTime = "launch-time"
+)
+
+var sortKeys = []string{sortByName, sortByInstanceId, sortByIp, sortByState, sortByLaunchTime}
+
+var sortBy string
+
+// checkSortBy validates the key the instance table rows are sorted by
+func checkSortBy() error {
+	if sortBy != "" && !slices.Contains(sortKeys, sortBy) {
+		return fmt.Errorf("unsupported sort key %q, expected any of %v", sortBy, sortKeys)
+	}
+	return nil
+}
+
+// sortInstances returns the instances in the order of the instance table rows, sorted by the --sort-by key
+// and then by the instance ID, or in the described order unless sorted
+func sortInstances(instances []ec2Types.Instance) []ec2Types.Instance {
+	if sortBy == "" {
+		return instances
+	}
+
+	sorted := slices.Clone(instances)
+	slices.SortStableFunc(sorted, func(a, b ec2Types.Instance) int {
+		var c int
+		switch sortBy {
+		case sortByName:
+			c = strings.Compare(instanceTag(a, "Name"), instanceTag(b, "Name"))
+		case sortByIp:
+			c = compareIPs(aws.ToString(a.PrivateIpAddress), aws.ToString(b.PrivateIpAddress))
+		case sortByState:
+			c = strings.Compare(instanceState(a), instanceState(b))
+		case sortByLaunchTime:
+			c = aws.ToTime(a.LaunchTime).Compare(aws.ToTime(b.LaunchTime))
+		}
+		if c != 0 {
+			return c
+		}
+		return strings.Compare(aws.ToString(a.InstanceId), aws.ToString(b.InstanceId))
+	})
+	return sorted
+}
+
+// compareIPs compares the IP addresses numerically, the invalid or missing ones first
+func compareIPs(a, b string) int {
+	ipA, errA := netip.ParseAddr(a)
+	ipB, errB := netip.ParseAddr(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return ipA.Compare(ipB)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/spec"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// stackFileExtensions are the extensions of the stack spec and plan files
+var stackFileExtensions = []string{"yml", "yaml"}
+
+// registerCompletions registers the dynamic completions of the flags, once every command has defined its flags
+func registerCompletions() {
+	completeFiles := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return stackFileExtensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+
+	rootCmd.RegisterFlagCompletionFunc("stack", completeFiles)
+	rootCmd.RegisterFlagCompletionFunc("groups", completeGroups)
+	rootCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]string{outputPretty, outputPlain, outputJSON, outputCSV}, cobra.ShellCompDirectiveNoFileComp,
+	))
+	rootCmd.RegisterFlagCompletionFunc("columns", cobra.FixedCompletions(
+		append(columnNames(), tagColumnPrefix), cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace,
+	))
+	rootCmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions(
+		sortKeys, cobra.ShellCompDirectiveNoFileComp,
+	))
+	rootCmd.RegisterFlagCompletionFunc("instance-states", cobra.FixedCompletions(
+		[]string{"pending", "running", "shutting-down", "terminated", "stopping", "stopped"}, cobra.ShellCompDirectiveNoFileComp,
+	))
+	rootCmd.RegisterFlagCompletionFunc("report", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "md"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	completeRecordings := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	rootCmd.RegisterFlagCompletionFunc("record", completeRecordings)
+	rootCmd.RegisterFlagCompletionFunc("replay", completeRecordings)
+
+	shutdownCmd.RegisterFlagCompletionFunc("strategy", cobra.FixedCompletions(
+		[]string{string(types.ShutdownStrategySequential), string(types.ShutdownStrategyTwoPhase)}, cobra.ShellCompDirectiveNoFileComp,
+	))
+	planCmd.RegisterFlagCompletionFunc("action", cobra.FixedCompletions(
+		[]string{string(types.ActionShutdown), string(types.ActionStartup)}, cobra.ShellCompDirectiveNoFileComp,
+	))
+	planCmd.RegisterFlagCompletionFunc("out", completeFiles)
+	applyCmd.RegisterFlagCompletionFunc("plan", completeFiles)
+	workerCmd.RegisterFlagCompletionFunc("stack-dir", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+}
+
+// completeGroups completes the names of the groups of the selected stack spec
+func completeGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !localStackFile() {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	source, err := readStackSource()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var s types.Stack
+	if err := yaml.Unmarshal(source, &s); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(s.Groups))
+	for _, g := range s.Groups {
+		if g.Name != nil {
+			names = append(names, *g.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEnvironments completes the names of the environments of the selected stack spec
+func completeEnvironments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !localStackFile() {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	source, err := os.ReadFile(stackFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names, err := spec.Environments(source)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readStackSource reads the selected stack spec with the overlay of the selected environment, if any
+func readStackSource() ([]byte, error) {
+	source, err := os.ReadFile(stackFile)
+	if err != nil {
+		return nil, err
+	}
+
+	_, source, err = spec.Overlay(source, environment)
+	return source, err
+}
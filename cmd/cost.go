@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var costHours float64
+
+// costCmd represents the cost command
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Estimate shutdown savings of instance stack",
+	Long: `Resolve the instance stack and estimate the on-demand compute cost saved
+by keeping its running instances down for the given number of hours.
+
+Prices are looked up with the AWS Price List API for the instance types, platforms and tenancy
+of the resolved instances. Storage and other charges which continue while instances are stopped
+are not accounted.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if costHours <= 0 {
+			return fmt.Errorf("invalid number of hours %v, expected a positive number", costHours)
+		}
+
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		pricingClient := pricing.NewFromConfig(cfg, func(o *pricing.Options) {
+			o.Region = curator.PricingRegion
+		})
+
+		groups := make([]types.Group, 0, len(stack.Groups))
+		for _, group := range curator.OrderGroups(&stack, types.ActionShutdown) {
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		}
+
+		estimate, err := curator.EstimateShutdownSavings(ctx, pricingClient, cfg.Region, groups, costHours)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]string, 0, len(estimate.Instances))
+		for _, i := range estimate.Instances {
+			rows = append(rows, []string{
+				i.Group,
+				i.InstanceId,
+				i.InstanceType,
+				i.Platform,
+				fmt.Sprintf("$%.4f", i.HourlyPrice),
+			})
+		}
+		out().PrintTable(curator.Table{
+			Name:             "cost",
+			Header:           []string{"Group", "Instance ID", "Instance type", "Platform", "Hourly price"},
+			Rows:             rows,
+			MergeFirstColumn: true,
+		})
+
+		out().Summaryf(
+			"Instance stack %v: %v running instances cost $%.4f per hour, keeping them down for %v hours saves $%.2f\n",
+			*stack.Name, len(estimate.Instances), estimate.HourlyPrice, estimate.Hours, estimate.Savings,
+		)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(costCmd)
+
+	// Local flags which will only run when this command is called directly
+	costCmd.Flags().Float64Var(&costHours, "hours", 12, "Number of hours the instance stack is kept down")
+}
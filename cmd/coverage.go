@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+var strictCoverage bool
+
+// guardUncoveredInstances reports the InService instances of the Auto Scaling Groups of the stack matched
+// by no group, as lowering the MinSize by the curated instances alone may let the ASGs terminate them.
+// With --strict the run fails before any change instead.
+func guardUncoveredInstances(ctx context.Context, clients *awsClients) error {
+	uncovered, err := out().FindUncoveredInstances(ctx, clients.ec2, clients.autoscaling, &stack)
+	if err != nil {
+		return err
+	}
+
+	if len(uncovered) == 0 {
+		return nil
+	}
+
+	asgNames := make([]string, 0, len(uncovered))
+	for name := range uncovered {
+		asgNames = append(asgNames, name)
+	}
+	sort.Strings(asgNames)
+
+	for _, name := range asgNames {
+		out().Summaryf("Warning: InService instances %v of Auto Scaling Group %v are not covered by any instance group\n", uncovered[name], name)
+	}
+	if strictCoverage {
+		return fmt.Errorf("instance stack %v has Auto Scaling Groups %v with instances not covered by any instance group", *stack.Name, asgNames)
+	}
+	return nil
+}
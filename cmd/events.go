@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/notify"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+var eventsFile string
+var eventsFileWriter *os.File
+
+// eventHandler receives the orchestration events of the command, if set
+var eventHandler func(curator.Event)
+
+// output receives the messages and the tables, which must not interleave with the events streamed to stdout
+var output io.Writer = os.Stdout
+
+// initEvents records the orchestration events for the run report and streams them as JSON lines
+// to the events file, or to stdout for "-", if any
+func initEvents() error {
+	var encoder *json.Encoder
+	switch eventsFile {
+	case "":
+	case "-":
+		output = os.Stderr
+		encoder = json.NewEncoder(os.Stdout)
+	default:
+		f, err := os.OpenFile(eventsFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		eventsFileWriter = f
+		encoder = json.NewEncoder(f)
+	}
+
+	var mu sync.Mutex
+	eventHandler = func(e curator.Event) {
+		if runTracker != nil {
+			runTracker.RecordEvent(e)
+			notifyCapacityRetry(e)
+		}
+		if encoder == nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := encoder.Encode(e); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing event: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// notifyCapacityRetry forwards the capacity retries of the groups configured to notify them to the notifiers
+func notifyCapacityRetry(e curator.Event) {
+	if e.Type != curator.EventCapacityRetry {
+		return
+	}
+
+	group, ok := curator.FindGroup(&stack, e.Group)
+	if !ok || group.CapacityRetry == nil || !group.CapacityRetry.Notify {
+		return
+	}
+	publish(notify.Event{
+		Type:    notify.EventCapacityRetry,
+		Time:    e.Time,
+		Message: fmt.Sprintf("Instance group %v: insufficient capacity to start instances %v: %v", e.Group, e.InstanceIds, e.Error),
+	})
+}
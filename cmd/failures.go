@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// printFailures renders the individual failures of the run with their group, Auto Scaling Group and instances
+func printFailures(summary run.Summary) {
+	if len(summary.Failures) == 0 {
+		return
+	}
+
+	rows := make([][]string, 0, len(summary.Failures))
+	for _, f := range summary.Failures {
+		rows = append(rows, []string{f.Group, f.AutoScalingGroupName, strings.Join(f.InstanceIds, ", "), f.Error})
+	}
+
+	out().PrintTable(curator.Table{
+		Name:   "failures",
+		Header: []string{"Group", "ASG", "Instances", "Error"},
+		Rows:   rows,
+	})
+}
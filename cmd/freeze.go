@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// freezeCmd represents the freeze command
+var freezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "Put instance stack into Standby without stopping it",
+	Long: `Put the InService Auto Scaling instances of every group into Standby in the shutdown order,
+keeping the instances running, e.g. for maintenance which the Auto Scaling health checks
+and scaling activities would interfere with. The MinSize of the Auto Scaling Groups is lowered
+when required. The thaw command returns the instances to service.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionFreeze)
+		if err := beginRun(ctx, types.ActionFreeze, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
+		}
+
+		for _, group := range curator.OrderGroups(&stack, types.ActionShutdown) {
+			if completedByResumedRun(group) {
+				continue
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+
+			if len(group.Instances) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
+			}
+			if err := c.FreezeGroup(ctx, group, instanceIds); err != nil {
+				return err
+			}
+			if !dryRun {
+				out().Printf("Instance group %v: instances %v have been frozen\n", *group.Name, instanceIds)
+			}
+		}
+
+		out().Summaryf("Instance stack %v: freeze has been completed\n", *stack.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(freezeCmd)
+
+	// Local flags which will only run when this command is called directly
+	freezeCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	freezeCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+}
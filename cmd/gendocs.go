@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var manDir, markdownDir string
+
+// genDocsCmd represents the gen-docs command
+var genDocsCmd = &cobra.Command{
+	Use:    "gen-docs",
+	Short:  "Generate the man pages and the Markdown reference of the commands",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the generated documents do not change between the runs unless the commands do
+		rootCmd.DisableAutoGenTag = true
+
+		if manDir != "" {
+			if err := os.MkdirAll(manDir, 0755); err != nil {
+				return err
+			}
+			header := &doc.GenManHeader{
+				Title:   "INSTANCE-STACK-CURATOR",
+				Section: "1",
+				Source:  "instance-stack-curator",
+				Manual:  "Instance Stack Curator Manual",
+			}
+			// SOURCE_DATE_EPOCH makes the date of the man pages reproducible for the packaged distributions
+			if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+				date := time.Unix(epoch, 0).UTC()
+				header.Date = &date
+			}
+			if err := doc.GenManTree(rootCmd, header, manDir); err != nil {
+				return err
+			}
+			out().Summaryf("Man pages have been generated in %v\n", manDir)
+		}
+
+		if markdownDir != "" {
+			if err := os.MkdirAll(markdownDir, 0755); err != nil {
+				return err
+			}
+			if err := doc.GenMarkdownTree(rootCmd, markdownDir); err != nil {
+				return err
+			}
+			out().Summaryf("Markdown reference has been generated in %v\n", markdownDir)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(genDocsCmd)
+
+	// Local flags which will only run when this command is called directly
+	genDocsCmd.Flags().StringVar(&manDir, "man-dir", "docs/man", "Directory the man pages are generated in, none if empty")
+	genDocsCmd.Flags().StringVar(&markdownDir, "markdown-dir", "docs/reference", "Directory the Markdown reference is generated in, none if empty")
+}
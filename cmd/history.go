@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// historyRetention is the number of the latest runs of a stack kept in the history
+const historyRetention = 100
+
+var historyLimit int
+
+// historyKey returns the key of the run summaries of the stack, the latest first
+func historyKey() string {
+	return fmt.Sprintf("%v-history.json", *stack.Name)
+}
+
+// runReportKey returns the key of the report of the run of the stack
+func runReportKey(runId string) string {
+	return fmt.Sprintf("%v-run-%v.json", *stack.Name, runId)
+}
+
+// readHistory reads the run summaries of the stack, the latest first
+func readHistory(ctx context.Context) ([]run.Summary, error) {
+	data, err := stateStore.Get(ctx, historyKey())
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var summaries []run.Summary
+	if err := json.Unmarshal(data, &summaries); err != nil {
+		return nil, fmt.Errorf("invalid run history %v: %w", stateStore.Location(historyKey()), err)
+	}
+	return summaries, nil
+}
+
+// recordHistory adds the report of the finished run to the history of the stack in the state backend,
+// dropping the runs beyond the retention. The report of a cancelled run is recorded as well.
+func recordHistory(ctx context.Context, report run.Report) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), curator.CleanupTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if err := stateStore.Put(ctx, runReportKey(report.Summary.RunId), data); err != nil {
+		return err
+	}
+
+	summaries, err := readHistory(ctx)
+	if err != nil {
+		return err
+	}
+	summaries = append([]run.Summary{report.Summary}, summaries...)
+	for _, s := range summaries[min(len(summaries), historyRetention):] {
+		if err := stateStore.Delete(ctx, runReportKey(s.RunId)); err != nil {
+			return err
+		}
+	}
+	summaries = summaries[:min(len(summaries), historyRetention)]
+
+	data, err = json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return stateStore.Put(ctx, historyKey(), data)
+}
+
+// initHistory initializes the state backend the history of the stack is read from
+func initHistory(ctx context.Context) error {
+	if err := initStack(ctx); err != nil {
+		return err
+	}
+
+	cfg, err := initAWS(ctx)
+	if err != nil {
+		return err
+	}
+	initStateStore(cfg)
+	return nil
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List prior runs of instance stack",
+	Long: `List the prior runs of the stack recorded in its state backend, the latest first:
+the action, the user, the start time, the duration, the result and the groups processed.
+The full report of a run is printed by the history show command.
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initHistory(cmd.Context()); err != nil {
+			return err
+		}
+
+		summaries, err := readHistory(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			out().Summaryf("Instance stack %v: no runs recorded in %v\n", *stack.Name, stateStore.Location(historyKey()))
+			return nil
+		}
+		if historyLimit > 0 && len(summaries) > historyLimit {
+			summaries = summaries[:historyLimit]
+		}
+
+		rows := make([][]string, 0, len(summaries))
+		for _, s := range summaries {
+			groups := make([]string, 0, len(s.Groups))
+			for _, g := range s.Groups {
+				groups = append(groups, g.Name)
+			}
+			rows = append(rows, []string{
+				s.RunId,
+				s.Action,
+				s.User,
+				s.StartedAt.Local().Format(time.RFC3339),
+				formatTiming(s.Duration()),
+				s.Result,
+				strings.Join(groups, ", "),
+			})
+		}
+
+		out().PrintTable(curator.Table{
+			Name:   "history",
+			Header: []string{"Run ID", "Action", "User", "Started", "Duration", "Result", "Groups"},
+			Rows:   rows,
+		})
+		return nil
+	},
+}
+
+// historyShowCmd represents the history show command
+var historyShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Print the report of a prior run of instance stack",
+	Long: `Print the full report of a prior run of the stack recorded in its state backend,
+as Markdown, or as JSON with --output json.
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initHistory(cmd.Context()); err != nil {
+			return err
+		}
+
+		key := runReportKey(args[0])
+		data, err := stateStore.Get(cmd.Context(), key)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return fmt.Errorf("no run %v of instance stack %v in %v", args[0], *stack.Name, stateStore.Location(key))
+		}
+
+		var report run.Report
+		if err := json.Unmarshal(data, &report); err != nil {
+			return fmt.Errorf("invalid run report %v: %w", stateStore.Location(key), err)
+		}
+
+		if outputFormat == outputJSON {
+			return report.WriteJSON(output)
+		}
+		return report.WriteMarkdown(output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	// Local flags which will only run when this command is called directly
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "Maximum number of runs listed, all the recorded runs if 0")
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/validator"
+)
+
+var lintResolve, lintStrict bool
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Lint instance stack",
+	Long: `Lint the valid instance stack for settings which may not act as intended: groups with identical
+filters, groups whose filters can never match, and deprecated fields. With --resolve the group instances
+are resolved as well, warning about the groups matching no instances and the instances without a Name tag.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		warnings := validator.Lint(&stack, stackFileName(), stackDocument)
+		if lintResolve {
+			cfg, err := initAWS(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			resolved, err := lintInstances(cmd.Context(), newAWSClients(cfg))
+			if err != nil {
+				return err
+			}
+			warnings = append(warnings, resolved...)
+		}
+
+		for _, w := range warnings {
+			out().Summaryf("Warning: %v\n", w)
+		}
+		if len(warnings) == 0 {
+			out().Summaryf("Instance stack %v: no lint warnings\n", *stack.Name)
+			return nil
+		}
+		if lintStrict {
+			return fmt.Errorf("instance stack %v has %v lint warnings", *stack.Name, len(warnings))
+		}
+		return nil
+	},
+}
+
+// lintInstances resolves the group instances, warning about the groups matching no instances
+// and the instances without a Name tag
+func lintInstances(ctx context.Context, clients *awsClients) ([]validator.Warning, error) {
+	var warnings []validator.Warning
+	for _, group := range stack.Groups {
+		// groups without filters consist of clusters only
+		if len(group.Filters) == 0 {
+			continue
+		}
+
+		if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+			return nil, err
+		}
+		if len(group.Instances) == 0 {
+			warnings = append(warnings, validator.NewGroupWarning(stackDocument, stackFileName(), *group.Name, "filters",
+				"group is unreachable, no instances are matched"))
+			continue
+		}
+
+		for _, instance := range group.Instances {
+			named := slices.ContainsFunc(instance.Tags, func(t ec2Types.Tag) bool {
+				return aws.ToString(t.Key) == "Name" && aws.ToString(t.Value) != ""
+			})
+			if !named {
+				warnings = append(warnings, validator.NewGroupWarning(stackDocument, stackFileName(), *group.Name, "",
+					fmt.Sprintf("instance %v has no Name tag", aws.ToString(instance.InstanceId))))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	// Local flags which will only run when this command is called directly
+	lintCmd.Flags().BoolVar(&lintResolve, "resolve", false, "Resolve the group instances, warning about the groups matching no instances and the instances without a Name tag")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Fail when there are lint warnings")
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"syscall"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/state"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// runLock is the content of the advisory lock of a stack, held by a mutating run
+type runLock struct {
+	PID       int       `json:"pid"`
+	User      string    `json:"user"`
+	Host      string    `json:"host"`
+	Action    string    `json:"action"`
+	RunId     string    `json:"runId"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+var lockFile string
+var noLock bool
+
+// heldLockStore and heldLockKey locate the lock held by the run, if any
+var heldLockStore state.Store
+var heldLockKey string
+
+// runLockKey returns the store and the key of the lock of the stack
+func runLockKey() (state.Store, string) {
+	return stateStoreKey(lockFile, fmt.Sprintf("%v.lock", *stack.Name))
+}
+
+// maxLockAttempts is the number of attempts to create the lock of the stack, removed or taken over meanwhile
+const maxLockAttempts = 3
+
+// acquireLock takes the advisory lock of the stack, refusing to run while another live run of this host
+// or any run of another host holds it. The lock of a run which is no longer alive is taken over.
+// The lock is only exclusive across hosts with a state backend creating the keys conditionally, e.g. DynamoDB.
+func acquireLock(ctx context.Context, action types.Action) error {
+	if noLock {
+		return nil
+	}
+
+	store, key := runLockKey()
+	path := store.Location(key)
+
+	host, _ := os.Hostname()
+	lock := runLock{
+		PID:       os.Getpid(),
+		User:      currentUser(),
+		Host:      host,
+		Action:    string(action),
+		RunId:     runId,
+		StartedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxLockAttempts; attempt++ {
+		created, err := store.Create(ctx, key, data)
+		if err != nil {
+			return err
+		}
+		if created {
+			heldLockStore, heldLockKey = store, key
+			return nil
+		}
+
+		held, heldData, err := readLock(ctx, store, key)
+		if err != nil {
+			return err
+		}
+		// the lock has been released meanwhile, so that it is created again
+		if held == nil {
+			continue
+		}
+		// only the lock of a dead process of this host is taken over, as the processes of other hosts cannot be checked
+		if held.Host != host || processAlive(held.PID) {
+			return fmt.Errorf("instance stack %v is locked by %v run %v of %v on %v, pid %v, since %v; remove %v if the run is gone",
+				*stack.Name, held.Action, held.RunId, held.User, held.Host, held.PID, held.StartedAt.Format(time.RFC3339), path)
+		}
+
+		// the stale lock is removed only if it has not been taken over by a concurrent run meanwhile
+		out().Printf("Warning: removing stale lock %v of a run which is no longer alive\n", path)
+		if _, err := store.DeleteIf(ctx, key, heldData); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("instance stack %v is locked by a concurrent run, see %v", *stack.Name, path)
+}
+
+// readLock reads the lock and its content, returning nil if it has been removed meanwhile. A lock of an invalid
+// content is considered held, as it may be of a run of another version or written by hand, and fails the run.
+func readLock(ctx context.Context, store state.Store, key string) (*runLock, []byte, error) {
+	data, err := store.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+
+	var lock runLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, nil, fmt.Errorf("instance stack %v is locked by an unreadable lock %v; remove it if no run is going on: %w",
+			*stack.Name, store.Location(key), err)
+	}
+	return &lock, data, nil
+}
+
+// releaseLock removes the lock held by the run, if any, unless it has been taken over by another run.
+// The lock is released even if the run has been cancelled.
+func releaseLock(ctx context.Context) error {
+	if heldLockStore == nil {
+		return nil
+	}
+	store, key := heldLockStore, heldLockKey
+	heldLockStore, heldLockKey = nil, ""
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), curator.CleanupTimeout)
+	defer cancel()
+
+	held, data, err := readLock(ctx, store, key)
+	if err != nil {
+		return err
+	}
+	if held == nil || held.RunId != runId {
+		out().Printf("Warning: lock %v is no longer held by run %v, leaving it\n", store.Location(key), runId)
+		return nil
+	}
+
+	removed, err := store.DeleteIf(ctx, key, data)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		out().Printf("Warning: lock %v has been taken over by another run, leaving it\n", store.Location(key))
+	}
+	return nil
+}
+
+// processAlive reports whether the process of this host is running
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// currentUser returns the name of the user running the curator
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
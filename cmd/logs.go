@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go/logging"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/logstream"
+)
+
+var logStreamWriter *logstream.Writer
+var logStreamName string
+
+// initLogStream streams the structured logs of the run to a log stream of the stack log group
+func initLogStream(ctx context.Context, cfg aws.Config) error {
+	cfg = cfg.Copy()
+	if stack.Logs.Region != nil {
+		cfg.Region = *stack.Logs.Region
+	}
+	// the log stream calls are not logged themselves, as the logs would be written back to the stream
+	cfg.APIOptions = nil
+	cfg.ClientLogMode = 0
+	cfg.Logger = logging.Nop{}
+
+	logStreamName = fmt.Sprintf("%v/%v", *stack.Name, runId)
+
+	var err error
+	logStreamWriter, err = logstream.Open(ctx, cloudwatchlogs.NewFromConfig(cfg), *stack.Logs.LogGroup, logStreamName)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = logStreamWriter
+	if logFileWriter != nil {
+		w = io.MultiWriter(logFileWriter, logStreamWriter)
+	}
+	logger = newLogger(w)
+	return nil
+}
+
+// closeLogStream puts the remaining logs to the log stream
+func closeLogStream() {
+	if err := logStreamWriter.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/notify"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var runTracker *run.Tracker
+var notifiers []notify.Notifier
+var reportFiles []string
+
+// runContext is the context of the tracked run, whose events are delivered to the notifiers
+var runContext = context.Background()
+
+// notifyTimeout bounds the delivery of a run event to a single notifier, so that a hung notifier
+// neither blocks the run nor the other notifiers
+const notifyTimeout = time.Minute
+
+// beginRun initializes the state backend of the stack, starts tracking the groups processed by the action for the run summary
+// and notifies the configured notifiers of the run start
+func beginRun(ctx context.Context, action types.Action, cfg aws.Config) error {
+	initStateStore(cfg)
+	if err := loadRunState(ctx, action); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := checkOperationWindows(action); err != nil {
+		return err
+	}
+
+	if err := acquireLock(ctx, action); err != nil {
+		return err
+	}
+
+	if stack.Notifications != nil {
+		if email := stack.Notifications.Email; email != nil {
+			cfg := cfg.Copy()
+			if email.Region != nil {
+				cfg.Region = *email.Region
+			}
+			notifiers = append(notifiers, notify.NewEmailNotifier(sesv2.NewFromConfig(cfg), email))
+		}
+
+		if metrics := stack.Notifications.Metrics; metrics != nil {
+			cfg := cfg.Copy()
+			if metrics.Region != nil {
+				cfg.Region = *metrics.Region
+			}
+			notifiers = append(notifiers, notify.NewMetricsNotifier(cloudwatch.NewFromConfig(cfg), metrics))
+		}
+
+		if eb := stack.Notifications.EventBridge; eb != nil {
+			cfg := cfg.Copy()
+			if eb.Region != nil {
+				cfg.Region = *eb.Region
+			}
+			notifiers = append(notifiers, notify.NewEventBridgeNotifier(eventbridge.NewFromConfig(cfg), eb))
+		}
+
+		for _, w := range stack.Notifications.Webhooks {
+			n, err := notify.NewWebhookNotifier(http.DefaultClient, w)
+			if err != nil {
+				return err
+			}
+			notifiers = append(notifiers, n)
+		}
+	}
+
+	runContext = ctx
+	runTracker = run.NewTracker(*stack.Name, string(action), runId, currentUser())
+	summary := runTracker.Summary()
+	out().Emit(curator.Event{Type: curator.EventRunStarted, Time: summary.StartedAt, Message: fmt.Sprintf("%v %v", summary.Action, summary.RunId)})
+	publish(notify.Event{Type: notify.EventRunStarted, Time: summary.StartedAt})
+	return nil
+}
+
+// trackGroup records the start of the group processing in the run summary
+func trackGroup(group types.Group) {
+	if runTracker == nil {
+		return
+	}
+
+	completeGroup(nil)
+	started := runTracker.StartGroup(*group.Name)
+	out().Emit(curator.Event{Type: curator.EventGroupStarted, Time: started.StartedAt, Group: started.Name})
+	publish(notify.Event{Type: notify.EventGroupStarted, Time: started.StartedAt, Group: &started})
+}
+
+// suspendGroup records the suspension of the group being processed, if any, to be resumed by resumeGroup
+func suspendGroup() {
+	if runTracker == nil {
+		return
+	}
+	runTracker.SuspendGroup()
+}
+
+// resumeGroup records the resumption of the suspended group processing in the run summary
+func resumeGroup(group types.Group) {
+	if runTracker == nil {
+		return
+	}
+
+	completeGroup(nil)
+	runTracker.ResumeGroup(*group.Name)
+}
+
+// completeGroup records the completion of the group being processed, if any
+func completeGroup(err error) {
+	completed := runTracker.CompleteGroup(err)
+	if completed == nil {
+		return
+	}
+
+	out().Emit(curator.Event{Type: curator.EventGroupCompleted, Time: completed.FinishedAt, Group: completed.Name, Error: completed.Error})
+	publish(notify.Event{Type: notify.EventGroupCompleted, Time: completed.FinishedAt, Group: completed})
+}
+
+// finishRun completes the run summary and notifies the configured notifiers of the run completion
+func finishRun(ctx context.Context, runErr error) error {
+	completeGroup(runErr)
+	if runErr != nil {
+		out().Emit(curator.Event{Type: curator.EventError, Error: runErr.Error()})
+	}
+
+	if logFile != "" {
+		runTracker.AddLink("log file", logFile)
+	}
+	if logStreamWriter != nil {
+		runTracker.AddLink("log stream", fmt.Sprintf("%v/%v", *stack.Logs.LogGroup, logStreamName))
+	}
+	summary := runTracker.Finish(runErr)
+	printTimings(summary)
+	printFailures(summary)
+	if err := saveRunState(ctx, summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to save run state: %v\n", err)
+		runErr = errors.Join(runErr, err)
+	}
+	out().Emit(curator.Event{Type: curator.EventRunFinished, Time: summary.FinishedAt, Message: summary.Result})
+
+	if err := publish(notify.Event{Type: notify.EventRunFinished, Time: summary.FinishedAt, Summary: &summary}); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	report := runTracker.Report()
+	if err := recordHistory(ctx, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to record run history: %v\n", err)
+		runErr = errors.Join(runErr, err)
+	}
+	for _, path := range reportFiles {
+		if err := report.WriteFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			runErr = errors.Join(runErr, err)
+			continue
+		}
+		out().Summaryf("Instance stack %v: run report has been written to %v\n", *stack.Name, path)
+	}
+	return runErr
+}
+
+// publish delivers the run event to every notifier. A failed delivery is reported
+// but does not interrupt the run.
+func publish(event notify.Event) error {
+	summary := runTracker.Summary()
+	event.Stack, event.Action, event.RunId = summary.Stack, summary.Action, summary.RunId
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	// the events of a cancelled run, e.g. its completion, are delivered as well
+	ctx := context.WithoutCancel(runContext)
+	var errs []error
+	for _, n := range notifiers {
+		if err := notifyWithTimeout(ctx, n, event); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyWithTimeout delivers the run event to the notifier within notifyTimeout
+func notifyWithTimeout(ctx context.Context, n notify.Notifier, event notify.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return n.Notify(ctx, event)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// Output formats of the messages and the tables
+const (
+	outputPretty = "pretty"
+	outputPlain  = "plain"
+	outputJSON   = "json"
+	outputCSV    = "csv"
+)
+
+var outputFormat string
+
+// reporter receives the messages and the tables of the command in the output format
+var reporter curator.Reporter
+
+// logger receives the structured logs of the command, if any
+var logger *slog.Logger
+
+// initReporter reports the messages and the tables to the output in the output format
+func initReporter() error {
+	switch outputFormat {
+	case outputPretty:
+		f, ok := output.(*os.File)
+		reporter = curator.NewPrettyReporter(output, ok && term.IsTerminal(int(f.Fd())))
+	case outputPlain:
+		reporter = curator.NewPlainReporter(output)
+	case outputJSON:
+		reporter = curator.NewJSONReporter(output)
+	case outputCSV:
+		// the progress messages go to stderr, so that the output may be redirected to a spreadsheet
+		reporter = curator.NewCSVReporter(output, os.Stderr)
+	default:
+		return fmt.Errorf("unsupported output format %q, expected %v, %v, %v or %v", outputFormat, outputPretty, outputPlain, outputJSON, outputCSV)
+	}
+	return nil
+}
+
+// outputOptions makes a curator report, log and deliver the events of the command
+func outputOptions() []curator.Option {
+	return []curator.Option{
+		curator.WithReporter(reporter),
+		curator.WithVerbosity(getVerbosity()),
+		curator.WithLogger(logger),
+		curator.WithEventHandler(eventHandler),
+	}
+}
+
+// out returns the curator printing the messages and the tables of the command
+func out() *curator.Curator {
+	return curator.New(outputOptions()...)
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"golang.org/x/term"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// errPickAborted is returned when the operator aborts the selection of the groups or the instances
+var errPickAborted = errors.New("selection has been aborted")
+
+var pick bool
+
+// checkPick validates that the groups and the instances may be picked on an interactive terminal
+func checkPick() error {
+	if pick && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("--pick requires an interactive terminal")
+	}
+	return nil
+}
+
+// pickGroups keeps the stack groups checked by the operator in a checklist, if picking
+func pickGroups() error {
+	if !pick || len(stack.Groups) == 0 {
+		return nil
+	}
+
+	items := make([]string, 0, len(stack.Groups))
+	for _, g := range stack.Groups {
+		items = append(items, *g.Name)
+	}
+	checked, err := pickItems(fmt.Sprintf("Instance stack %v: select the groups", *stack.Name), items)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(checked))
+	for _, i := range checked {
+		names = append(names, items[i])
+	}
+	return selectGroups(names)
+}
+
+// pickGroupInstances keeps the group instances checked by the operator in a checklist, if picking
+func pickGroupInstances(group *types.Group) error {
+	if !pick || len(group.Instances) == 0 {
+		return nil
+	}
+
+	items := make([]string, 0, len(group.Instances))
+	for _, i := range group.Instances {
+		items = append(items, strings.Join([]string{*i.InstanceId, instanceTag(i, "Name"), instanceState(i)}, "  "))
+	}
+	checked, err := pickItems(fmt.Sprintf("Instance group %v: select the instances", *group.Name), items)
+	if err != nil {
+		return err
+	}
+
+	excluded := make([]string, 0)
+	instances := make([]ec2Types.Instance, 0, len(checked))
+	for n, i := range group.Instances {
+		if slices.Contains(checked, n) {
+			instances = append(instances, i)
+		} else {
+			excluded = append(excluded, *i.InstanceId)
+		}
+	}
+	if len(excluded) > 0 {
+		out().Printf("Instance group %v: instances excluded by the operator: %v\n", *group.Name, excluded)
+		group.AllInstances = group.Instances
+	}
+	group.Instances = instances
+	return nil
+}
+
+// pickItems shows the items on the terminal in a checklist, all of them checked, and returns the indexes
+// of the items checked by the operator once confirmed. At least one item must remain checked.
+func pickItems(title string, items []string) ([]int, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, state)
+
+	checked := make([]bool, len(items))
+	for i := range checked {
+		checked[i] = true
+	}
+	cursor, lines := 0, 0
+	hint := "up/down or k/j: move, space: toggle, a: toggle all, enter: confirm, q: abort"
+	buf := make([]byte, 3)
+	for {
+		lines = renderChecklist(os.Stderr, title, hint, items, checked, cursor, lines)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		switch key := string(buf[:n]); key {
+		case "\x1b[A", "k":
+			cursor = (cursor + len(items) - 1) % len(items)
+		case "\x1b[B", "j":
+			cursor = (cursor + 1) % len(items)
+		case " ":
+			checked[cursor] = !checked[cursor]
+		case "a":
+			all := !slices.Contains(checked, false)
+			for i := range checked {
+				checked[i] = !all
+			}
+		case "\r", "\n":
+			selected := make([]int, 0, len(items))
+			for i, c := range checked {
+				if c {
+					selected = append(selected, i)
+				}
+			}
+			if len(selected) > 0 {
+				return selected, nil
+			}
+			hint = "select at least one item, or abort with q"
+		case "q", "\x03", "\x1b":
+			return nil, errPickAborted
+		}
+	}
+}
+
+// renderChecklist draws the checklist over the previously drawn lines and returns the number of the drawn lines
+func renderChecklist(w io.Writer, title, hint string, items []string, checked []bool, cursor, lines int) int {
+	if lines > 0 {
+		fmt.Fprintf(w, "\x1b[%dA", lines)
+	}
+	fmt.Fprint(w, "\r\x1b[J")
+
+	fmt.Fprintf(w, "%v (%v)\r\n", title, hint)
+	for i, item := range items {
+		pointer, mark := " ", " "
+		if i == cursor {
+			pointer = ">"
+		}
+		if checked[i] {
+			mark = "x"
+		}
+		fmt.Fprintf(w, "%v [%v] %v\r\n", pointer, mark, item)
+	}
+	return 1 + len(items)
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var planAction, planOutFile string
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Plan instance stack changes",
+	Long: `Resolve the instance stack and write a plan of the intended changes to a file.
+
+The plan records the resolved instance IDs, the intended Auto Scaling Group changes
+and the group ordering, and can be executed later with the apply command.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		action := types.Action(planAction)
+		if action != types.ActionShutdown && action != types.ActionStartup {
+			return fmt.Errorf("unsupported plan action %q, expected one of: %v, %v", planAction, types.ActionShutdown, types.ActionStartup)
+		}
+
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		initStateStore(cfg)
+		plan, err := buildPlan(ctx, action, newAWSClients(cfg))
+		if err != nil {
+			return err
+		}
+
+		planYaml, err := yaml.Marshal(plan)
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(planOutFile, planYaml, 0644); err != nil {
+			return err
+		}
+
+		out().Summaryf("Instance stack %v: %v plan has been written to %v\n", *stack.Name, action, planOutFile)
+		return nil
+	},
+}
+
+// buildPlan resolves the stack groups and computes the intended changes without mutating anything
+func buildPlan(ctx context.Context, action types.Action, clients *awsClients) (*types.Plan, error) {
+	plan := &types.Plan{
+		Action:    action,
+		Stack:     stack.Name,
+		CreatedAt: time.Now().UTC(),
+		Groups:    make([]types.GroupPlan, 0, len(stack.Groups)),
+	}
+	if err := guardOverlaps(ctx, clients); err != nil {
+		return nil, err
+	}
+	if action == types.ActionShutdown {
+		if err := guardUncoveredInstances(ctx, clients); err != nil {
+			return nil, err
+		}
+	}
+
+	c := curator.New(append(outputOptions(), curator.WithAutoScaling(clients.autoscaling), curator.WithSizeRecorder(stateSizeRecorder{}))...)
+
+	for _, group := range curator.OrderGroups(&stack, action) {
+		if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+			return nil, err
+		}
+
+		groupPlan := types.GroupPlan{
+			Name:      group.Name,
+			Instances: make([]types.PlannedInstance, 0, len(group.Instances)),
+		}
+		if len(group.Instances) == 0 && len(group.Clusters) == 0 {
+			out().Printf("No instances in instance group %v\n", *group.Name)
+			plan.Groups = append(plan.Groups, groupPlan)
+			continue
+		}
+
+		if _, err := getGroupInstanceIds(&group); err != nil {
+			return nil, err
+		}
+		for _, i := range group.Instances {
+			groupPlan.Instances = append(groupPlan.Instances, types.PlannedInstance{
+				InstanceId: i.InstanceId,
+				State:      i.State.Name,
+			})
+		}
+
+		var err error
+		if action == types.ActionStartup {
+			groupPlan.AutoScalingGroups, err = c.PlanGroupStartup(ctx, group)
+		} else {
+			groupPlan.AutoScalingGroups, err = c.PlanGroupShutdown(ctx, group)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		clusters, err := curator.DescribeGroupClusters(ctx, clients.rds, group)
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range clusters {
+			groupPlan.Clusters = append(groupPlan.Clusters, types.PlannedCluster{
+				Identifier: cluster.DBClusterIdentifier,
+				Status:     cluster.Status,
+			})
+		}
+
+		plan.Groups = append(plan.Groups, groupPlan)
+	}
+
+	return plan, nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	// Local flags which will only run when this command is called directly
+	planCmd.Flags().StringVar(&planAction, "action", "", "Action to plan: shutdown or startup")
+	planCmd.Flags().StringVar(&planOutFile, "out", "", "Path to write the plan to")
+	planCmd.Flags().BoolVar(&strictCoverage, "strict", false, "Fail when the Auto Scaling Groups have InService instances not covered by any group")
+	planCmd.MarkFlagRequired("action")
+	planCmd.MarkFlagRequired("out")
+}
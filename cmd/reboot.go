@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var rebootStandby, rebootHard bool
+
+// rebootCmd represents the reboot command
+var rebootCmd = &cobra.Command{
+	Use:   "reboot",
+	Short: "Reboot instance stack",
+	Long: `Reboot the instances of every group in the shutdown order, e.g. for patching.
+
+The instances are rebooted in place, or stopped and started with --hard, and checked for health.
+With --standby the instances are put into Standby for the reboot and returned to service afterwards.
+The instances of rolling groups are rebooted in batches like with the restart command.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionReboot)
+		if err := beginRun(ctx, types.ActionReboot, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
+		}
+
+		for _, group := range curator.OrderGroups(&stack, types.ActionShutdown) {
+			if completedByResumedRun(group) {
+				continue
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+
+			if len(group.Instances) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				continue
+			}
+
+			if err := out().CheckInstanceGroupGuards(ctx, clients.ec2, clients.autoscaling, group, instanceIds); err != nil {
+				return err
+			}
+
+			batches := curator.GroupBatches(group)
+			for n, instances := range batches {
+				if len(batches) > 1 {
+					out().Printf("Instance group %v: rebooting batch %v of %v\n", *group.Name, n+1, len(batches))
+				}
+				batch := group
+				batch.Instances = instances
+				if len(batches) > 1 {
+					batch.AllInstances = group.Instances
+				}
+				if err := rebootGroup(ctx, clients, c, batch); err != nil {
+					return err
+				}
+
+				if n == 0 && len(batches) > 1 && group.Canary != nil && group.Canary.FirstBatch {
+					if err := pauseAfterCanary(ctx, group); err != nil {
+						return err
+					}
+				}
+			}
+
+			out().Printf("Instance group %v: reboot has been completed\n", *group.Name)
+
+			if group.Canary != nil && (!group.Canary.FirstBatch || len(batches) == 1) {
+				if err := pauseAfterCanary(ctx, group); err != nil {
+					return err
+				}
+			}
+		}
+
+		out().Summaryf("Instance stack %v: reboot has been completed\n", *stack.Name)
+		return nil
+	},
+}
+
+// rebootGroup reboots the resolved group instances, putting them into Standby for the reboot if requested
+func rebootGroup(ctx context.Context, clients *awsClients, c *curator.Curator, group types.Group) error {
+	instanceIds := curator.GroupInstanceIds(group)
+
+	var shutdownChanges []types.AutoScalingGroupChange
+	if rebootStandby {
+		changes, err := c.PlanGroupShutdown(ctx, group)
+		if err != nil {
+			return err
+		}
+		shutdownChanges = changes
+
+		if err := c.ApplyGroupShutdownPlan(ctx, group, shutdownChanges); err != nil {
+			return err
+		}
+	}
+
+	if rebootHard {
+		if err := c.StopGroup(ctx, group, instanceIds); err != nil {
+			return err
+		}
+
+		if err := c.StartGroup(ctx, group, instanceIds); err != nil {
+			return err
+		}
+	} else {
+		if err := out().RebootInstanceGroup(ctx, clients.ec2, group, instanceIds); err != nil {
+			return err
+		}
+
+		if err := out().WaitForInstanceGroupBoot(ctx, clients.ssm, group, instanceIds); err != nil {
+			return err
+		}
+	}
+
+	if rebootStandby {
+		startupChanges, err := c.PlanGroupStartup(ctx, group)
+		if err != nil {
+			return err
+		}
+		curator.RestoreMinSizes(startupChanges, shutdownChanges)
+
+		if err := c.ApplyGroupStartupPlan(ctx, group, startupChanges); err != nil {
+			return err
+		}
+	}
+
+	if err := out().CheckInstanceGroupHealth(ctx, clients.ec2, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := out().VerifyInstanceGroup(ctx, clients.ssm, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := tagGroup(ctx, clients, group, instanceIds, types.ActionReboot); err != nil {
+		return err
+	}
+
+	out().Printf("Instance group %v: instances %v have been rebooted\n", *group.Name, instanceIds)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(rebootCmd)
+
+	// Local flags which will only run when this command is called directly
+	rebootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	rebootCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+	rebootCmd.Flags().BoolVar(&confirmCanaries, "confirm-canaries", false, "Continue past canary groups without an interactive confirmation")
+	rebootCmd.Flags().BoolVar(&rebootStandby, "standby", false, "Put the instances into Standby for the reboot")
+	rebootCmd.Flags().BoolVar(&rebootHard, "hard", false, "Stop and start the instances instead of rebooting them")
+}
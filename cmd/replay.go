@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/replay"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator/fake"
+)
+
+var recordFile, replayFile string
+var responseRecorder *replay.Recorder
+
+var chaos float64
+var chaosSeed int64
+
+// chaosCloud is the in-memory fake changed by a replayed run with injected failures
+var chaosCloud *fake.Cloud
+
+// initRecording starts recording the describe responses of the AWS calls made with the AWS config
+func initRecording(cfg *aws.Config) {
+	responseRecorder = replay.NewRecorder(*stack.Name, cfg.Region)
+	cfg.APIOptions = append(cfg.APIOptions, responseRecorder.AddMiddleware)
+}
+
+// finishRecording writes the recorded responses of the run to the record file
+func finishRecording(runErr error) error {
+	if err := responseRecorder.Save(recordFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return errors.Join(runErr, err)
+	}
+
+	out().Summaryf("Instance stack %v: %v AWS responses have been recorded to %v\n", *stack.Name, responseRecorder.Len(), recordFile)
+	return runErr
+}
+
+// initReplay answers the AWS calls made with the AWS config with the recorded responses of the replay file.
+// The replayed run is a dry run which needs neither AWS credentials nor network access, unless failures
+// are injected: the run then changes the in-memory fake seeded with the recorded instances and Auto Scaling Groups.
+func initReplay(cfg *aws.Config) error {
+	player, err := replay.Load(replayFile)
+	if err != nil {
+		return err
+	}
+
+	recording := player.Recording()
+	out().Printf("Replaying %v AWS responses of instance stack %v recorded at %v\n", len(recording.Responses), recording.Stack, recording.RecordedAt)
+	cfg.Credentials = aws.AnonymousCredentials{}
+	cfg.APIOptions = append(cfg.APIOptions, player.AddMiddleware)
+	if chaos == 0 {
+		dryRun = true
+		return nil
+	}
+
+	if chaosCloud, err = player.Cloud(); err != nil {
+		return err
+	}
+	chaosCloud.SetChaos(fake.Chaos{
+		EnterStandbyFailure: chaos,
+		StuckStopping:       chaos,
+		Throttling:          chaos,
+		Seed:                chaosSeed,
+	})
+	out().Printf("Rehearsing with failures injected at the probability of %v\n", chaos)
+	return nil
+}
+
+// checkChaos verifies that the failures are injected into a replayed run only
+func checkChaos() error {
+	if chaos < 0 || chaos > 1 {
+		return fmt.Errorf("invalid --chaos %v, expected a probability from 0 to 1", chaos)
+	}
+	if chaos > 0 && replayFile == "" {
+		return errors.New("--chaos is only usable with --replay")
+	}
+	return nil
+}
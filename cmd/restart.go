@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// restartCmd represents the restart command
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart instance stack",
+	Long: `Restart the instances of every group in the shutdown order.
+
+The instances of rolling groups are restarted in batches, one instance at a time by default:
+put into Standby, stopped, started, returned to service and checked for health before the next batch,
+so that the group never loses all members.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionRestart)
+		if err := beginRun(ctx, types.ActionRestart, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
+		}
+		if err := guardUncoveredInstances(ctx, clients); err != nil {
+			return err
+		}
+
+		for _, group := range curator.OrderGroups(&stack, types.ActionShutdown) {
+			if completedByResumedRun(group) {
+				continue
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+
+			if len(group.Instances) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				continue
+			}
+
+			if err := out().CheckInstanceGroupGuards(ctx, clients.ec2, clients.autoscaling, group, instanceIds); err != nil {
+				return err
+			}
+
+			batches := curator.GroupBatches(group)
+			for n, instances := range batches {
+				if len(batches) > 1 {
+					out().Printf("Instance group %v: restarting batch %v of %v\n", *group.Name, n+1, len(batches))
+				}
+				batch := group
+				batch.Instances = instances
+				if len(batches) > 1 {
+					batch.AllInstances = group.Instances
+				}
+				if err := restartGroup(ctx, c, batch); err != nil {
+					return err
+				}
+
+				if n == 0 && len(batches) > 1 && group.Canary != nil && group.Canary.FirstBatch {
+					if err := pauseAfterCanary(ctx, group); err != nil {
+						return err
+					}
+				}
+			}
+
+			out().Printf("Instance group %v: restart has been completed\n", *group.Name)
+
+			if group.Canary != nil && (!group.Canary.FirstBatch || len(batches) == 1) {
+				if err := pauseAfterCanary(ctx, group); err != nil {
+					return err
+				}
+			}
+		}
+
+		out().Summaryf("Instance stack %v: restart has been completed\n", *stack.Name)
+		return nil
+	},
+}
+
+// restartGroup restarts the resolved group instances, restoring the Auto Scaling Group sizes
+func restartGroup(ctx context.Context, c *curator.Curator, group types.Group) error {
+	instanceIds := curator.GroupInstanceIds(group)
+
+	if err := c.BeginGroupShutdown(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	shutdownChanges, err := c.PlanGroupShutdown(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	if err := c.ApplyGroupShutdownPlan(ctx, group, shutdownChanges); err != nil {
+		return err
+	}
+
+	if err := c.StopGroup(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.StartGroup(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	startupChanges, err := c.PlanGroupStartup(ctx, group)
+	if err != nil {
+		return err
+	}
+	curator.RestoreMinSizes(startupChanges, shutdownChanges)
+
+	if err := c.ApplyGroupStartupPlan(ctx, group, startupChanges); err != nil {
+		return err
+	}
+
+	if err := c.CompleteGroupStartup(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	out().Printf("Instance group %v: instances %v have been restarted\n", *group.Name, instanceIds)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+
+	// Local flags which will only run when this command is called directly
+	restartCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	restartCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+	restartCmd.Flags().BoolVar(&strictCoverage, "strict", false, "Fail when the Auto Scaling Groups have InService instances not covered by any group")
+	restartCmd.Flags().BoolVar(&confirmCanaries, "confirm-canaries", false, "Continue past canary groups without an interactive confirmation")
+}
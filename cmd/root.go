@@ -2,22 +2,39 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"sort"
+	"syscall"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/google/uuid"
 	"github.com/k0kubun/pp/v3"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 
-	"github.com/ikorchynskyi/instance-stack-curator/internal/curator"
-	"github.com/ikorchynskyi/instance-stack-curator/internal/types"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/logfile"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/spec"
 	"github.com/ikorchynskyi/instance-stack-curator/internal/validator"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/version"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -30,145 +47,529 @@ It allows to execute startup and shutdown of groups of EC2 instances in a predic
 	`,
 }
 
-var debug, dryRun bool
+var debug, dryRun, quiet bool
+var verbose int
+var logFile string
+var logFileMaxSize, logFileMaxBackups int
+var logFileWriter *logfile.RotatingFile
 var stack types.Stack
 var stackFile string
 
+// stackDocument is the YAML document of the stack spec, positioning the spec fields
+var stackDocument *yamlv3.Node
+var environment string
+var commandPath string
+var runId, requestedRunId string
+
+// runIdPattern matches the run IDs which fit the role session names, prefixed with instance-stack-curator-
+var runIdPattern = regexp.MustCompile(`^[\w+=,.@-]{1,41}$`)
+var instanceStates []string
+var skipAutoScaling, skipEC2 bool
+var groupNames []string
+var endpointURL string
+var region, roleARN string
+var externalID, sourceIdentity string
+var sessionTags map[string]string
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	registerCompletions()
+
+	// the commands are cancelled on the first signal, while a second signal terminates the curator immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		fmt.Fprintln(os.Stderr, "Cancelling, cleaning up the instances already changed; interrupt again to terminate")
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if responseRecorder != nil {
+		err = finishRecording(err)
+	}
+	if auditRecorder != nil {
+		err = finishAudit(ctx, err)
+	}
+	if runTracker != nil {
+		err = finishRun(ctx, err)
+	} else if err != nil {
+		out().Emit(curator.Event{Type: curator.EventError, Error: err.Error()})
+	}
+	if lockErr := releaseLock(ctx); lockErr != nil {
+		err = errors.Join(err, lockErr)
+	}
+	if taskToken != "" {
+		err = sendTaskResult(ctx, err)
+	}
+	if logger != nil && err != nil {
+		logger.Error("command failed", "error", err)
+	}
+	if logStreamWriter != nil {
+		closeLogStream()
+	}
+	if logFileWriter != nil {
+		logFileWriter.Close()
+	}
+	if eventsFileWriter != nil {
+		eventsFileWriter.Close()
+	}
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	// DisableDefaultCmd prevents Cobra from creating a default 'completion' command
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
-
 	// SilenceUsage is an option to silence usage when an error occurs.
 	rootCmd.SilenceUsage = true
 
 	// Persistent flags which will be global for the application.
-	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Turn on debug logging")
-	rootCmd.PersistentFlags().StringVar(&stackFile, "stack", "", "Path to a stack spec")
-	rootCmd.MarkPersistentFlagRequired("stack")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Turn on debug logging, including AWS request and response bodies")
+	rootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "Increase verbosity: -v logs waiter attempts and AWS request IDs, -vv also logs AWS requests and responses")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only the final summary and errors")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.MarkFlagsMutuallyExclusive("debug", "quiet")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Path to a file receiving full structured logs regardless of the verbosity")
+	rootCmd.PersistentFlags().IntVar(&logFileMaxSize, "log-file-max-size", 10, "Maximum size of the log file in megabytes before it is rotated")
+	rootCmd.PersistentFlags().IntVar(&logFileMaxBackups, "log-file-max-backups", 5, "Maximum number of rotated log files to keep")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputPretty, "Format of the messages and the tables: pretty, plain, json or csv")
+	rootCmd.PersistentFlags().BoolVar(&wide, "wide", false, "Add the availability zone, instance type, launch time and Auto Scaling Group columns to the instance tables")
+	rootCmd.PersistentFlags().StringVar(&sortBy, "sort-by", "", "Order of the instance table rows: name, instance-id, ip, state or launch-time, the described order if empty")
+	rootCmd.PersistentFlags().StringSliceVar(&columns, "columns", nil, "Columns of the instance tables, e.g. Group,InstanceId,tag:Owner,PrivateIp,State, the default ones if empty")
+	rootCmd.PersistentFlags().StringVar(&eventsFile, "events-file", "", "Path to a file receiving the orchestration events as JSON lines, or - for stdout with the progress printed to stderr")
+	rootCmd.PersistentFlags().StringSliceVar(&reportFiles, "report", nil, "Paths the run report is written to, as Markdown for .md paths and as JSON otherwise")
+	rootCmd.PersistentFlags().StringVar(&taskToken, "task-token", "", "Step Functions task token the run report is sent back with, read from "+taskTokenEnv+" unless given")
+	rootCmd.PersistentFlags().StringVar(&stackFile, "stack", "", "Path to a stack spec, - to read it from stdin, or an s3://bucket/key[?versionId=...], ssm://parameter-name or https:// URL")
+	rootCmd.PersistentFlags().BoolVar(&allowOverlaps, "allow-overlaps", false, "Warn rather than fail when instances are matched by more than one group")
+	rootCmd.PersistentFlags().StringVar(&stackSHA256, "stack-sha256", "", "Hex SHA-256 digest the stack spec must match")
+	rootCmd.PersistentFlags().StringVar(&endpointURL, "endpoint-url", "", "URL of the endpoint all AWS calls are sent to, e.g. http://localhost:4566 for LocalStack, overriding the stack spec")
+	rootCmd.PersistentFlags().StringVar(&environment, "env", "", "Name of the environment whose overlay is layered onto the stack spec")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "Name of the Region, overriding the stack spec")
+	rootCmd.PersistentFlags().StringVar(&roleARN, "role-arn", "", "IAM Role ARN to be assumed, overriding the stack spec")
+	rootCmd.PersistentFlags().StringVar(&externalID, "external-id", "", "External ID of the assumed role, overriding the stack spec")
+	rootCmd.PersistentFlags().StringVar(&sourceIdentity, "source-identity", "", "Source identity of the assumed role session, overriding the stack spec")
+	rootCmd.PersistentFlags().StringToStringVar(&sessionTags, "session-tags", nil, "Tags of the assumed role session, e.g. team=ops, added to the stack spec ones")
+	rootCmd.PersistentFlags().StringSliceVar(&instanceStates, "instance-states", nil, "Instance states considered by all groups, overriding the stack spec")
+	rootCmd.PersistentFlags().BoolVar(&skipAutoScaling, "skip-asg", false, "Skip putting the instances of all groups into Standby and returning them to service")
+	rootCmd.PersistentFlags().BoolVar(&skipEC2, "skip-ec2", false, "Skip stopping and starting the instances of all groups, only moving them in and out of Standby")
+	rootCmd.PersistentFlags().BoolVar(&resume, "resume", false, "Resume the last failed or cancelled run of the action, skipping the groups it completed")
+	rootCmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "Path of the run state written by a failed or cancelled run, overriding the state backend of the stack")
+	rootCmd.PersistentFlags().StringVar(&lockFile, "lock-file", "", "Path of the advisory lock file of the stack held by a mutating run, overriding the state backend of the stack")
+	rootCmd.PersistentFlags().BoolVar(&noLock, "no-lock", false, "Run without taking the advisory lock file of the stack")
+	rootCmd.PersistentFlags().StringSliceVar(&groupNames, "groups", nil, "Names of the groups acted upon, all groups of the stack spec by default")
+	rootCmd.PersistentFlags().BoolVar(&pick, "pick", false, "Pick the groups and then the instances of every group acted upon in interactive checklists")
+	rootCmd.PersistentFlags().StringVar(&requestedRunId, "run-id", "", "ID of the run correlating its logs, audit record, tags, notifications and role sessions, a generated UUID by default")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Path to a file the describe responses of the AWS calls are recorded to, to be replayed offline with --replay")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Path to a file of describe responses recorded with --record, replayed instead of calling AWS in a dry run")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.MarkFlagsMutuallyExclusive("wide", "columns")
+	rootCmd.PersistentFlags().Float64Var(&chaos, "chaos", 0, "Probability of the failures injected into a replayed run to rehearse the runbooks: failing EnterStandby, instances stuck stopping and throttling")
+	rootCmd.PersistentFlags().Int64Var(&chaosSeed, "chaos-seed", 0, "Seed of the failures injected by --chaos, making the rehearsals reproducible, a random seed if zero")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		commandPath = cmd.CommandPath()
+		runId = requestedRunId
+		if runId == "" {
+			runId = uuid.NewString()
+		} else if !runIdPattern.MatchString(runId) {
+			return fmt.Errorf("invalid run ID %q, expected up to 41 letters, digits or any of _+=,.@-", runId)
+		}
+		initTaskToken()
+		if err := initEvents(); err != nil {
+			return err
+		}
+		if err := initReporter(); err != nil {
+			return err
+		}
+		if err := initColumns(); err != nil {
+			return err
+		}
+		if err := checkSortBy(); err != nil {
+			return err
+		}
+		if err := checkPick(); err != nil {
+			return err
+		}
+		return initLogFile()
+	}
 
 	pp.PrintMapTypes = false
-	pp.Default.SetExportedOnly(true)
-	pp.Default.SetColoringEnabled(term.IsTerminal(int(os.Stdout.Fd())))
 }
 
-func initStack() error {
-	stackYaml, err := os.ReadFile(stackFile)
+func initStack(ctx context.Context) error {
+	// the flag is not marked as required, as the worker command resolves the stacks of the requests instead
+	if stackFile == "" {
+		return errors.New(`required flag(s) "stack" not set`)
+	}
+
+	stackYaml, err := readStackFile(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err = yaml.Unmarshal([]byte(stackYaml), &stack); err != nil {
+	document, stackYaml, err := spec.Overlay(stackYaml, environment)
+	if err != nil {
 		return err
 	}
+	stackDocument = document
 
-	if err = validator.ValidateStack(&stack); err != nil {
+	// unknown fields are rejected, so that misspelled keys are not silently ignored
+	if err = yaml.UnmarshalStrict(stackYaml, &stack); err != nil {
 		return err
 	}
+	stack.ExpandTagFilters()
 
-	pp.Printf("Instance stack: %v\n", stack)
+	if region != "" {
+		stack.Region = aws.String(region)
+	}
+	if roleARN != "" {
+		stack.RoleARN = aws.String(roleARN)
+	}
+	if externalID != "" {
+		stack.ExternalID = aws.String(externalID)
+	}
+	if sourceIdentity != "" {
+		stack.SourceIdentity = aws.String(sourceIdentity)
+	}
+	if len(sessionTags) > 0 && stack.SessionTags == nil {
+		stack.SessionTags = make(map[string]string, len(sessionTags))
+	}
+	for k, v := range sessionTags {
+		stack.SessionTags[k] = v
+	}
+
+	if len(instanceStates) > 0 {
+		for i := range stack.Groups {
+			stack.Groups[i].InstanceStates = make([]ec2Types.InstanceStateName, 0, len(instanceStates))
+			for _, s := range instanceStates {
+				stack.Groups[i].InstanceStates = append(stack.Groups[i].InstanceStates, ec2Types.InstanceStateName(s))
+			}
+		}
+	}
+
+	for i := range stack.Groups {
+		stack.Groups[i].SkipAutoScaling = stack.Groups[i].SkipAutoScaling || skipAutoScaling
+		stack.Groups[i].SkipEC2 = stack.Groups[i].SkipEC2 || skipEC2
+	}
+
+	if err = validator.ValidateStackDocument(&stack, stackFileName(), document); err != nil {
+		return err
+	}
+
+	if len(groupNames) > 0 {
+		if err = selectGroups(groupNames); err != nil {
+			return err
+		}
+	}
+	if err = pickGroups(); err != nil {
+		return err
+	}
+
+	out().Printf("Instance stack: %v\n", stack)
+	return nil
+}
+
+// selectGroups keeps the named stack groups only, in the stack spec order
+func selectGroups(names []string) error {
+	groups := make([]types.Group, 0, len(names))
+	for _, name := range names {
+		if _, ok := curator.FindGroup(&stack, name); !ok {
+			return fmt.Errorf("group %v is not defined in the stack spec", name)
+		}
+	}
+	for _, g := range stack.Groups {
+		if slices.Contains(names, *g.Name) {
+			groups = append(groups, g)
+		}
+	}
+	stack.Groups = groups
 	return nil
 }
 
-func initAWS() (aws.Config, error) {
+func initLogFile() error {
+	if logFile == "" {
+		return nil
+	}
+
+	var err error
+	logFileWriter, err = logfile.Open(logFile, int64(logFileMaxSize)*1024*1024, logFileMaxBackups)
+	if err != nil {
+		return err
+	}
+
+	logger = newLogger(logFileWriter)
+	return nil
+}
+
+// newLogger returns a structured logger writing JSON logs of every level to the writer
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})).With("command", commandPath, "run-id", runId, "version", version.Version, "commit", version.Get().Commit)
+}
+
+// structuredLogging reports whether the structured logs are written to a log file or a log stream
+func structuredLogging() bool {
+	return logFile != "" || stack.Logs != nil
+}
+
+// awsLogger sends AWS SDK logs to the console when verbose and to the structured logs, if any
+type awsLogger struct{}
+
+func (awsLogger) Logf(classification logging.Classification, format string, v ...interface{}) {
+	if getVerbosity() >= curator.VerbosityVerbose {
+		fmt.Fprintf(os.Stderr, "SDK %v %v %v\n", time.Now().Format("2006/01/02 15:04:05"), classification, fmt.Sprintf(format, v...))
+	}
+	if logger != nil {
+		level := slog.LevelDebug
+		if classification == logging.Warn {
+			level = slog.LevelWarn
+		}
+		logger.Log(context.Background(), level, fmt.Sprintf(format, v...), "source", "aws-sdk")
+	}
+}
+
+func getVerbosity() curator.Verbosity {
+	switch {
+	case quiet:
+		return curator.VerbosityQuiet
+	case debug:
+		return curator.VerbosityDebug
+	}
+	return min(curator.VerbosityNormal+curator.Verbosity(verbose), curator.VerbosityDebug)
+}
+
+func initAWS(ctx context.Context) (aws.Config, error) {
 	// Using the SDK's default configuration, loading additional config
 	// and credentials values from the environment variables, shared
 	// credentials, and shared configuration files
 	var clientLogMode aws.ClientLogMode
 	if debug {
-		clientLogMode = aws.LogRequestWithBody | aws.LogResponseWithBody
+		clientLogMode = aws.LogRetries | aws.LogRequestWithBody | aws.LogResponseWithBody
+	} else if getVerbosity() >= curator.VerbosityDebug {
+		clientLogMode = aws.LogRetries | aws.LogRequest | aws.LogResponse
 	} else {
 		clientLogMode = 0
 	}
 
-	var region string
-	if stack.Region != nil {
-		region = *stack.Region
+	if clientLogMode == 0 && structuredLogging() {
+		clientLogMode = aws.LogRetries
+	}
+
+	var apiOptions []func(*middleware.Stack) error
+	if getVerbosity() >= curator.VerbosityVerbose || structuredLogging() {
+		apiOptions = append(apiOptions, addRequestIDLogger)
 	}
 
-	ctx := context.TODO()
 	cfg, err := config.LoadDefaultConfig(
 		ctx,
-		config.WithRegion(region),
+		config.WithRegion(aws.ToString(stack.Region)),
 		config.WithClientLogMode(clientLogMode),
+		config.WithAPIOptions(apiOptions),
+		config.WithLogger(awsLogger{}),
 	)
 	if err != nil {
 		return cfg, err
 	}
+	setEndpointURL(&cfg)
 
-	if stack.RoleARN != nil {
-		stsClient := sts.NewFromConfig(cfg)
-		credentialsCache := aws.NewCredentialsCache(
-			stscreds.NewAssumeRoleProvider(
-				stsClient,
-				*stack.RoleARN,
-				func(options *stscreds.AssumeRoleOptions) {
-					options.RoleSessionName = "instance-stack-curator-" + uuid.NewString()
-					options.Duration = 2 * curator.DefaultWaitDuration
-				},
-			),
-			func(options *aws.CredentialsCacheOptions) {
-				options.ExpiryWindow = curator.DefaultWaitDuration
-			},
-		)
+	if err := checkChaos(); err != nil {
+		return cfg, err
+	}
+	// the replayed run neither assumes roles nor streams logs, so that it runs offline
+	if replayFile != "" {
+		return cfg, initReplay(&cfg)
+	}
+
+	var credentialsCache *aws.CredentialsCache
+	if stack.WebIdentity != nil {
+		credentialsCache = newWebIdentityCredentials(sts.NewFromConfig(cfg), *stack.WebIdentity)
 		if _, err = credentialsCache.Retrieve(ctx); err != nil {
-			return cfg, err
+			return cfg, fmt.Errorf("unable to assume role %v with web identity: %w", *stack.WebIdentity.RoleARN, err)
+		}
+	}
+
+	if stack.RoleARN != nil {
+		// every role of the chain is assumed with the credentials of the previous one
+		roles := append(slices.Clone(stack.RoleChain), types.AssumeRole{
+			RoleARN:        stack.RoleARN,
+			ExternalID:     stack.ExternalID,
+			SourceIdentity: stack.SourceIdentity,
+			SessionTags:    stack.SessionTags,
+		})
+
+		for _, role := range roles {
+			stsConfig := cfg.Copy()
+			if credentialsCache != nil {
+				stsConfig.Credentials = credentialsCache
+			}
+			credentialsCache = newAssumeRoleCredentials(sts.NewFromConfig(stsConfig), role)
+			if _, err = credentialsCache.Retrieve(ctx); err != nil {
+				return cfg, fmt.Errorf("unable to assume role %v: %w", *role.RoleARN, err)
+			}
 		}
+	}
+
+	if credentialsCache != nil {
 		cfg, err = config.LoadDefaultConfig(
 			ctx,
 			config.WithRegion(cfg.Region),
+			config.WithClientLogMode(clientLogMode),
+			config.WithAPIOptions(apiOptions),
+			config.WithLogger(awsLogger{}),
 			config.WithCredentialsProvider(credentialsCache),
 		)
+		if err != nil {
+			return cfg, err
+		}
+		setEndpointURL(&cfg)
+	}
+
+	if stack.Logs != nil && logStreamWriter == nil {
+		if err = initLogStream(ctx, cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if stack.Audit != nil {
+		initAudit(ctx, &cfg)
 	}
 
-	return cfg, err
+	if recordFile != "" {
+		initRecording(&cfg)
+	}
+
+	return cfg, nil
+}
+
+// setEndpointURL points all AWS clients to the endpoint given by the flag or the stack spec, if any,
+// e.g. to LocalStack
+func setEndpointURL(cfg *aws.Config) {
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	} else if stack.EndpointURL != nil {
+		cfg.BaseEndpoint = stack.EndpointURL
+	}
 }
 
-func getGroupInstanceIds(group *types.Group) []string {
+// addRequestIDLogger logs the request ID of every AWS operation
+func addRequestIDLogger(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RequestIDLogger", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+			if getVerbosity() >= curator.VerbosityVerbose {
+				fmt.Fprintf(os.Stderr, "%v %v request ID: %v\n", awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), requestID)
+			}
+			if logger != nil {
+				attrs := []any{
+					"service", awsmiddleware.GetServiceID(ctx),
+					"operation", awsmiddleware.GetOperationName(ctx),
+					"request-id", requestID,
+				}
+				if err != nil {
+					logger.Warn("AWS request failed", append(attrs, "error", err)...)
+				} else {
+					logger.Debug("AWS request", attrs...)
+				}
+			}
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
+
+func getGroupInstanceIds(group *types.Group) ([]string, error) {
+	if err := pickGroupInstances(group); err != nil {
+		return nil, err
+	}
+
 	instanceIds := make([]string, 0, len(group.Instances))
-	tableData := make([][]string, 0, 1+len(group.Instances))
 	for _, i := range group.Instances {
 		instanceIds = append(instanceIds, *i.InstanceId)
-		var instanceName string
-		for _, t := range i.Tags {
-			if *t.Key == "Name" {
-				instanceName = *t.Value
-				break
-			}
+	}
+
+	tableData := make([][]string, 0, 1+len(group.Instances))
+	for _, i := range sortInstances(group.Instances) {
+		row := make([]string, 0, len(tableColumns))
+		for _, c := range tableColumns {
+			row = append(row, c.value(*group, i))
 		}
-		tableData = append(tableData, []string{
-			*group.Name,
-			*i.InstanceId,
-			instanceName,
-			*i.PrivateIpAddress,
-			string(i.State.Name),
-		})
+		tableData = append(tableData, row)
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Group", "Instance ID", "Name", "Private IP", "State"})
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAutoMergeCellsByColumnIndex([]int{0})
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		table.SetColumnColor(
-			tablewriter.Colors{tablewriter.Normal, tablewriter.FgRedColor},
-			tablewriter.Colors{tablewriter.Normal, tablewriter.FgYellowColor},
-			tablewriter.Colors{tablewriter.Normal, tablewriter.FgGreenColor},
-			tablewriter.Colors{tablewriter.Normal, tablewriter.FgGreenColor},
-			tablewriter.Colors{tablewriter.Normal, tablewriter.FgGreenColor},
-		)
+	header := make([]string, 0, len(tableColumns))
+	for _, c := range tableColumns {
+		header = append(header, c.header)
 	}
 
-	table.AppendBulk(tableData)
-	table.Render()
+	if runTracker != nil {
+		runTracker.SetGroupInstances(instanceIds)
+	}
 
-	return instanceIds
+	out().PrintTable(curator.Table{
+		Name:             "instances",
+		Header:           header,
+		Rows:             tableData,
+		MergeFirstColumn: tableColumns[0].name == columnGroup,
+		Colored:          true,
+	})
+
+	return instanceIds, nil
+}
+
+// newAssumeRoleCredentials returns the cached credentials of the role assumed with the STS client
+func newAssumeRoleCredentials(stsClient *sts.Client, role types.AssumeRole) *aws.CredentialsCache {
+	return aws.NewCredentialsCache(
+		stscreds.NewAssumeRoleProvider(
+			stsClient,
+			*role.RoleARN,
+			func(options *stscreds.AssumeRoleOptions) {
+				options.RoleSessionName = "instance-stack-curator-" + runId
+				options.Duration = 2 * curator.DefaultWaitDuration
+				options.ExternalID = role.ExternalID
+				options.SourceIdentity = role.SourceIdentity
+				options.Tags = sessionTagList(role.SessionTags)
+			},
+		),
+		func(options *aws.CredentialsCacheOptions) {
+			options.ExpiryWindow = curator.DefaultWaitDuration
+		},
+	)
+}
+
+// newWebIdentityCredentials returns the cached credentials of the role assumed with the web identity token
+func newWebIdentityCredentials(stsClient *sts.Client, webIdentity types.WebIdentity) *aws.CredentialsCache {
+	return aws.NewCredentialsCache(
+		stscreds.NewWebIdentityRoleProvider(
+			stsClient,
+			*webIdentity.RoleARN,
+			stscreds.IdentityTokenFile(*webIdentity.TokenFile),
+			func(options *stscreds.WebIdentityRoleOptions) {
+				options.RoleSessionName = aws.ToString(webIdentity.SessionName)
+				if options.RoleSessionName == "" {
+					options.RoleSessionName = "instance-stack-curator-" + runId
+				}
+				options.Duration = 2 * curator.DefaultWaitDuration
+			},
+		),
+		func(options *aws.CredentialsCacheOptions) {
+			options.ExpiryWindow = curator.DefaultWaitDuration
+		},
+	)
+}
+
+// sessionTagList converts the session tags into the STS tags ordered by the key
+func sessionTagList(tags map[string]string) []stsTypes.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	list := make([]stsTypes.Tag, 0, len(tags))
+	for _, k := range keys {
+		list = append(list, stsTypes.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return list
 }
@@ -3,128 +3,176 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"time"
 
-	"github.com/k0kubun/pp/v3"
 	"github.com/spf13/cobra"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/jmespath/go-jmespath"
-
-	"github.com/ikorchynskyi/instance-stack-curator/internal/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
 )
 
+var strategy string
+
 // shutdownCmd represents the shutdown command
 var shutdownCmd = &cobra.Command{
 	Use:   "shutdown",
 	Short: "Shutdown instance stack",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := initStack(); err != nil {
+		switch types.ShutdownStrategy(strategy) {
+		case "", types.ShutdownStrategySequential, types.ShutdownStrategyTwoPhase:
+		default:
+			return fmt.Errorf("unsupported shutdown strategy %q, expected %v or %v", strategy, types.ShutdownStrategySequential, types.ShutdownStrategyTwoPhase)
+		}
+
+		if err := initStack(cmd.Context()); err != nil {
 			return err
 		}
 
-		ctx := context.TODO()
-		cfg, err := initAWS()
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
 		if err != nil {
 			return err
 		}
 
-		ec2Client := ec2.NewFromConfig(cfg)
-		var autoscalingClient *autoscaling.Client
-		if !dryRun {
-			autoscalingClient = autoscaling.NewFromConfig(cfg)
-		}
-
-		for i := range stack.Groups {
-			group := stack.Groups[i]
-			filters := append(stack.Filters, group.Filters...)
-			filters = append(
-				filters,
-				ec2Types.Filter{
-					Name: aws.String("instance-state-name"),
-					Values: []string{
-						string(ec2Types.InstanceStateNameRunning),
-						string(ec2Types.InstanceStateNameStopped),
-					},
-				},
-			)
-
-			if output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-				Filters: filters,
-			}); err != nil {
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionShutdown)
+		if err := beginRun(ctx, types.ActionShutdown, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
+		}
+		if err := guardUncoveredInstances(ctx, clients); err != nil {
+			return err
+		}
+
+		groups := curator.OrderGroups(&stack, types.ActionShutdown)
+		if shutdownStrategy() == types.ShutdownStrategyTwoPhase {
+			if err := shutdownTwoPhase(ctx, clients, c, groups); err != nil {
 				return err
-			} else {
-				for _, r := range output.Reservations {
-					group.Instances = append(group.Instances, r.Instances...)
-				}
 			}
 
-			if len(group.Instances) == 0 {
-				pp.Printf("No instances in instance group %v\n", *group.Name)
+			out().Summaryf("Instance stack %v: shutdown has been completed\n", *stack.Name)
+			return nil
+		}
+
+		for _, group := range groups {
+			if completedByResumedRun(group) {
 				continue
 			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
 
-			instanceIds := getGroupInstanceIds(&group)
-			if dryRun {
+			if len(group.Instances) == 0 && len(group.Clusters) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
 				continue
 			}
 
-			if err := curator.PrepareInstanceGroupForShutdown(ctx, autoscalingClient, group); err != nil {
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
 				return err
 			}
-
-			if output, err := ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
-				InstanceIds: instanceIds,
-			}); err != nil {
+			inDesiredState, err := c.GroupInDesiredState(ctx, group, types.ActionShutdown)
+			if err != nil {
 				return err
-			} else {
-				pp.Printf("Instance state changes in instance group %v: %v\n", *group.Name, output.StoppingInstances)
+			}
+			if inDesiredState || dryRun {
+				continue
 			}
 
-			waiter := ec2.NewInstanceStoppedWaiter(ec2Client, func(o *ec2.InstanceStoppedWaiterOptions) {
-				o.LogWaitAttempts = true
-				o.MaxDelay = time.Minute
-			})
-			if output, err := waiter.WaitForOutput(ctx, &ec2.DescribeInstancesInput{
-				InstanceIds: instanceIds,
-			}, curator.DefaultWaitDuration); err != nil {
+			if err := c.ShutdownGroup(ctx, group, instanceIds); err != nil {
 				return err
-			} else {
-				pathValue, err := jmespath.Search(
-					fmt.Sprintf(
-						"Reservations[].Instances[].{%[1]v:%[1]v,%[2]v:%[2]v,%[3]v:%[3]v,%[4]v:%[4]v}",
-						"InstanceId",
-						"State",
-						"StateReason",
-						"StateTransitionReason",
-					),
-					output,
-				)
-				if err != nil {
-					return fmt.Errorf("error evaluating instance state: %w", err)
-				}
-
-				listOfValues, ok := pathValue.([]interface{})
-				if !ok {
-					return fmt.Errorf("expected list got %T", pathValue)
-				}
-				pp.Printf("Instance states in instance group %v: %v\n", *group.Name, listOfValues)
 			}
 
-			pp.Printf("Instance group %v: shutdown has been completed\n", *group.Name)
+			out().Printf("Instance group %v: shutdown has been completed\n", *group.Name)
 		}
 
-		pp.Printf("Instance stack %v: shutdown has been completed\n", *stack.Name)
+		out().Summaryf("Instance stack %v: shutdown has been completed\n", *stack.Name)
 		return nil
 	},
 }
 
+// shutdownStrategy returns the shutdown strategy of the --strategy flag or of the stack spec
+func shutdownStrategy() types.ShutdownStrategy {
+	if strategy != "" {
+		return types.ShutdownStrategy(strategy)
+	}
+	if stack.ShutdownStrategy != nil {
+		return *stack.ShutdownStrategy
+	}
+	return types.ShutdownStrategySequential
+}
+
+// standbyGroup is a group put into Standby by the first phase of the two-phase shutdown
+type standbyGroup struct {
+	group       types.Group
+	instanceIds []string
+}
+
+// shutdownTwoPhase puts the instances of every group into Standby in order, then stops the instances
+// of every group in order
+func shutdownTwoPhase(ctx context.Context, clients *awsClients, c *curator.Curator, groups []types.Group) error {
+	out().Printf("Instance stack %v: putting instances of every instance group into Standby\n", *stack.Name)
+	standby := make([]standbyGroup, 0, len(groups))
+	for _, group := range groups {
+		if completedByResumedRun(group) {
+			continue
+		}
+		ctx := audit.WithGroup(ctx, *group.Name)
+		trackGroup(group)
+		if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+			return err
+		}
+
+		if len(group.Instances) == 0 && len(group.Clusters) == 0 {
+			out().Printf("No instances in instance group %v\n", *group.Name)
+			continue
+		}
+
+		instanceIds, err := getGroupInstanceIds(&group)
+		if err != nil {
+			return err
+		}
+		inDesiredState, err := c.GroupInDesiredState(ctx, group, types.ActionShutdown)
+		if err != nil {
+			return err
+		}
+		if inDesiredState || dryRun {
+			continue
+		}
+
+		if err := c.StandbyGroup(ctx, group, instanceIds); err != nil {
+			return err
+		}
+
+		// the group is completed by the second phase
+		suspendGroup()
+		standby = append(standby, standbyGroup{group: group, instanceIds: instanceIds})
+		out().Printf("Instance group %v: instances have been put into Standby\n", *group.Name)
+	}
+
+	out().Printf("Instance stack %v: stopping instances of every instance group\n", *stack.Name)
+	for _, g := range standby {
+		ctx := audit.WithGroup(ctx, *g.group.Name)
+		resumeGroup(g.group)
+		if err := c.StopStandbyGroup(ctx, g.group, g.instanceIds); err != nil {
+			return err
+		}
+
+		out().Printf("Instance group %v: shutdown has been completed\n", *g.group.Name)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(shutdownCmd)
 
 	// Local flags which will only run when this command is called directly
 	shutdownCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	shutdownCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+	shutdownCmd.Flags().BoolVar(&strictCoverage, "strict", false, "Fail when the Auto Scaling Groups have InService instances not covered by any group")
+	shutdownCmd.Flags().StringVar(&strategy, "strategy", "", "Shutdown strategy overriding the stack spec: sequential or two-phase")
 }
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// stackStdin is the stack spec read from stdin, which may be read only once
+var stackStdin []byte
+
+// stackSHA256 is the hex SHA-256 digest the stack spec is pinned to, if any
+var stackSHA256 string
+
+// readStackFile reads the stack spec of the --stack flag and verifies its digest, if pinned
+func readStackFile(ctx context.Context) ([]byte, error) {
+	source, err := readStackSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if stackSHA256 != "" {
+		digest := sha256.Sum256(source)
+		if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, stackSHA256) {
+			return nil, fmt.Errorf("stack spec %v has SHA-256 digest %v, expected %v", stackFileName(), actual, stackSHA256)
+		}
+	}
+	return source, nil
+}
+
+// readStackSpec reads the stack spec of the --stack flag: from stdin if the flag is -,
+// from S3 if the flag is an s3:// URL, from Parameter Store if the flag is an ssm:// URL, over HTTP if the flag is an http:// or https:// URL,
+// or from the file otherwise
+func readStackSpec(ctx context.Context) ([]byte, error) {
+	switch {
+	case stackFile == "-":
+		if stackStdin == nil {
+			source, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read stack spec from stdin: %w", err)
+			}
+			stackStdin = source
+		}
+		return stackStdin, nil
+	case strings.HasPrefix(stackFile, "s3://"):
+		return readS3StackFile(ctx, stackFile)
+	case strings.HasPrefix(stackFile, "ssm://"):
+		return readSSMStackFile(ctx, stackFile)
+	case strings.HasPrefix(stackFile, "https://"), strings.HasPrefix(stackFile, "http://"):
+		return readHTTPStackFile(ctx, stackFile)
+	}
+	return os.ReadFile(stackFile)
+}
+
+// stackSpecConfig returns the AWS config the stack spec is read with: the default AWS credentials,
+// as the stack spec configuring the credentials of the run is not read yet
+func stackSpecConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region), config.WithLogger(awsLogger{}))
+	if err != nil {
+		return cfg, err
+	}
+	setEndpointURL(&cfg)
+	return cfg, nil
+}
+
+// readS3StackFile reads the stack spec of the s3://bucket/key[?versionId=...] URL
+func readS3StackFile(ctx context.Context, location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack spec URL %v: %w", location, err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("invalid stack spec URL %v, expected s3://bucket/key[?versionId=...]", location)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(key),
+	}
+	if versionId := u.Query().Get("versionId"); versionId != "" {
+		input.VersionId = aws.String(versionId)
+	}
+
+	cfg, err := stackSpecConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s3.NewFromConfig(cfg).GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stack spec %v: %w", location, err)
+	}
+	defer output.Body.Close()
+
+	source, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stack spec %v: %w", location, err)
+	}
+	return source, nil
+}
+
+// readSSMStackFile reads the stack spec of the ssm://parameter-name URL from the String
+// or SecureString parameter, e.g. ssm:///stacks/web for the /stacks/web parameter
+func readSSMStackFile(ctx context.Context, location string) ([]byte, error) {
+	name := strings.TrimPrefix(location, "ssm://")
+	if name == "" {
+		return nil, fmt.Errorf("invalid stack spec URL %v, expected ssm://parameter-name", location)
+	}
+
+	cfg, err := stackSpecConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stack spec %v: %w", location, err)
+	}
+	return []byte(aws.ToString(output.Parameter.Value)), nil
+}
+
+// readHTTPStackFile reads the stack spec of the http:// or https:// URL
+func readHTTPStackFile(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack spec URL %v: %w", location, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stack spec %v: %w", location, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to read stack spec %v: %v", location, resp.Status)
+	}
+
+	source, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stack spec %v: %w", location, err)
+	}
+	return source, nil
+}
+
+// localStackFile reports whether the stack spec is a local file, which the completions may read
+// without consuming stdin or calling AWS
+func localStackFile() bool {
+	return stackFile != "" && stackFile != "-" && !strings.Contains(stackFile, "://")
+}
+
+// stackFileName returns the name of the stack spec in the validation errors
+func stackFileName() string {
+	if stackFile == "-" {
+		return "<stdin>"
+	}
+	return stackFile
+}
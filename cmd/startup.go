@@ -1,18 +1,11 @@
 package cmd
 
 import (
-	"context"
-	"time"
-
-	"github.com/k0kubun/pp/v3"
 	"github.com/spf13/cobra"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-
-	"github.com/ikorchynskyi/instance-stack-curator/internal/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
 )
 
 // startupCmd represents the startup command
@@ -20,84 +13,64 @@ var startupCmd = &cobra.Command{
 	Use:   "startup",
 	Short: "Startup instance stack",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := initStack(); err != nil {
+		if err := initStack(cmd.Context()); err != nil {
 			return err
 		}
 
-		ctx := context.TODO()
-		cfg, err := initAWS()
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
 		if err != nil {
 			return err
 		}
 
-		ec2Client := ec2.NewFromConfig(cfg)
-		var autoscalingClient *autoscaling.Client
-		if !dryRun {
-			autoscalingClient = autoscaling.NewFromConfig(cfg)
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionStartup)
+		if err := beginRun(ctx, types.ActionStartup, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
 		}
 
-		for i := range stack.Groups {
-			group := stack.Groups[len(stack.Groups)-1-i]
-			filters := append(stack.Filters, group.Filters...)
-			filters = append(
-				filters,
-				ec2Types.Filter{
-					Name: aws.String("instance-state-name"),
-					Values: []string{
-						string(ec2Types.InstanceStateNameRunning),
-						string(ec2Types.InstanceStateNameStopped),
-					},
-				},
-			)
-
-			if output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-				Filters: filters,
-			}); err != nil {
+		for _, group := range curator.OrderGroups(&stack, types.ActionStartup) {
+			if completedByResumedRun(group) {
+				continue
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
 				return err
-			} else {
-				for _, r := range output.Reservations {
-					group.Instances = append(group.Instances, r.Instances...)
-				}
 			}
 
-			if len(group.Instances) == 0 {
-				pp.Printf("No instances in instance group %v\n", *group.Name)
+			if len(group.Instances) == 0 && len(group.Clusters) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
 				continue
 			}
 
-			instanceIds := getGroupInstanceIds(&group)
-			if dryRun {
-				continue
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
 			}
-
-			if output, err := ec2Client.StartInstances(ctx, &ec2.StartInstancesInput{
-				InstanceIds: instanceIds,
-			}); err != nil {
+			inDesiredState, err := c.GroupInDesiredState(ctx, group, types.ActionStartup)
+			if err != nil {
 				return err
-			} else {
-				pp.Printf("Instance state changes in instance group %v: %v\n", *group.Name, output.StartingInstances)
+			}
+			if inDesiredState || dryRun {
+				continue
 			}
 
-			waiter := ec2.NewInstanceStatusOkWaiter(ec2Client, func(o *ec2.InstanceStatusOkWaiterOptions) {
-				o.LogWaitAttempts = true
-				o.MaxDelay = time.Minute
-			})
-			if output, err := waiter.WaitForOutput(ctx, &ec2.DescribeInstanceStatusInput{
-				InstanceIds: instanceIds,
-			}, curator.DefaultWaitDuration); err != nil {
+			if err := c.StartupGroup(ctx, group, instanceIds); err != nil {
 				return err
-			} else {
-				pp.Printf("Instance statuses in instance group %v: %v\n", *group.Name, output.InstanceStatuses)
 			}
 
-			if err := curator.PrepareInstanceGroupForStartup(ctx, autoscalingClient, group); err != nil {
+			out().Printf("Instance group %v: startup has been completed\n", *group.Name)
+
+			if err := pauseAfterCanary(ctx, group); err != nil {
 				return err
 			}
-
-			pp.Printf("Instance group %v: startup has been completed\n", *group.Name)
 		}
 
-		pp.Printf("Instance stack %v: startup has been completed\n", *stack.Name)
+		out().Summaryf("Instance stack %v: startup has been completed\n", *stack.Name)
 		return nil
 	},
 }
@@ -107,4 +80,6 @@ func init() {
 
 	// Local flags which will only run when this command is called directly
 	startupCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	startupCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+	startupCmd.Flags().BoolVar(&confirmCanaries, "confirm-canaries", false, "Continue past canary groups without an interactive confirmation")
 }
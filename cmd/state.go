@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/state"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// runState is the state of a failed or cancelled run, which a later run of the same action may resume
+type runState struct {
+	Stack           string   `json:"stack"`
+	Action          string   `json:"action"`
+	RunId           string   `json:"runId"`
+	CompletedGroups []string `json:"completedGroups"`
+}
+
+var resume bool
+var stateFile string
+var resumedState *runState
+
+// runStateKey returns the store and the key of the run state of the action on the stack
+func runStateKey(action string) (state.Store, string) {
+	return stateStoreKey(stateFile, fmt.Sprintf("%v-%v.json", *stack.Name, action))
+}
+
+// loadRunState reads the state of the resumed run, if resuming
+func loadRunState(ctx context.Context, action types.Action) error {
+	if !resume {
+		return nil
+	}
+
+	store, key := runStateKey(string(action))
+	path := store.Location(key)
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("no %v run of instance stack %v to resume in %v", action, *stack.Name, path)
+	}
+
+	var state runState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid run state %v: %w", path, err)
+	}
+	if state.Stack != *stack.Name || state.Action != string(action) {
+		return fmt.Errorf("run state %v is of %v run of instance stack %v", path, state.Action, state.Stack)
+	}
+
+	resumedState = &state
+	out().Printf("Resuming run %v, completed instance groups: %v\n", state.RunId, state.CompletedGroups)
+	return nil
+}
+
+// completedByResumedRun reports whether the group has been completed by the resumed run
+func completedByResumedRun(group types.Group) bool {
+	if resumedState == nil || !slices.Contains(resumedState.CompletedGroups, *group.Name) {
+		return false
+	}
+
+	out().Printf("Instance group %v: skipped, completed by run %v\n", *group.Name, resumedState.RunId)
+	return true
+}
+
+// saveRunState writes the state of a failed run, so that a later run may resume it with --resume,
+// and removes the state of a succeeded run. The state of a cancelled run is written as well.
+func saveRunState(ctx context.Context, summary run.Summary) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), curator.CleanupTimeout)
+	defer cancel()
+
+	store, key := runStateKey(summary.Action)
+	if summary.Result == run.ResultSucceeded {
+		return store.Delete(ctx, key)
+	}
+
+	state := runState{
+		Stack:           summary.Stack,
+		Action:          summary.Action,
+		RunId:           summary.RunId,
+		CompletedGroups: make([]string, 0, len(summary.Groups)),
+	}
+	if resumedState != nil {
+		state.CompletedGroups = append(state.CompletedGroups, resumedState.CompletedGroups...)
+	}
+	for _, g := range summary.Groups {
+		if g.Result == run.ResultSucceeded {
+			state.CompletedGroups = append(state.CompletedGroups, g.Name)
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, key, data); err != nil {
+		return err
+	}
+
+	out().Summaryf("Instance stack %v: run state has been written to %v, resume the run with --resume\n", summary.Stack, store.Location(key))
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/state"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// stateStore is the state backend of the stack, initialized by initStateStore
+var stateStore state.Store
+
+// initStateStore initializes the state backend configured for the stack, the local files by default
+func initStateStore(cfg aws.Config) {
+	backend := stack.State
+	switch {
+	case backend != nil && backend.S3 != nil:
+		cfg := cfg.Copy()
+		if backend.S3.Region != nil {
+			cfg.Region = *backend.S3.Region
+		}
+		stateStore = state.NewS3Store(s3.NewFromConfig(cfg), *backend.S3.Bucket, aws.ToString(backend.S3.Prefix))
+	case backend != nil && backend.DynamoDB != nil:
+		cfg := cfg.Copy()
+		if backend.DynamoDB.Region != nil {
+			cfg.Region = *backend.DynamoDB.Region
+		}
+		stateStore = state.NewDynamoDBStore(dynamodb.NewFromConfig(cfg), *backend.DynamoDB.Table)
+	case backend != nil && backend.File != nil:
+		stateStore = state.NewFileStore(*backend.File.Dir)
+	default:
+		stateStore = state.NewFileStore(filepath.Join(os.TempDir(), "instance-stack-curator"))
+	}
+}
+
+// stateStoreKey returns the store and the key of the state value, the file overriding the state backend if set
+func stateStoreKey(file, key string) (state.Store, string) {
+	if file != "" {
+		return state.NewFileStore(filepath.Dir(file)), filepath.Base(file)
+	}
+	return stateStore, key
+}
+
+// stateSizeRecorder records the Auto Scaling Group sizes lowered by the shutdown in the state backend
+type stateSizeRecorder struct{}
+
+// sizesKey returns the key of the recorded Auto Scaling Group sizes of the group
+func sizesKey(group string) string {
+	return fmt.Sprintf("%v-%v-sizes.json", *stack.Name, group)
+}
+
+func (stateSizeRecorder) RecordShutdownChanges(ctx context.Context, group string, changes []types.AutoScalingGroupChange) error {
+	if stateStore == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return stateStore.Put(ctx, sizesKey(group), data)
+}
+
+func (stateSizeRecorder) ShutdownChanges(ctx context.Context, group string) ([]types.AutoScalingGroupChange, error) {
+	if stateStore == nil {
+		return nil, nil
+	}
+
+	data, err := stateStore.Get(ctx, sizesKey(group))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var changes []types.AutoScalingGroupChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("invalid recorded ASG sizes %v: %w", stateStore.Location(sizesKey(group)), err)
+	}
+	return changes, nil
+}
+
+func (stateSizeRecorder) ForgetShutdownChanges(ctx context.Context, group string) error {
+	if stateStore == nil {
+		return nil
+	}
+	return stateStore.Delete(ctx, sizesKey(group))
+}
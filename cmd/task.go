@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+)
+
+// taskTokenEnv is the environment variable the task token is read from unless given by the flag
+const taskTokenEnv = "CURATOR_TASK_TOKEN"
+
+// Step Functions limits of the task result
+const (
+	maxTaskOutput = 256 * 1024
+	maxTaskCause  = 32768
+)
+
+// taskError is the error name of a failed task, matched by the Retry and Catch fields of the state machine
+const taskError = "InstanceStackCurator.RunFailed"
+
+// taskResultTimeout bounds the sending of the task result, so that a command never hangs on Step Functions
+const taskResultTimeout = time.Minute
+
+var taskToken string
+
+// initTaskToken reads the task token from the environment unless given by the flag
+func initTaskToken() {
+	if taskToken == "" {
+		taskToken = os.Getenv(taskTokenEnv)
+	}
+}
+
+// sendTaskResult reports the outcome of the command to Step Functions with the task token,
+// with the run report as the task output or the failure cause. The outcome of a cancelled command
+// is reported as well, within taskResultTimeout.
+func sendTaskResult(ctx context.Context, runErr error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), taskResultTimeout)
+	defer cancel()
+
+	// the callback is sent with the credentials of the curator itself rather than of the assumed role
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("unable to send task result: %w", err))
+	}
+	if cfg.Region == "" {
+		cfg.Region = aws.ToString(stack.Region)
+	}
+	setEndpointURL(&cfg)
+	client := sfn.NewFromConfig(cfg)
+
+	if runErr == nil {
+		_, err = client.SendTaskSuccess(ctx, &sfn.SendTaskSuccessInput{
+			TaskToken: aws.String(taskToken),
+			Output:    aws.String(encodeTaskReport(maxTaskOutput)),
+		})
+	} else {
+		cause := runErr.Error()
+		if runTracker != nil {
+			cause = encodeTaskReport(maxTaskCause)
+		}
+		_, err = client.SendTaskFailure(ctx, &sfn.SendTaskFailureInput{
+			TaskToken: aws.String(taskToken),
+			Error:     aws.String(taskError),
+			Cause:     aws.String(truncate(cause, maxTaskCause)),
+		})
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to send task result: %w", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return errors.Join(runErr, err)
+	}
+
+	out().Summaryf("Task result has been sent to Step Functions\n")
+	return runErr
+}
+
+// encodeTaskReport encodes the run report as JSON. A report exceeding the size limit is encoded
+// without the actions and, if still too large, as the run summary only.
+func encodeTaskReport(limit int) string {
+	if runTracker == nil {
+		encoded, _ := json.Marshal(map[string]string{"command": commandPath, "runId": runId, "result": run.ResultSucceeded})
+		return string(encoded)
+	}
+
+	report := runTracker.Report()
+	var encoded []byte
+	for _, r := range []any{report, run.Report{Summary: report.Summary}, report.Summary} {
+		var err error
+		if encoded, err = json.Marshal(r); err == nil && len(encoded) <= limit {
+			break
+		}
+	}
+	return string(encoded)
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit]
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// thawCmd represents the thaw command
+var thawCmd = &cobra.Command{
+	Use:   "thaw",
+	Short: "Return frozen instance stack to service",
+	Long: `Return the Standby Auto Scaling instances of every group to service in the startup order,
+without starting the instances, once the maintenance started by the freeze command is over.
+The MaxSize and MinSize of the Auto Scaling Groups are raised when required.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+		c := newCurator(clients, types.ActionThaw)
+		if err := beginRun(ctx, types.ActionThaw, cfg); err != nil {
+			return err
+		}
+		if err := guardOverlaps(ctx, clients); err != nil {
+			return err
+		}
+
+		for _, group := range curator.OrderGroups(&stack, types.ActionStartup) {
+			if completedByResumedRun(group) {
+				continue
+			}
+			ctx := audit.WithGroup(ctx, *group.Name)
+			trackGroup(group)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+
+			if len(group.Instances) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
+			}
+			if err := c.ThawGroup(ctx, group, instanceIds); err != nil {
+				return err
+			}
+			if !dryRun {
+				out().Printf("Instance group %v: instances %v have been thawed\n", *group.Name, instanceIds)
+			}
+		}
+
+		out().Summaryf("Instance stack %v: thaw has been completed\n", *stack.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(thawCmd)
+
+	// Local flags which will only run when this command is called directly
+	thawCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Set to true to disable actual instance changes")
+	thawCmd.Flags().BoolVar(&force, "force", false, "Run outside the operation windows of the stack")
+}
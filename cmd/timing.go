@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// printTimings renders the per group and per phase timings of the run
+func printTimings(summary run.Summary) {
+	if len(summary.Groups) == 0 {
+		return
+	}
+
+	phases := summary.Phases()
+	header := []string{"Group"}
+	for _, p := range phases {
+		header = append(header, string(p))
+	}
+	header = append(header, "Waiters", "Waiter attempts", "Total")
+
+	rows := make([][]string, 0, len(summary.Groups))
+	for _, g := range summary.Groups {
+		row := []string{g.Name}
+		for _, p := range phases {
+			row = append(row, formatTiming(g.PhaseDuration(p)))
+		}
+		row = append(row, formatTiming(g.WaiterDuration), fmt.Sprint(g.WaiterAttempts), formatTiming(g.Duration()))
+		rows = append(rows, row)
+	}
+
+	out().PrintTable(curator.Table{
+		Name:       "timings",
+		Header:     header,
+		Rows:       rows,
+		AlignRight: true,
+	})
+}
+
+func formatTiming(d time.Duration) string {
+	return d.Round(100 * time.Millisecond).String()
+}
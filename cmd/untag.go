@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/audit"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// untagCmd represents the untag command
+var untagCmd = &cobra.Command{
+	Use:   "untag",
+	Short: "Remove run metadata tags of instance stack",
+	Long: `Remove the run metadata tags written by the curator from the instances
+of the stack and their Auto Scaling Groups.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := initStack(cmd.Context()); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		cfg, err := initAWS(ctx)
+		if err != nil {
+			return err
+		}
+
+		clients := newAWSClients(cfg)
+
+		for _, group := range curator.OrderGroups(&stack, types.ActionShutdown) {
+			ctx := audit.WithGroup(ctx, *group.Name)
+			if err := out().ResolveGroupInstances(ctx, clients.ec2, &stack, &group); err != nil {
+				return err
+			}
+
+			if len(group.Instances) == 0 {
+				out().Printf("No instances in instance group %v\n", *group.Name)
+				continue
+			}
+
+			instanceIds, err := getGroupInstanceIds(&group)
+			if err != nil {
+				return err
+			}
+			if err := out().UntagInstanceGroup(ctx, clients.ec2, clients.autoscalingTags, group, instanceIds, curator.RunTagsPrefix(&stack)); err != nil {
+				return err
+			}
+		}
+
+		out().Summaryf("Instance stack %v: run metadata tags have been removed\n", *stack.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(untagCmd)
+}
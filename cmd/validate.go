@@ -1,21 +1,89 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"sort"
+
 	"github.com/spf13/cobra"
 )
 
+var checkOverlaps bool
+var allowOverlaps bool
+
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate instance stack",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := initStack(); err != nil {
+		if err := initStack(cmd.Context()); err != nil {
 			return err
 		}
-		return nil
+
+		if !checkOverlaps {
+			return nil
+		}
+
+		cfg, err := initAWS(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return validateOverlaps(cmd.Context(), newAWSClients(cfg))
 	},
 }
 
+// validateOverlaps fails when instances are matched by the filters of more than one group
+func validateOverlaps(ctx context.Context, clients *awsClients) error {
+	overlapping, err := out().FindOverlappingInstances(ctx, clients.ec2, &stack)
+	if err != nil {
+		return err
+	}
+
+	if len(overlapping) == 0 {
+		out().Summaryf("Instance stack %v: no instances are matched by more than one group\n", *stack.Name)
+		return nil
+	}
+	return reportOverlaps(overlapping)
+}
+
+// guardOverlaps fails the run before any change when instances are matched by the filters of more than
+// one group, as handling an instance twice corrupts the bookkeeping of the ASG sizes.
+// With --allow-overlaps the overlapping instances are reported without failing the run.
+func guardOverlaps(ctx context.Context, clients *awsClients) error {
+	overlapping, err := out().FindOverlappingInstances(ctx, clients.ec2, &stack)
+	if err != nil {
+		return err
+	}
+
+	if len(overlapping) == 0 {
+		return nil
+	}
+	err = reportOverlaps(overlapping)
+	if allowOverlaps {
+		out().Summaryf("Warning: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// reportOverlaps reports the groups of every overlapping instance and returns the error of the overlaps
+func reportOverlaps(overlapping map[string][]string) error {
+	instanceIds := make([]string, 0, len(overlapping))
+	for id := range overlapping {
+		instanceIds = append(instanceIds, id)
+	}
+	sort.Strings(instanceIds)
+
+	for _, id := range instanceIds {
+		out().Summaryf("Instance %v is matched by instance groups %v\n", id, overlapping[id])
+	}
+	return fmt.Errorf("%v instances of instance stack %v are matched by more than one group", len(overlapping), *stack.Name)
+}
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
+
+	// Local flags which will only run when this command is called directly
+	validateCmd.Flags().BoolVar(&checkOverlaps, "check-overlaps", false, "Resolve the group instances and fail when instances are matched by more than one group")
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/version"
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and the build metadata",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Get()
+		out().Summaryf("Version:     %v\n", info.Version)
+		out().Summaryf("Commit:      %v\n", info.Commit)
+		out().Summaryf("Build date:  %v\n", info.Date)
+		out().Summaryf("Go version:  %v\n", info.GoVersion)
+		out().Summaryf("SDK version: %v\n", info.SDKVersion)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	rootCmd.Version = version.Get().String()
+	rootCmd.SetVersionTemplate("instance-stack-curator {{.Version}}\n")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+var force bool
+
+// checkOperationWindows refuses to run the action outside the operation windows of the stack, unless forced
+func checkOperationWindows(action types.Action) error {
+	open, err := curator.OperationWindowsOpen(&stack, action, time.Now())
+	if err != nil {
+		return err
+	}
+	if open {
+		return nil
+	}
+
+	if force {
+		out().Summaryf("Warning: instance stack %v: %v is forced outside the operation windows\n", *stack.Name, action)
+		return nil
+	}
+	return fmt.Errorf("instance stack %v: %v is not allowed outside the operation windows, use --force to run it anyway", *stack.Name, action)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/spf13/cobra"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/internal/worker"
+)
+
+var workerConfig worker.Config
+var stackDir string
+
+// workerCmd represents the worker command
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Execute curation requests received from an SQS queue",
+	Long: `Receive curation requests from an SQS queue and execute them one at a time per stack.
+
+A request is a JSON message naming the stack spec, relative to the stack directory, and the action:
+
+  {"stack": "web.yaml", "action": "shutdown", "env": "prod"}
+
+The responses, with the run summary, are sent to the response queue and published to the response topic.
+A request is deleted once its response is reported, so that it is redelivered otherwise.
+
+The requests of a stack are executed one at a time within a worker only. The workers of several hosts
+rely on the lock of the stack taken by each run, which needs a state backend shared by the hosts.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		if err != nil {
+			return err
+		}
+		setEndpointURL(&cfg)
+
+		out().Printf("Receiving curation requests from %v\n", workerConfig.QueueURL)
+		worker.New(sqs.NewFromConfig(cfg), sns.NewFromConfig(cfg), workerConfig, executeRequest).Run(ctx)
+		out().Printf("Curation requests are no longer received\n")
+		return nil
+	},
+}
+
+// executeRequest executes the request with a separate curator process of the run ID, returning the summary of its run report
+func executeRequest(ctx context.Context, request worker.Request, runId string) (*run.Summary, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	reportFile, err := os.CreateTemp("", "instance-stack-curator-*.json")
+	if err != nil {
+		return nil, err
+	}
+	reportFile.Close()
+	defer os.Remove(reportFile.Name())
+
+	// the stack reference may not escape the stack directory
+	stackPath := filepath.Join(stackDir, request.StackPath())
+	args := []string{string(request.Action), "--stack", stackPath, "--report", reportFile.Name(), "--run-id", runId}
+	if request.Env != "" {
+		args = append(args, "--env", request.Env)
+	}
+	if request.DryRun {
+		args = append(args, "--dry-run")
+	}
+	args = append(args, "--output", outputFormat)
+	if endpointURL != "" {
+		args = append(args, "--endpoint-url", endpointURL)
+	}
+
+	out().Printf("Executing %v of %v, run %v\n", request.Action, stackPath, runId)
+	command := exec.CommandContext(ctx, executable, args...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	runErr := command.Run()
+
+	var report run.Report
+	if data, err := os.ReadFile(reportFile.Name()); err != nil || len(data) == 0 || json.Unmarshal(data, &report) != nil {
+		return nil, runErr
+	}
+	return &report.Summary, runErr
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+
+	// Local flags which will only run when this command is called directly
+	workerCmd.Flags().StringVar(&workerConfig.QueueURL, "queue-url", "", "URL of the SQS queue the curation requests are received from")
+	workerCmd.MarkFlagRequired("queue-url")
+	workerCmd.Flags().StringVar(&workerConfig.ResponseQueueURL, "response-queue-url", "", "URL of the SQS queue the responses are sent to")
+	workerCmd.Flags().StringVar(&workerConfig.ResponseTopicARN, "response-topic-arn", "", "ARN of the SNS topic the responses are published to")
+	workerCmd.Flags().StringVar(&stackDir, "stack-dir", ".", "Directory the stack specs of the requests are resolved in")
+	workerCmd.Flags().IntVar(&workerConfig.Concurrency, "concurrency", 1, "Number of requests executed concurrently, one at a time per stack")
+	workerCmd.Flags().DurationVar(&workerConfig.VisibilityTimeout, "visibility-timeout", 5*time.Minute, "Visibility timeout of the received requests, extended while they are executed")
+	workerCmd.Flags().DurationVar(&workerConfig.WaitTime, "wait-time", 20*time.Second, "Long polling wait time of the SQS receives")
+}
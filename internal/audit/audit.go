@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmsTypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/version"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// RecordVersion is the version of the audit record format
+const RecordVersion = "1"
+
+// ignoredServices are not audited as they do not mutate curated resources
+var ignoredServices = map[string]bool{
+	"DynamoDB": true,
+	"KMS":      true,
+	"S3":       true,
+	"STS":      true,
+}
+
+type groupKey struct{}
+
+// WithGroup returns a context attributing the audited calls to the instance group.
+func WithGroup(ctx context.Context, group string) context.Context {
+	return context.WithValue(ctx, groupKey{}, group)
+}
+
+// Call is an audited mutating AWS call
+type Call struct {
+	Time                 time.Time   `json:"time"`
+	Service              string      `json:"service"`
+	Operation            string      `json:"operation"`
+	Group                string      `json:"group,omitempty"`
+	AutoScalingGroupName string      `json:"autoScalingGroupName,omitempty"`
+	InstanceIds          []string    `json:"instanceIds,omitempty"`
+	Parameters           interface{} `json:"parameters"`
+	RequestID            string      `json:"requestId,omitempty"`
+	Error                string      `json:"error,omitempty"`
+}
+
+// Record is the audit record of a curator run
+type Record struct {
+	Version    string       `json:"version"`
+	Build      version.Info `json:"build"`
+	Stack      string       `json:"stack"`
+	Command    string       `json:"command"`
+	RunId      string       `json:"runId"`
+	Region     string       `json:"region"`
+	Principal  string       `json:"principal,omitempty"`
+	User       string       `json:"user,omitempty"`
+	Host       string       `json:"host,omitempty"`
+	StartedAt  time.Time    `json:"startedAt"`
+	FinishedAt time.Time    `json:"finishedAt"`
+	Result     string       `json:"result"`
+	Error      string       `json:"error,omitempty"`
+	Calls      []Call       `json:"calls"`
+}
+
+// Signature is the KMS HMAC signature of an audit record
+type Signature struct {
+	KeyId     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	Mac       string `json:"mac"`
+}
+
+// SignedRecord is the document uploaded to S3
+type SignedRecord struct {
+	// Record is the JSON encoded audit record, kept verbatim so the signature can be verified.
+	Record    json.RawMessage `json:"record"`
+	Signature *Signature      `json:"signature,omitempty"`
+}
+
+// Recorder records the mutating AWS calls of a curator run
+type Recorder struct {
+	mu     sync.Mutex
+	record Record
+}
+
+// NewRecorder constructs a Recorder for the run of the command on the stack.
+func NewRecorder(stack, command, runId, region string) *Recorder {
+	r := &Recorder{
+		record: Record{
+			Version:   RecordVersion,
+			Build:     version.Get(),
+			Stack:     stack,
+			Command:   command,
+			RunId:     runId,
+			Region:    region,
+			StartedAt: time.Now().UTC(),
+			Calls:     make([]Call, 0),
+		},
+	}
+	if u, err := user.Current(); err == nil {
+		r.record.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		r.record.Host = host
+	}
+	return r
+}
+
+// SetPrincipal records the AWS principal the run is performed as.
+func (r *Recorder) SetPrincipal(principal string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record.Principal = principal
+}
+
+// Len returns the number of recorded calls.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.record.Calls)
+}
+
+// AddMiddleware is an API option recording the mutating calls of an AWS client.
+func (r *Recorder) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AuditRecorder", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+		if ignoredServices[service] || !isMutating(operation) {
+			return next.HandleInitialize(ctx, in)
+		}
+
+		call := Call{
+			Time:       time.Now().UTC(),
+			Service:    service,
+			Operation:  operation,
+			Parameters: in.Parameters,
+		}
+		if group, ok := ctx.Value(groupKey{}).(string); ok {
+			call.Group = group
+		}
+		call.AutoScalingGroupName, call.InstanceIds = describeParameters(in.Parameters)
+
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+			call.RequestID = requestID
+		}
+		if err != nil {
+			call.Error = err.Error()
+		}
+
+		r.mu.Lock()
+		r.record.Calls = append(r.record.Calls, call)
+		r.mu.Unlock()
+		return out, metadata, err
+	}), middleware.After)
+}
+
+func isMutating(operation string) bool {
+	for _, prefix := range []string{"Describe", "Get", "List"} {
+		if strings.HasPrefix(operation, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// describeParameters extracts the ASG name and instance IDs of an operation input, if any
+func describeParameters(parameters interface{}) (string, []string) {
+	v := reflect.Indirect(reflect.ValueOf(parameters))
+	if v.Kind() != reflect.Struct {
+		return "", nil
+	}
+
+	var asgName string
+	if f := v.FieldByName("AutoScalingGroupName"); f.IsValid() {
+		if name, ok := f.Interface().(*string); ok {
+			asgName = aws.ToString(name)
+		}
+	}
+
+	var instanceIds []string
+	if f := v.FieldByName("InstanceIds"); f.IsValid() {
+		instanceIds, _ = f.Interface().([]string)
+	}
+	return asgName, instanceIds
+}
+
+// Finish completes the record with the result of the run.
+func (r *Recorder) Finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record.FinishedAt = time.Now().UTC()
+	r.record.Result = "succeeded"
+	if err != nil {
+		r.record.Result = "failed"
+		r.record.Error = err.Error()
+	}
+}
+
+// Upload signs the record with the configured KMS HMAC key, if any, and uploads it to S3.
+// It returns the S3 URL of the uploaded record.
+func (r *Recorder) Upload(ctx context.Context, s3Client *s3.Client, kmsClient *kms.Client, config *types.Audit) (string, error) {
+	r.mu.Lock()
+	recordJson, err := json.Marshal(r.record)
+	startedAt := r.record.StartedAt
+	r.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	signed := SignedRecord{Record: recordJson}
+	if config.KMSKeyId != nil {
+		output, err := kmsClient.GenerateMac(ctx, &kms.GenerateMacInput{
+			KeyId:        config.KMSKeyId,
+			MacAlgorithm: kmsTypes.MacAlgorithmSpecHmacSha256,
+			Message:      recordJson,
+		})
+		if err != nil {
+			return "", fmt.Errorf("error signing audit record: %w", err)
+		}
+		signed.Signature = &Signature{
+			KeyId:     aws.ToString(output.KeyId),
+			Algorithm: string(output.MacAlgorithm),
+			Mac:       base64.StdEncoding.EncodeToString(output.Mac),
+		}
+	}
+
+	body, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	key := path.Join(
+		aws.ToString(config.Prefix),
+		r.record.Stack,
+		fmt.Sprintf("%v-%v.json", startedAt.Format("20060102T150405Z"), strings.ReplaceAll(r.record.Command, " ", "-")),
+	)
+	if _, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      config.Bucket,
+		Key:         aws.String(key),
+		Body:        strings.NewReader(string(body)),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return "", fmt.Errorf("error uploading audit record: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%v/%v", *config.Bucket, key), nil
+}
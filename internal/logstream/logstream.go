@@ -0,0 +1,168 @@
+// Package logstream streams the structured log output to CloudWatch Logs.
+package logstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwlTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// FlushInterval is the interval the buffered log events are put at
+const FlushInterval = 5 * time.Second
+
+// PutTimeout is the maximum duration of putting a batch of log events
+const PutTimeout = 30 * time.Second
+
+// PutLogEvents limits of a single batch
+const (
+	maxBatchEvents = 10000
+	maxBatchSize   = 1024 * 1024
+
+	// overhead of every event counted towards the batch size
+	eventOverhead = 26
+)
+
+// CloudWatchLogsAPI is the subset of the CloudWatch Logs client operations used by the Writer.
+type CloudWatchLogsAPI interface {
+	CreateLogGroup(context.Context, *cloudwatchlogs.CreateLogGroupInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(context.Context, *cloudwatchlogs.CreateLogStreamInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(context.Context, *cloudwatchlogs.PutLogEventsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// Writer is an io.WriteCloser putting every written line as a log event of a CloudWatch Logs stream.
+// The events are buffered and put in batches every FlushInterval, once a batch is full and on Close.
+type Writer struct {
+	mu sync.Mutex
+
+	// ctx is the context of Open without its cancellation, so that the logs of a cancelled run are put as well
+	ctx           context.Context
+	client        CloudWatchLogsAPI
+	logGroupName  string
+	logStreamName string
+
+	events []cwlTypes.InputLogEvent
+	size   int
+	err    error
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Open creates the log stream, and the log group if it does not exist, and starts the periodic flushes.
+func Open(ctx context.Context, client CloudWatchLogsAPI, logGroupName, logStreamName string) (*Writer, error) {
+	if err := createLogStream(ctx, client, logGroupName, logStreamName); err != nil {
+		return nil, fmt.Errorf("unable to create log stream %v of log group %v: %w", logStreamName, logGroupName, err)
+	}
+
+	w := &Writer{
+		ctx:           context.WithoutCancel(ctx),
+		client:        client,
+		logGroupName:  logGroupName,
+		logStreamName: logStreamName,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func createLogStream(ctx context.Context, client CloudWatchLogsAPI, logGroupName, logStreamName string) error {
+	input := &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroupName),
+		LogStreamName: aws.String(logStreamName),
+	}
+
+	_, err := client.CreateLogStream(ctx, input)
+	var notFound *cwlTypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		return err
+	}
+
+	if _, err := client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(logGroupName),
+	}); err != nil {
+		return err
+	}
+	_, err = client.CreateLogStream(ctx, input)
+	return err
+}
+
+func (w *Writer) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			w.flush()
+			w.mu.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Write buffers every line of p as a log event.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	timestamp := aws.Int64(time.Now().UnixMilli())
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if len(w.events) == maxBatchEvents || w.size+len(line)+eventOverhead > maxBatchSize {
+			w.flush()
+		}
+		w.events = append(w.events, cwlTypes.InputLogEvent{
+			Message:   aws.String(string(line)),
+			Timestamp: timestamp,
+		})
+		w.size += len(line) + eventOverhead
+	}
+	return len(p), nil
+}
+
+// flush puts the buffered events, keeping the first error to be reported by Close
+func (w *Writer) flush() {
+	if len(w.events) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, PutTimeout)
+	defer cancel()
+
+	if _, err := w.client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(w.logGroupName),
+		LogStreamName: aws.String(w.logStreamName),
+		LogEvents:     w.events,
+	}); err != nil && w.err == nil {
+		w.err = fmt.Errorf("unable to put log events to log stream %v of log group %v: %w", w.logStreamName, w.logGroupName, err)
+	}
+	w.events = nil
+	w.size = 0
+}
+
+// Close stops the periodic flushes and puts the remaining events. It returns the first error
+// of putting the events, if any.
+func (w *Writer) Close() error {
+	close(w.done)
+	<-w.stopped
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.flush()
+	return w.err
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sesTypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// SESAPI is the subset of the SES client operations used by the email notifier.
+type SESAPI interface {
+	SendEmail(context.Context, *sesv2.SendEmailInput, ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
+var emailTemplate = template.Must(template.New("email").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) time.Duration { return d.Round(time.Second) },
+	"upper":    strings.ToUpper,
+}).Parse(`Instance stack {{.Stack}}: {{.Action}} has {{.Result}}
+
+Run ID:    {{.RunId}}
+Started:   {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}
+Finished:  {{.FinishedAt.Format "2006-01-02 15:04:05 MST"}}
+Duration:  {{duration .Duration}}
+{{- if .Error}}
+Error:     {{.Error}}
+{{- end}}
+
+Groups:
+{{- range .Groups}}
+  {{.Name}}: {{upper .Result}} in {{duration .Duration}}{{if .Error}} - {{.Error}}{{end}}
+{{- else}}
+  none
+{{- end}}
+{{- if .Links}}
+
+Links:
+{{- range $name, $location := .Links}}
+  {{$name}}: {{$location}}
+{{- end}}
+{{- end}}
+`))
+
+// EmailNotifier sends the summary of the finished run with SES
+type EmailNotifier struct {
+	sesClient SESAPI
+	config    *types.EmailNotification
+}
+
+// NewEmailNotifier constructs an EmailNotifier sending to the configured recipients.
+func NewEmailNotifier(sesClient SESAPI, config *types.EmailNotification) *EmailNotifier {
+	return &EmailNotifier{sesClient: sesClient, config: config}
+}
+
+// Notify sends the run summary of the run-finished event, unless the run has succeeded
+// and only failures are notified.
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	if event.Type != EventRunFinished || event.Summary == nil {
+		return nil
+	}
+
+	summary := *event.Summary
+	if n.config.OnFailureOnly && summary.Result != run.ResultFailed {
+		return nil
+	}
+
+	var body bytes.Buffer
+	if err := emailTemplate.Execute(&body, summary); err != nil {
+		return fmt.Errorf("error rendering email notification: %w", err)
+	}
+
+	subject := fmt.Sprintf("[%v] Instance stack %v: %v %v", strings.ToUpper(summary.Result), summary.Stack, summary.Action, summary.RunId)
+	if _, err := n.sesClient.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: n.config.From,
+		Destination: &sesTypes.Destination{
+			ToAddresses: n.config.To,
+			CcAddresses: n.config.Cc,
+		},
+		Content: &sesTypes.EmailContent{
+			Simple: &sesTypes.Message{
+				Subject: &sesTypes.Content{Data: aws.String(subject), Charset: aws.String("UTF-8")},
+				Body: &sesTypes.Body{
+					Text: &sesTypes.Content{Data: aws.String(body.String()), Charset: aws.String("UTF-8")},
+				},
+			},
+		},
+	}); err != nil {
+		return fmt.Errorf("error sending email notification: %w", err)
+	}
+	return nil
+}
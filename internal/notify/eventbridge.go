@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebTypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DefaultEventSource is the source of the EventBridge events unless configured
+const DefaultEventSource = "instance-stack-curator"
+
+// EventBridgeAPI is the subset of the EventBridge client operations used by the EventBridge notifier.
+type EventBridgeAPI interface {
+	PutEvents(context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeNotifier puts the run events as custom EventBridge events
+type EventBridgeNotifier struct {
+	eventbridgeClient EventBridgeAPI
+	eventBus          *string
+	source            string
+}
+
+// NewEventBridgeNotifier constructs an EventBridgeNotifier putting to the configured event bus.
+func NewEventBridgeNotifier(eventbridgeClient EventBridgeAPI, config *types.EventBridgeNotification) *EventBridgeNotifier {
+	source := DefaultEventSource
+	if config.Source != nil {
+		source = *config.Source
+	}
+	return &EventBridgeNotifier{eventbridgeClient: eventbridgeClient, eventBus: config.EventBus, source: source}
+}
+
+// Notify puts the event with the detail type of the stack or group transition, e.g. GroupShutdownCompleted,
+// and the JSON encoded event as the detail.
+func (n *EventBridgeNotifier) Notify(ctx context.Context, event Event) error {
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	output, err := n.eventbridgeClient.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebTypes.PutEventsRequestEntry{
+			{
+				EventBusName: n.eventBus,
+				Source:       aws.String(n.source),
+				DetailType:   aws.String(DetailType(event)),
+				Detail:       aws.String(string(detail)),
+				Time:         aws.Time(event.Time),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error putting EventBridge event %v: %w", DetailType(event), err)
+	}
+	if output.FailedEntryCount > 0 && len(output.Entries) > 0 {
+		entry := output.Entries[0]
+		return fmt.Errorf("error putting EventBridge event %v: %v: %v", DetailType(event), aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
+
+// DetailType returns the EventBridge detail type of the event: Stack or Group, the action and the transition,
+// e.g. StackStartupStarted, GroupShutdownCompleted or GroupRestartFailed.
+func DetailType(event Event) string {
+	action := event.Action
+	if action != "" {
+		action = strings.ToUpper(action[:1]) + action[1:]
+	}
+
+	switch event.Type {
+	case EventRunStarted:
+		return "Stack" + action + "Started"
+	case EventRunFinished:
+		if event.Summary != nil && event.Summary.Result == run.ResultFailed {
+			return "Stack" + action + "Failed"
+		}
+		return "Stack" + action + "Completed"
+	case EventGroupStarted:
+		return "Group" + action + "Started"
+	case EventGroupCompleted:
+		if event.Group != nil && event.Group.Result == run.ResultFailed {
+			return "Group" + action + "Failed"
+		}
+		return "Group" + action + "Completed"
+	case EventCapacityRetry:
+		return "Group" + action + "CapacityRetry"
+	}
+	return string(event.Type)
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DefaultMetricsNamespace is the namespace of the run metrics unless configured
+const DefaultMetricsNamespace = "InstanceStackCurator"
+
+// maxMetricData is the number of metrics put with a single call
+const maxMetricData = 20
+
+// CloudWatchAPI is the subset of the CloudWatch client operations used by the metrics notifier.
+type CloudWatchAPI interface {
+	PutMetricData(context.Context, *cloudwatch.PutMetricDataInput, ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// MetricsNotifier publishes the metrics of the finished run to CloudWatch
+type MetricsNotifier struct {
+	cloudwatchClient CloudWatchAPI
+	namespace        string
+}
+
+// NewMetricsNotifier constructs a MetricsNotifier publishing under the configured namespace.
+func NewMetricsNotifier(cloudwatchClient CloudWatchAPI, config *types.MetricsNotification) *MetricsNotifier {
+	namespace := DefaultMetricsNamespace
+	if config.Namespace != nil {
+		namespace = *config.Namespace
+	}
+	return &MetricsNotifier{cloudwatchClient: cloudwatchClient, namespace: namespace}
+}
+
+// Notify publishes the run and group durations, instance counts and failures of the run-finished event
+// with the stack, action and group dimensions.
+func (n *MetricsNotifier) Notify(ctx context.Context, event Event) error {
+	if event.Type != EventRunFinished || event.Summary == nil {
+		return nil
+	}
+
+	summary := *event.Summary
+	timestamp := aws.Time(summary.FinishedAt)
+	runDimensions := []cwTypes.Dimension{
+		{Name: aws.String("Stack"), Value: aws.String(summary.Stack)},
+		{Name: aws.String("Action"), Value: aws.String(summary.Action)},
+	}
+
+	data := []cwTypes.MetricDatum{
+		durationDatum("RunDuration", runDimensions, summary.Duration(), timestamp),
+		countDatum("RunFailures", runDimensions, failures(summary.Result), timestamp),
+	}
+	for _, g := range summary.Groups {
+		groupDimensions := append(runDimensions[:len(runDimensions):len(runDimensions)], cwTypes.Dimension{Name: aws.String("Group"), Value: aws.String(g.Name)})
+		data = append(data,
+			durationDatum("GroupDuration", groupDimensions, g.Duration(), timestamp),
+			countDatum("GroupInstances", groupDimensions, len(g.InstanceIds), timestamp),
+			countDatum("GroupFailures", groupDimensions, failures(g.Result), timestamp),
+		)
+	}
+
+	for start := 0; start < len(data); start += maxMetricData {
+		if _, err := n.cloudwatchClient.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(n.namespace),
+			MetricData: data[start:min(start+maxMetricData, len(data))],
+		}); err != nil {
+			return fmt.Errorf("error publishing run metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+func failures(result string) int {
+	if result == run.ResultFailed {
+		return 1
+	}
+	return 0
+}
+
+func durationDatum(name string, dimensions []cwTypes.Dimension, d time.Duration, timestamp *time.Time) cwTypes.MetricDatum {
+	return cwTypes.MetricDatum{
+		MetricName: aws.String(name),
+		Dimensions: dimensions,
+		Timestamp:  timestamp,
+		Unit:       cwTypes.StandardUnitSeconds,
+		Value:      aws.Float64(d.Seconds()),
+	}
+}
+
+func countDatum(name string, dimensions []cwTypes.Dimension, count int, timestamp *time.Time) cwTypes.MetricDatum {
+	return cwTypes.MetricDatum{
+		MetricName: aws.String(name),
+		Dimensions: dimensions,
+		Timestamp:  timestamp,
+		Unit:       cwTypes.StandardUnitCount,
+		Value:      aws.Float64(float64(count)),
+	}
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+)
+
+// EventType is the type of a run event
+type EventType string
+
+// Run event types
+const (
+	EventRunStarted     EventType = "run-started"
+	EventGroupStarted   EventType = "group-started"
+	EventGroupCompleted EventType = "group-completed"
+	EventCapacityRetry  EventType = "capacity-retry"
+	EventRunFinished    EventType = "run-finished"
+)
+
+// Event is a run event delivered to the notifiers
+type Event struct {
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Stack  string    `json:"stack"`
+	Action string    `json:"action"`
+	RunId  string    `json:"runId"`
+
+	// Group is the result of the completed group, or the started group without a result.
+	Group *run.GroupResult `json:"group,omitempty"`
+
+	// Summary is the summary of the finished run.
+	Summary *run.Summary `json:"summary,omitempty"`
+
+	// Message describes the event, e.g. the capacity error of a retried instance start.
+	Message string `json:"message,omitempty"`
+}
+
+// Notifier delivers run events, ignoring the events it is not interested in
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
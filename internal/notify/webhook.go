@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DefaultWebhookTimeout is the default timeout of a webhook delivery
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookNotifier posts the run events to an HTTP endpoint
+type WebhookNotifier struct {
+	httpClient *http.Client
+	config     types.WebhookNotification
+	template   *template.Template
+}
+
+// NewWebhookNotifier constructs a WebhookNotifier, parsing the payload template, if any.
+func NewWebhookNotifier(httpClient *http.Client, config types.WebhookNotification) (*WebhookNotifier, error) {
+	n := &WebhookNotifier{httpClient: httpClient, config: config}
+	if config.Template != nil {
+		tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Option("missingkey=error").Parse(*config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing webhook template: %w", err)
+		}
+		n.template = tmpl
+	}
+	return n, nil
+}
+
+// Notify delivers the event, if the webhook is subscribed to it, and fails on a non-2xx response.
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	if len(n.config.Events) > 0 && !slices.Contains(n.config.Events, string(event.Type)) {
+		return nil
+	}
+
+	var payload []byte
+	if n.template != nil {
+		var buf bytes.Buffer
+		if err := n.template.Execute(&buf, event); err != nil {
+			return fmt.Errorf("error rendering webhook payload: %w", err)
+		}
+		payload = buf.Bytes()
+	} else {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	timeout := DefaultWebhookTimeout
+	if n.config.Timeout != nil {
+		timeout = *n.config.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := http.MethodPost
+	if n.config.Method != nil {
+		method = *n.config.Method
+	}
+	req, err := http.NewRequestWithContext(ctx, method, os.ExpandEnv(*n.config.URL), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error delivering %v event to webhook %v: %w", event.Type, redactURL(*n.config.URL), unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook %v responded to %v event with %v: %v", redactURL(*n.config.URL), event.Type, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// redactURL keeps only the scheme and host of the webhook URL, as chat webhooks carry their secret in the path
+func redactURL(rawURL string) string {
+	u, err := url.Parse(os.ExpandEnv(rawURL))
+	if err != nil {
+		return "(invalid URL)"
+	}
+	return fmt.Sprintf("%v://%v", u.Scheme, u.Host)
+}
+
+// unwrapURLError drops the URL the HTTP client adds to the errors
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
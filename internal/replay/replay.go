@@ -0,0 +1,237 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator/fake"
+)
+
+// RecordingVersion is the version of the recording format
+const RecordingVersion = "1"
+
+// outputs constructs the outputs of the recorded operations by the service ID and the operation name.
+// Only the describe operations the plans and the dry runs depend on are recorded.
+var outputs = map[string]func() interface{}{
+	"EC2/DescribeInstances":                     func() interface{} { return &ec2.DescribeInstancesOutput{} },
+	"EC2/DescribeInstanceStatus":                func() interface{} { return &ec2.DescribeInstanceStatusOutput{} },
+	"EC2/DescribeAddresses":                     func() interface{} { return &ec2.DescribeAddressesOutput{} },
+	"Auto Scaling/DescribeAutoScalingGroups":    func() interface{} { return &autoscaling.DescribeAutoScalingGroupsOutput{} },
+	"Auto Scaling/DescribeAutoScalingInstances": func() interface{} { return &autoscaling.DescribeAutoScalingInstancesOutput{} },
+	"Auto Scaling/DescribePolicies":             func() interface{} { return &autoscaling.DescribePoliciesOutput{} },
+}
+
+// Response is a recorded response of an AWS operation to its input
+type Response struct {
+	Service   string          `json:"service"`
+	Operation string          `json:"operation"`
+	Input     json.RawMessage `json:"input"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// Recording is the file of the AWS responses recorded during a curator run
+type Recording struct {
+	Version    string     `json:"version"`
+	Stack      string     `json:"stack"`
+	Region     string     `json:"region"`
+	RecordedAt time.Time  `json:"recordedAt"`
+	Responses  []Response `json:"responses"`
+}
+
+// Recorder records the responses of the describe operations of a curator run
+type Recorder struct {
+	mu        sync.Mutex
+	recording Recording
+}
+
+// NewRecorder constructs a Recorder of the run on the stack in the region.
+func NewRecorder(stack, region string) *Recorder {
+	return &Recorder{
+		recording: Recording{
+			Version:    RecordingVersion,
+			Stack:      stack,
+			Region:     region,
+			RecordedAt: time.Now().UTC(),
+			Responses:  make([]Response, 0),
+		},
+	}
+}
+
+// Len returns the number of recorded responses.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.recording.Responses)
+}
+
+// AddMiddleware is an API option recording the responses of the describe operations of an AWS client.
+func (r *Recorder) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ResponseRecorder", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+		if _, ok := outputs[service+"/"+operation]; !ok || err != nil {
+			return out, metadata, err
+		}
+
+		input, inputErr := json.Marshal(in.Parameters)
+		output, outputErr := json.Marshal(out.Result)
+		if inputErr != nil || outputErr != nil {
+			return out, metadata, err
+		}
+
+		r.mu.Lock()
+		r.recording.Responses = append(r.recording.Responses, Response{
+			Service:   service,
+			Operation: operation,
+			Input:     input,
+			Output:    output,
+		})
+		r.mu.Unlock()
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// Save writes the recorded responses to the file at the path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.recording, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing recording %v: %w", path, err)
+	}
+	return nil
+}
+
+// Player replays the recorded responses instead of calling AWS
+type Player struct {
+	mu        sync.Mutex
+	recording Recording
+
+	// responses are the recorded outputs by the operation and its input, in the recorded order
+	responses map[string][]json.RawMessage
+}
+
+// Load reads the recording at the path to be replayed.
+func Load(path string) (*Player, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("invalid recording %v: %w", path, err)
+	}
+	if recording.Version != RecordingVersion {
+		return nil, fmt.Errorf("recording %v is of unsupported version %v", path, recording.Version)
+	}
+
+	p := &Player{recording: recording, responses: make(map[string][]json.RawMessage)}
+	for _, response := range recording.Responses {
+		key := responseKey(response.Service, response.Operation, response.Input)
+		p.responses[key] = append(p.responses[key], response.Output)
+	}
+	return p, nil
+}
+
+// Recording returns the replayed recording.
+func (p *Player) Recording() Recording {
+	return p.recording
+}
+
+func responseKey(service, operation string, input []byte) string {
+	return service + "/" + operation + "/" + string(input)
+}
+
+// AddMiddleware is an API option answering the operations of an AWS client with the recorded responses.
+// The responses of the same operation input are replayed in the recorded order, the last one repeatedly,
+// while the operations without a recorded response fail.
+func (p *Player) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ResponsePlayer", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+		newOutput, ok := outputs[service+"/"+operation]
+		if !ok {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, fmt.Errorf("%v %v is not replayed", service, operation)
+		}
+
+		input, err := json.Marshal(in.Parameters)
+		if err != nil {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, err
+		}
+
+		key := responseKey(service, operation, input)
+		p.mu.Lock()
+		recorded := p.responses[key]
+		if len(recorded) > 1 {
+			p.responses[key] = recorded[1:]
+		}
+		p.mu.Unlock()
+		if len(recorded) == 0 {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, fmt.Errorf("no recorded %v %v response to the input %s", service, operation, input)
+		}
+
+		output := newOutput()
+		if err := json.Unmarshal(recorded[0], output); err != nil {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, fmt.Errorf("invalid recorded %v %v response: %w", service, operation, err)
+		}
+		return middleware.InitializeOutput{Result: output}, middleware.Metadata{}, nil
+	}), middleware.After)
+}
+
+// Cloud returns the in-memory fake seeded with the recorded instances, Elastic IP addresses,
+// Auto Scaling Groups and scaling policies, the latest recorded state of each, so that a replayed run may change them.
+func (p *Player) Cloud() (*fake.Cloud, error) {
+	cloud := fake.New()
+	policies := make(map[string]bool)
+	for _, response := range p.recording.Responses {
+		output := outputs[response.Service+"/"+response.Operation]()
+		if err := json.Unmarshal(response.Output, output); err != nil {
+			return nil, fmt.Errorf("invalid recorded %v %v response: %w", response.Service, response.Operation, err)
+		}
+
+		switch output := output.(type) {
+		case *ec2.DescribeInstancesOutput:
+			for _, r := range output.Reservations {
+				for _, i := range r.Instances {
+					cloud.AddInstance(i)
+				}
+			}
+		case *ec2.DescribeAddressesOutput:
+			for _, a := range output.Addresses {
+				cloud.AddAddress(a)
+			}
+		case *autoscaling.DescribeAutoScalingGroupsOutput:
+			for _, g := range output.AutoScalingGroups {
+				cloud.AddAutoScalingGroup(g)
+			}
+		case *autoscaling.DescribePoliciesOutput:
+			for _, policy := range output.ScalingPolicies {
+				if key := aws.ToString(policy.AutoScalingGroupName) + "/" + aws.ToString(policy.PolicyName); !policies[key] {
+					policies[key] = true
+					cloud.AddScalingPolicy(policy)
+				}
+			}
+		}
+	}
+	return cloud, nil
+}
@@ -0,0 +1,132 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// Report is the report of a finished curator run
+type Report struct {
+	Summary Summary `json:"summary"`
+
+	// Actions are the orchestration events of the run, except the waiter attempts and the timings.
+	Actions []curator.Event `json:"actions"`
+}
+
+// WaiterAttempts returns the number of waiter attempts of all groups.
+func (r Report) WaiterAttempts() int64 {
+	var attempts int64
+	for _, g := range r.Summary.Groups {
+		attempts += g.WaiterAttempts
+	}
+	return attempts
+}
+
+// Report returns the report of the run. It is complete once the run is finished.
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	actions := make([]curator.Event, len(t.actions))
+	copy(actions, t.actions)
+	return Report{Summary: t.summary, Actions: actions}
+}
+
+var markdownTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) time.Duration { return d.Round(time.Second) },
+	"time":     func(t time.Time) string { return t.Format(time.RFC3339) },
+	"join":     strings.Join,
+	"cell":     strings.NewReplacer("|", "\\|", "\n", " ").Replace,
+}).Parse(`# Instance stack {{.Summary.Stack}}: {{.Summary.Action}} {{.Summary.Result}}
+
+| | |
+|---|---|
+| Run ID | {{.Summary.RunId}} |
+{{- if .Summary.User}}
+| User | {{cell .Summary.User}} |
+{{- end}}
+| Started | {{time .Summary.StartedAt}} |
+| Finished | {{time .Summary.FinishedAt}} |
+| Duration | {{duration .Summary.Duration}} |
+| Waiter attempts | {{.WaiterAttempts}} |
+{{- if .Summary.Error}}
+| Error | {{cell .Summary.Error}} |
+{{- end}}
+{{- range $name, $location := .Summary.Links}}
+| {{$name}} | {{$location}} |
+{{- end}}
+
+## Groups
+
+| Group | Instances | Result | Duration | Waiter attempts | Error |
+|---|---|---|---|---|---|
+{{- range .Summary.Groups}}
+| {{.Name}} | {{join .InstanceIds ", "}} | {{.Result}} | {{duration .Duration}} | {{.WaiterAttempts}} | {{cell .Error}} |
+{{- end}}
+{{- if .Summary.Failures}}
+
+## Failures
+
+| Group | Auto Scaling Group | Instances | Error |
+|---|---|---|---|
+{{- range .Summary.Failures}}
+| {{.Group}} | {{.AutoScalingGroupName}} | {{join .InstanceIds ", "}} | {{cell .Error}} |
+{{- end}}
+{{- end}}
+
+## Timing
+
+| Group |{{range .Summary.Phases}} {{.}} |{{end}} Waiters | Waiter attempts | Total |
+|---|{{range .Summary.Phases}}---|{{end}}---|---|---|
+{{- range $g := .Summary.Groups}}
+| {{$g.Name}} |{{range $.Summary.Phases}} {{duration ($g.PhaseDuration .)}} |{{end}} {{duration $g.WaiterDuration}} | {{$g.WaiterAttempts}} | {{duration $g.Duration}} |
+{{- end}}
+
+## Actions
+
+| Time | Event | Group | Instances | Details |
+|---|---|---|---|---|
+{{- range .Actions}}
+| {{time .Time}} | {{.Type}} | {{.Group}} | {{join .InstanceIds ", "}} | {{cell .Message}}{{cell .Error}} |
+{{- end}}
+`))
+
+// WriteMarkdown writes the report to w as Markdown.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	return markdownTemplate.Execute(w, r)
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteFile writes the report to the path, as Markdown for .md paths and as JSON otherwise.
+func (r Report) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		err = r.WriteMarkdown(f)
+	default:
+		err = r.WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing run report %v: %w", path, err)
+	}
+	return f.Close()
+}
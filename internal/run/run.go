@@ -0,0 +1,297 @@
+package run
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// Group results
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+
+	// ResultSuspended is the result of a group whose processing is to be resumed later in the run
+	ResultSuspended = "suspended"
+)
+
+// GroupResult is the result of the processing of an instance group
+type GroupResult struct {
+	Name           string    `json:"name"`
+	InstanceIds    []string  `json:"instanceIds,omitempty"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	Result         string    `json:"result"`
+	Error          string    `json:"error,omitempty"`
+	WaiterAttempts int64     `json:"waiterAttempts"`
+
+	// Durations of the processing phases in the order they first took place.
+	Phases []PhaseTiming `json:"phases,omitempty"`
+
+	// Total duration of the waiters, included in the phase durations.
+	WaiterDuration time.Duration `json:"waiterDuration"`
+}
+
+// PhaseTiming is the total duration of a processing phase of a group
+type PhaseTiming struct {
+	Phase    curator.Phase `json:"phase"`
+	Duration time.Duration `json:"duration"`
+}
+
+// PhaseDuration returns the total duration of the phase of the group.
+func (g GroupResult) PhaseDuration(phase curator.Phase) time.Duration {
+	for _, p := range g.Phases {
+		if p.Phase == phase {
+			return p.Duration
+		}
+	}
+	return 0
+}
+
+// Duration returns the processing duration of the group.
+func (g GroupResult) Duration() time.Duration {
+	return g.FinishedAt.Sub(g.StartedAt)
+}
+
+// Summary is the summary of a curator run
+type Summary struct {
+	Stack      string        `json:"stack"`
+	Action     string        `json:"action"`
+	RunId      string        `json:"runId"`
+	User       string        `json:"user,omitempty"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	Result     string        `json:"result"`
+	Error      string        `json:"error,omitempty"`
+	Groups     []GroupResult `json:"groups"`
+
+	// Individual failures of the run, with the group, the Auto Scaling Group and the instances they relate to.
+	Failures []Failure `json:"failures,omitempty"`
+
+	// Links to the artifacts of the run, e.g. the audit record and the log file.
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// Failure is an individual failure of a curator run
+type Failure struct {
+	Group                string   `json:"group,omitempty"`
+	AutoScalingGroupName string   `json:"autoScalingGroupName,omitempty"`
+	InstanceIds          []string `json:"instanceIds,omitempty"`
+	Error                string   `json:"error"`
+}
+
+// Duration returns the duration of the run.
+func (s Summary) Duration() time.Duration {
+	return s.FinishedAt.Sub(s.StartedAt)
+}
+
+// Phases returns the processing phases of all groups in the order they first took place.
+func (s Summary) Phases() []curator.Phase {
+	phases := make([]curator.Phase, 0)
+	seen := make(map[curator.Phase]bool)
+	for _, g := range s.Groups {
+		for _, p := range g.Phases {
+			if !seen[p.Phase] {
+				seen[p.Phase] = true
+				phases = append(phases, p.Phase)
+			}
+		}
+	}
+	return phases
+}
+
+// Tracker tracks the groups processed by a curator run one after another
+type Tracker struct {
+	mu      sync.Mutex
+	summary Summary
+	current *GroupResult
+	actions []curator.Event
+}
+
+// Summary returns the summary of the run so far.
+func (t *Tracker) Summary() Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.summary
+}
+
+// NewTracker constructs a Tracker of the run of the action on the stack by the user.
+func NewTracker(stack, action, runId, user string) *Tracker {
+	return &Tracker{
+		summary: Summary{
+			Stack:     stack,
+			Action:    action,
+			RunId:     runId,
+			User:      user,
+			StartedAt: time.Now().UTC(),
+			Groups:    make([]GroupResult, 0),
+			Links:     make(map[string]string),
+		},
+	}
+}
+
+// StartGroup starts tracking the group, completing the previous group as succeeded.
+func (t *Tracker) StartGroup(name string) GroupResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.completeGroup(nil)
+	t.current = &GroupResult{
+		Name:      name,
+		StartedAt: time.Now().UTC(),
+	}
+	return *t.current
+}
+
+// CompleteGroup completes the group being processed with the error, if any,
+// and returns its result. It returns nil when no group is being processed.
+func (t *Tracker) CompleteGroup(err error) *GroupResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.completeGroup(err)
+}
+
+func (t *Tracker) completeGroup(err error) *GroupResult {
+	if t.current == nil {
+		return nil
+	}
+
+	t.current.FinishedAt = time.Now().UTC()
+	t.current.Result = ResultSucceeded
+	if err != nil {
+		t.current.Result = ResultFailed
+		t.current.Error = err.Error()
+		t.addFailures(t.current.Name, err)
+	}
+	t.summary.Groups = append(t.summary.Groups, *t.current)
+	t.current = nil
+	return &t.summary.Groups[len(t.summary.Groups)-1]
+}
+
+// SuspendGroup suspends the group being processed, so that it may be resumed later in the run,
+// and returns its result. It returns nil when no group is being processed.
+func (t *Tracker) SuspendGroup() *GroupResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil {
+		return nil
+	}
+	t.current.Result = ResultSuspended
+	t.summary.Groups = append(t.summary.Groups, *t.current)
+	t.current = nil
+	return &t.summary.Groups[len(t.summary.Groups)-1]
+}
+
+// ResumeGroup resumes tracking the suspended group, completing the previous group as succeeded.
+// A group which has not been suspended is started instead.
+func (t *Tracker) ResumeGroup(name string) GroupResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.completeGroup(nil)
+	for i, g := range t.summary.Groups {
+		if g.Name == name && g.Result == ResultSuspended {
+			g.Result = ""
+			t.current = &g
+			t.summary.Groups = append(t.summary.Groups[:i], t.summary.Groups[i+1:]...)
+			return *t.current
+		}
+	}
+
+	t.current = &GroupResult{
+		Name:      name,
+		StartedAt: time.Now().UTC(),
+	}
+	return *t.current
+}
+
+// SetGroupInstances records the resolved instances of the group being processed.
+func (t *Tracker) SetGroupInstances(instanceIds []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current != nil {
+		t.current.InstanceIds = instanceIds
+	}
+}
+
+// RecordEvent records the orchestration event as an action taken by the run,
+// accounting the waiter attempts and the timings of the group being processed instead.
+func (t *Tracker) RecordEvent(e curator.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch e.Type {
+	case curator.EventWaiterAttempt:
+		if t.current != nil {
+			t.current.WaiterAttempts++
+		}
+	case curator.EventWaiterCompleted:
+		if t.current != nil {
+			t.current.WaiterDuration += e.Duration
+		}
+	case curator.EventPhaseCompleted:
+		if t.current != nil {
+			t.current.addPhase(e.Phase, e.Duration)
+		}
+	default:
+		t.actions = append(t.actions, e)
+	}
+}
+
+func (g *GroupResult) addPhase(phase curator.Phase, d time.Duration) {
+	for i := range g.Phases {
+		if g.Phases[i].Phase == phase {
+			g.Phases[i].Duration += d
+			return
+		}
+	}
+	g.Phases = append(g.Phases, PhaseTiming{Phase: phase, Duration: d})
+}
+
+// AddLink records a link to an artifact of the run.
+func (t *Tracker) AddLink(name, location string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.summary.Links[name] = location
+}
+
+// Finish completes the run and the group being processed with the result of the run,
+// and returns the summary.
+func (t *Tracker) Finish(err error) Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.completeGroup(err)
+	t.summary.FinishedAt = time.Now().UTC()
+	t.summary.Result = ResultSucceeded
+	if err != nil {
+		t.summary.Result = ResultFailed
+		t.summary.Error = err.Error()
+		// the failures of a run failing outside of the groups are not recorded by the groups
+		if len(t.summary.Failures) == 0 {
+			t.addFailures("", err)
+		}
+	}
+	return t.summary
+}
+
+// addFailures records the individual failures of the error, of the group unless they name their own
+func (t *Tracker) addFailures(group string, err error) {
+	for _, f := range curator.Failures(err) {
+		if f.Group == "" {
+			f.Group = group
+		}
+		t.summary.Failures = append(t.summary.Failures, Failure{
+			Group:                f.Group,
+			AutoScalingGroupName: f.AutoScalingGroupName,
+			InstanceIds:          f.InstanceIds,
+			Error:                f.Err.Error(),
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package spec loads the stack specs.
+package spec
+
+import (
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// EnvironmentsKey is the key of the per-environment overlays of the stack spec
+const EnvironmentsKey = "environments"
+
+// Overlay parses the stack spec source and layers the overlay of the environment, if any, onto the base
+// stack. It returns the document without the environments section, with the nodes keeping the lines
+// of the source they were parsed from, and the source of the document: the original source unless
+// the environments section was removed.
+func Overlay(source []byte, environment string) (*yamlv3.Node, []byte, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(source, &document); err != nil {
+		return nil, nil, err
+	}
+
+	if document.Kind != yamlv3.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yamlv3.MappingNode {
+		if environment != "" {
+			return nil, nil, fmt.Errorf("environment %v is not defined in the stack spec", environment)
+		}
+		return &document, source, nil
+	}
+
+	root := document.Content[0]
+	var environments *yamlv3.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == EnvironmentsKey {
+			environments = root.Content[i+1]
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+
+	if environments == nil && environment == "" {
+		return &document, source, nil
+	}
+
+	if environment != "" {
+		overlay := mappingValue(environments, environment)
+		if overlay == nil {
+			return nil, nil, fmt.Errorf("environment %v is not defined in the stack spec", environment)
+		}
+		merge(root, overlay)
+	}
+
+	merged, err := yamlv3.Marshal(&document)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &document, merged, nil
+}
+
+// Environments returns the names of the environments defined in the stack spec source.
+func Environments(source []byte) ([]string, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(source, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind != yamlv3.DocumentNode || len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	environments := mappingValue(document.Content[0], EnvironmentsKey)
+	if environments == nil || environments.Kind != yamlv3.MappingNode {
+		return nil, nil
+	}
+	names := make([]string, 0, len(environments.Content)/2)
+	for i := 0; i+1 < len(environments.Content); i += 2 {
+		names = append(names, environments.Content[i].Value)
+	}
+	return names, nil
+}
+
+// mappingValue returns the value of the key of the mapping, or nil
+func mappingValue(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	if mapping == nil || mapping.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// itemKey identifies the items of the sequences merged item by item: the name of a named item,
+// e.g. a group or a verbose filter, or the single key of a shorthand filter
+func itemKey(item *yamlv3.Node) (string, bool) {
+	if item.Kind != yamlv3.MappingNode {
+		return "", false
+	}
+	if name := mappingValue(item, "name"); name != nil && name.Kind == yamlv3.ScalarNode {
+		return name.Value, true
+	}
+	if len(item.Content) == 2 {
+		return item.Content[0].Value, true
+	}
+	return "", false
+}
+
+// merge layers the overlay onto the base node in place. Mappings are merged key by key and the sequences
+// of named items item by item, appending the items missing in the base. Any other value is replaced.
+func merge(base, overlay *yamlv3.Node) {
+	switch {
+	case base.Kind == yamlv3.MappingNode && overlay.Kind == yamlv3.MappingNode:
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			if existing := mappingValue(base, key.Value); existing != nil {
+				merge(existing, value)
+				continue
+			}
+			base.Content = append(base.Content, key, value)
+		}
+
+	case base.Kind == yamlv3.SequenceNode && overlay.Kind == yamlv3.SequenceNode && namedItems(base) && namedItems(overlay):
+		for _, item := range overlay.Content {
+			key, _ := itemKey(item)
+			var existing *yamlv3.Node
+			for _, b := range base.Content {
+				if k, _ := itemKey(b); k == key {
+					existing = b
+					break
+				}
+			}
+			if existing == nil {
+				base.Content = append(base.Content, item)
+				continue
+			}
+			merge(existing, item)
+		}
+
+	default:
+		*base = *overlay
+	}
+}
+
+// namedItems reports whether all the sequence items are identified by itemKey
+func namedItems(sequence *yamlv3.Node) bool {
+	for _, item := range sequence.Content {
+		if _, ok := itemKey(item); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package spec_test
+
+import (
+	"reflect"
+	"testing"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/spec"
+)
+
+const overlaySource = `
+name: web
+region: us-east-1
+tags:
+  team: web
+  tier: frontend
+notify:
+  - slack
+  - email
+groups:
+  - name: app
+    rolling: true
+  - name: db
+environments:
+  prod:
+    region: eu-west-1
+    tags:
+      tier: edge
+      owner: ops
+    notify:
+      - pagerduty
+    groups:
+      - name: db
+        rolling: true
+      - name: cache
+`
+
+func TestOverlay(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		environment string
+		expected    string
+	}{
+		{
+			name:     "no environment",
+			source:   overlaySource,
+			expected: "{name: web, region: us-east-1, tags: {team: web, tier: frontend}, notify: [slack, email], groups: [{name: app, rolling: true}, {name: db}]}",
+		},
+		{
+			name:        "environment overlay",
+			source:      overlaySource,
+			environment: "prod",
+			// the scalars and the lists are replaced, the maps and the named items are merged
+			expected: "{name: web, region: eu-west-1, tags: {team: web, tier: edge, owner: ops}, notify: [pagerduty], groups: [{name: app, rolling: true}, {name: db, rolling: true}, {name: cache}]}",
+		},
+		{
+			name:     "no environments section",
+			source:   "name: web\nregion: us-east-1\n",
+			expected: "{name: web, region: us-east-1}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, merged, err := spec.Overlay([]byte(tt.source), tt.environment)
+			if err != nil {
+				t.Fatalf("overlay has failed: %v", err)
+			}
+
+			var actual, expected map[string]interface{}
+			if err := yamlv3.Unmarshal(merged, &actual); err != nil {
+				t.Fatalf("overlaid source is invalid: %v", err)
+			}
+			if err := yamlv3.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("expected source is invalid: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("overlaid spec is %v, expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestOverlayUnknownEnvironment(t *testing.T) {
+	for _, source := range []string{overlaySource, "name: web\n"} {
+		if _, _, err := spec.Overlay([]byte(source), "staging"); err == nil {
+			t.Errorf("overlay of an unknown environment has succeeded on %q", source)
+		}
+	}
+}
+
+func TestEnvironments(t *testing.T) {
+	environments, err := spec.Environments([]byte(overlaySource))
+	if err != nil {
+		t.Fatalf("environments lookup has failed: %v", err)
+	}
+	if !reflect.DeepEqual(environments, []string{"prod"}) {
+		t.Errorf("environments are %v, expected [prod]", environments)
+	}
+}
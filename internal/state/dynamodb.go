@@ -0,0 +1,121 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBKeyAttribute is the partition key attribute of the DynamoDB store table, of the string type
+const DynamoDBKeyAttribute = "key"
+
+// DynamoDBValueAttribute is the attribute holding the value in the items of the DynamoDB store table
+const DynamoDBValueAttribute = "value"
+
+// DynamoDBAPI is the subset of the DynamoDB client operations used by the DynamoDB store.
+type DynamoDBAPI interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBStore stores the values as items of a DynamoDB table with the DynamoDBKeyAttribute partition key.
+// Create writes the item conditionally, so that exactly one of the writers racing each other succeeds.
+type DynamoDBStore struct {
+	client DynamoDBAPI
+	table  string
+}
+
+// NewDynamoDBStore returns the store of the table
+func NewDynamoDBStore(client DynamoDBAPI, table string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, table: table}
+}
+
+func (s *DynamoDBStore) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            itemKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := output.Item[DynamoDBValueAttribute].(*dynamodbTypes.AttributeValueMemberB)
+	if !ok {
+		return nil, nil
+	}
+	return value.Value, nil
+}
+
+func (s *DynamoDBStore) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item(key, value),
+	})
+	return err
+}
+
+func (s *DynamoDBStore) Create(ctx context.Context, key string, value []byte) (bool, error) {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(s.table),
+		Item:                     item(key, value),
+		ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+		ExpressionAttributeNames: map[string]string{"#key": DynamoDBKeyAttribute},
+	})
+	var conditionErr *dynamodbTypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       itemKey(key),
+	})
+	return err
+}
+
+func (s *DynamoDBStore) DeleteIf(ctx context.Context, key string, value []byte) (bool, error) {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       itemKey(key),
+		ConditionExpression:       aws.String("#value = :value"),
+		ExpressionAttributeNames:  map[string]string{"#value": DynamoDBValueAttribute},
+		ExpressionAttributeValues: map[string]dynamodbTypes.AttributeValue{":value": &dynamodbTypes.AttributeValueMemberB{Value: value}},
+	})
+	var conditionErr *dynamodbTypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) Location(key string) string {
+	return fmt.Sprintf("dynamodb://%v/%v", s.table, key)
+}
+
+func itemKey(key string) map[string]dynamodbTypes.AttributeValue {
+	return map[string]dynamodbTypes.AttributeValue{
+		DynamoDBKeyAttribute: &dynamodbTypes.AttributeValueMemberS{Value: key},
+	}
+}
+
+func item(key string, value []byte) map[string]dynamodbTypes.AttributeValue {
+	return map[string]dynamodbTypes.AttributeValue{
+		DynamoDBKeyAttribute:   &dynamodbTypes.AttributeValueMemberS{Value: key},
+		DynamoDBValueAttribute: &dynamodbTypes.AttributeValueMemberB{Value: value},
+	}
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// S3API is the subset of the S3 client operations used by the S3 store.
+type S3API interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Store stores the values as objects of an S3 bucket under a key prefix. Create writes the object conditionally,
+// so that exactly one of the writers racing each other succeeds, as long as the bucket supports the conditional
+// writes, which the S3-compatible stores may not.
+type S3Store struct {
+	client S3API
+	bucket string
+	prefix string
+}
+
+// NewS3Store returns the store of the bucket and the key prefix
+func NewS3Store(client S3API, bucket, prefix string) *S3Store {
+	return &S3Store{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	var noSuchKey *s3Types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key(key)),
+		Body:        bytes.NewReader(value),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// Create writes the object through an If-None-Match: * header, which S3 rejects with 412 Precondition Failed
+// if the object exists, or with 409 Conflict while a concurrent conditional write is in progress.
+func (s *S3Store) Create(ctx context.Context, key string, value []byte) (bool, error) {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key(key)),
+		Body:        bytes.NewReader(value),
+		ContentType: aws.String("application/json"),
+	}, s3.WithAPIOptions(smithyhttp.AddHeaderValue("If-None-Match", "*")))
+	if preconditionFailed(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	return err
+}
+
+// DeleteIf compares the value of the object, then removes the object only if its ETag is still the one read,
+// through an If-Match header, which S3 rejects with 412 Precondition Failed once the object has been replaced.
+func (s *S3Store) DeleteIf(ctx context.Context, key string, value []byte) (bool, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	var noSuchKey *s3Types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(data, value) {
+		return false, nil
+	}
+
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	}, s3.WithAPIOptions(smithyhttp.AddHeaderValue("If-Match", aws.ToString(output.ETag))))
+	if preconditionFailed(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3Store) Location(key string) string {
+	return fmt.Sprintf("s3://%v/%v", s.bucket, s.key(key))
+}
+
+func (s *S3Store) key(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+// preconditionFailed reports whether S3 has rejected a conditional request, as the object has been changed
+// or is being changed by a concurrent request
+func preconditionFailed(err error) bool {
+	var responseErr *smithyhttp.ResponseError
+	if !errors.As(err, &responseErr) {
+		return false
+	}
+	return responseErr.HTTPStatusCode() == http.StatusPreconditionFailed || responseErr.HTTPStatusCode() == http.StatusConflict
+}
@@ -0,0 +1,141 @@
+// Package state persists the curator state between the runs of a stack: the run states resumed by later runs,
+// the Auto Scaling Group sizes recorded by the shutdown and the advisory locks of the stacks.
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Store stores the state values by key
+type Store interface {
+	// Get returns the value of the key, or nil if the key is absent.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put writes the value of the key.
+	Put(ctx context.Context, key string, value []byte) error
+
+	// Create writes the value of the key unless the key exists, reporting whether the value has been written.
+	Create(ctx context.Context, key string, value []byte) (bool, error)
+
+	// Delete removes the key, if present.
+	Delete(ctx context.Context, key string) error
+
+	// DeleteIf removes the key only if it still holds the value, reporting whether the key has been removed.
+	DeleteIf(ctx context.Context, key string, value []byte) (bool, error)
+
+	// Location describes where the value of the key is stored, for the messages.
+	Location(key string) string
+}
+
+// FileStore stores the values as files of a local directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns the store of the directory, created on the first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.Location(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *FileStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Location(key), value, 0644)
+}
+
+// Create writes the value to a temporary file, linked to the key only once written, so that a concurrent
+// reader never sees the value partially written.
+func (s *FileStore) Create(ctx context.Context, key string, value []byte) (bool, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return false, err
+	}
+
+	f, err := os.CreateTemp(s.dir, "."+key+".*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return false, err
+	}
+
+	// the link fails rather than replaces an existing key
+	err = os.Link(f.Name(), s.Location(key))
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.Location(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeleteIf moves the file of the key aside before comparing its value, so that a value written meanwhile
+// is never removed. A value which does not match is moved back, unless the key has been created again.
+func (s *FileStore) DeleteIf(ctx context.Context, key string, value []byte) (bool, error) {
+	f, err := os.CreateTemp(s.dir, "."+key+".*")
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// the rename atomically replaces the placeholder with the file of the key
+	err = os.Rename(s.Location(key), f.Name())
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(data, value) {
+		return true, nil
+	}
+
+	// the link fails rather than replaces a key created meanwhile
+	if err := os.Link(f.Name(), s.Location(key)); err != nil && !errors.Is(err, fs.ErrExist) {
+		return false, err
+	}
+	return false, nil
+}
+
+func (s *FileStore) Location(key string) string {
+	return filepath.Join(s.dir, key)
+}
@@ -0,0 +1,105 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// partitions are the known AWS partitions
+var partitions = map[string]bool{
+	"aws":        true,
+	"aws-cn":     true,
+	"aws-us-gov": true,
+	"aws-iso":    true,
+	"aws-iso-b":  true,
+	"aws-iso-e":  true,
+	"aws-iso-f":  true,
+}
+
+// regionPartitionPrefixes maps the Region name prefixes to the partitions other than aws
+var regionPartitionPrefixes = []struct {
+	prefix    string
+	partition string
+}{
+	{"cn-", "aws-cn"},
+	{"us-gov-", "aws-us-gov"},
+	{"us-isob-", "aws-iso-b"},
+	{"us-iso-", "aws-iso"},
+	{"eu-isoe-", "aws-iso-e"},
+	{"us-isof-", "aws-iso-f"},
+}
+
+var accountIdPattern = regexp.MustCompile(`^\d{12}$`)
+
+// regionPartition returns the partition of the Region
+func regionPartition(region string) string {
+	for _, p := range regionPartitionPrefixes {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return "aws"
+}
+
+// isARN validates an ARN of a known partition with an account ID. The optional parameter restricts
+// the service and the resource type, e.g. `arn=iam:role`.
+func isARN(fl validator.FieldLevel) bool {
+	parsed, err := arn.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	if !partitions[parsed.Partition] || !accountIdPattern.MatchString(parsed.AccountID) || parsed.Resource == "" {
+		return false
+	}
+
+	if param := fl.Param(); param != "" {
+		service, resourceType, _ := strings.Cut(param, ":")
+		if parsed.Service != service {
+			return false
+		}
+		if resourceType != "" && !strings.HasPrefix(parsed.Resource, resourceType+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRolePartition verifies that the role ARNs of the stack, of its web identity and role chain belong
+// to the partition of the stack Region
+func validateRolePartition(sl validator.StructLevel, stack types.Stack) {
+	if stack.Region == nil {
+		return
+	}
+	partition := regionPartition(*stack.Region)
+
+	if stack.WebIdentity != nil {
+		validateARNPartition(sl, stack.WebIdentity.RoleARN, "web-identity.role-arn", partition)
+	}
+	for i, role := range stack.RoleChain {
+		validateARNPartition(sl, role.RoleARN, fmt.Sprintf("role-chain[%v].role-arn", i), partition)
+	}
+	validateARNPartition(sl, stack.RoleARN, "role-arn", partition)
+}
+
+// validateARNPartition reports the valid ARN of a partition other than the expected one
+func validateARNPartition(sl validator.StructLevel, value *string, fieldName string, partition string) {
+	if value == nil {
+		return
+	}
+
+	parsed, err := arn.Parse(*value)
+	if err != nil || !partitions[parsed.Partition] {
+		return
+	}
+
+	if parsed.Partition != partition {
+		sl.ReportError(value, fieldName, "RoleARN", "partition", partition)
+	}
+}
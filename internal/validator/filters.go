@@ -0,0 +1,167 @@
+package validator
+
+import (
+	"strings"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// tagFilterPrefix is the prefix of the filters by the value of a tag key
+const tagFilterPrefix = "tag:"
+
+// instanceFilterNames are the filter names supported by DescribeInstances
+var instanceFilterNames = map[string]bool{
+	"affinity":                         true,
+	"architecture":                     true,
+	"availability-zone":                true,
+	"block-device-mapping.attach-time": true,
+	"block-device-mapping.delete-on-termination": true,
+	"block-device-mapping.device-name":           true,
+	"block-device-mapping.status":                true,
+	"block-device-mapping.volume-id":             true,
+	"boot-mode":                                  true,
+	"capacity-reservation-id":                    true,
+	"capacity-reservation-specification.capacity-reservation-preference":                                     true,
+	"capacity-reservation-specification.capacity-reservation-target.capacity-reservation-id":                 true,
+	"capacity-reservation-specification.capacity-reservation-target.capacity-reservation-resource-group-arn": true,
+	"client-token":                        true,
+	"current-instance-boot-mode":          true,
+	"dns-name":                            true,
+	"ebs-optimized":                       true,
+	"ena-support":                         true,
+	"enclave-options.enabled":             true,
+	"hibernation-options.configured":      true,
+	"host-id":                             true,
+	"hypervisor":                          true,
+	"iam-instance-profile.arn":            true,
+	"iam-instance-profile.id":             true,
+	"image-id":                            true,
+	"instance-id":                         true,
+	"instance-lifecycle":                  true,
+	"instance-state-code":                 true,
+	"instance-state-name":                 true,
+	"instance-type":                       true,
+	"instance.group-id":                   true,
+	"instance.group-name":                 true,
+	"ip-address":                          true,
+	"ipv6-address":                        true,
+	"kernel-id":                           true,
+	"key-name":                            true,
+	"launch-index":                        true,
+	"launch-time":                         true,
+	"maintenance-options.auto-recovery":   true,
+	"metadata-options.http-endpoint":      true,
+	"metadata-options.http-protocol-ipv4": true,
+	"metadata-options.http-protocol-ipv6": true,
+	"metadata-options.http-put-response-hop-limit":                  true,
+	"metadata-options.http-tokens":                                  true,
+	"metadata-options.instance-metadata-tags":                       true,
+	"monitoring-state":                                              true,
+	"network-interface.addresses.association.allocation-id":         true,
+	"network-interface.addresses.association.association-id":        true,
+	"network-interface.addresses.association.carrier-ip":            true,
+	"network-interface.addresses.association.customer-owned-ip":     true,
+	"network-interface.addresses.association.ip-owner-id":           true,
+	"network-interface.addresses.association.public-dns-name":       true,
+	"network-interface.addresses.association.public-ip":             true,
+	"network-interface.addresses.primary":                           true,
+	"network-interface.addresses.private-dns-name":                  true,
+	"network-interface.addresses.private-ip-address":                true,
+	"network-interface.association.allocation-id":                   true,
+	"network-interface.association.association-id":                  true,
+	"network-interface.association.carrier-ip":                      true,
+	"network-interface.association.customer-owned-ip":               true,
+	"network-interface.association.ip-owner-id":                     true,
+	"network-interface.association.public-dns-name":                 true,
+	"network-interface.association.public-ip":                       true,
+	"network-interface.attachment.attach-time":                      true,
+	"network-interface.attachment.attachment-id":                    true,
+	"network-interface.attachment.delete-on-termination":            true,
+	"network-interface.attachment.device-index":                     true,
+	"network-interface.attachment.instance-id":                      true,
+	"network-interface.attachment.instance-owner-id":                true,
+	"network-interface.attachment.network-card-index":               true,
+	"network-interface.attachment.status":                           true,
+	"network-interface.availability-zone":                           true,
+	"network-interface.deny-all-igw-traffic":                        true,
+	"network-interface.description":                                 true,
+	"network-interface.group-id":                                    true,
+	"network-interface.group-name":                                  true,
+	"network-interface.ipv4-prefixes.ipv4-prefix":                   true,
+	"network-interface.ipv6-address":                                true,
+	"network-interface.ipv6-addresses.ipv6-address":                 true,
+	"network-interface.ipv6-addresses.is-primary-ipv6":              true,
+	"network-interface.ipv6-native":                                 true,
+	"network-interface.ipv6-prefixes.ipv6-prefix":                   true,
+	"network-interface.mac-address":                                 true,
+	"network-interface.network-interface-id":                        true,
+	"network-interface.outpost-arn":                                 true,
+	"network-interface.owner-id":                                    true,
+	"network-interface.private-dns-name":                            true,
+	"network-interface.private-ip-address":                          true,
+	"network-interface.public-dns-name":                             true,
+	"network-interface.requester-id":                                true,
+	"network-interface.requester-managed":                           true,
+	"network-interface.status":                                      true,
+	"network-interface.source-dest-check":                           true,
+	"network-interface.subnet-id":                                   true,
+	"network-interface.tag-key":                                     true,
+	"network-interface.tag-value":                                   true,
+	"network-interface.vpc-id":                                      true,
+	"outpost-arn":                                                   true,
+	"owner-id":                                                      true,
+	"placement-group-name":                                          true,
+	"placement-partition-number":                                    true,
+	"platform":                                                      true,
+	"platform-details":                                              true,
+	"private-dns-name":                                              true,
+	"private-dns-name-options.enable-resource-name-dns-a-record":    true,
+	"private-dns-name-options.enable-resource-name-dns-aaaa-record": true,
+	"private-dns-name-options.hostname-type":                        true,
+	"private-ip-address":                                            true,
+	"product-code":                                                  true,
+	"product-code.type":                                             true,
+	"ramdisk-id":                                                    true,
+	"reason":                                                        true,
+	"requester-id":                                                  true,
+	"reservation-id":                                                true,
+	"root-device-name":                                              true,
+	"root-device-type":                                              true,
+	"source-dest-check":                                             true,
+	"spot-instance-request-id":                                      true,
+	"state-reason-code":                                             true,
+	"state-reason-message":                                          true,
+	"subnet-id":                                                     true,
+	"tag-key":                                                       true,
+	"tag-value":                                                     true,
+	"tenancy":                                                       true,
+	"tpm-support":                                                   true,
+	"usage-operation":                                               true,
+	"usage-operation-update-time":                                   true,
+	"virtualization-type":                                           true,
+	"vpc-id":                                                        true,
+}
+
+// isInstanceFilterName reports whether the name is a DescribeInstances filter, or a tag filter with a key
+func isInstanceFilterName(name string) bool {
+	if key, ok := strings.CutPrefix(name, tagFilterPrefix); ok {
+		return key != ""
+	}
+	return instanceFilterNames[name]
+}
+
+// validateFilterName reports unknown filter names, unless allowed by the stack
+func validateFilterName(sl validator.StructLevel, filter ec2Types.Filter) {
+	if filter.Name == nil || len(*filter.Name) == 0 || isInstanceFilterName(*filter.Name) {
+		return
+	}
+
+	if stack, ok := sl.Top().Interface().(*types.Stack); ok && stack.AllowUnknownFilters {
+		return
+	}
+
+	sl.ReportError(filter.Name, "name", "Name", "filter", *filter.Name)
+}
@@ -0,0 +1,230 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Warning is a lint finding of a valid stack spec field with its position in the spec source
+type Warning struct {
+	// Path of the field in the spec, e.g. groups[2].filters
+	Path string
+
+	// Description of the finding
+	Message string
+
+	// The spec source file
+	File string
+
+	// Line of the field, or of its closest parent present in the spec. Zero when unknown
+	Line int
+}
+
+func (w Warning) String() string {
+	if w.Line == 0 {
+		return fmt.Sprintf("%v: %v", w.Path, w.Message)
+	}
+	return fmt.Sprintf("%v: %v (%v:%v)", w.Path, w.Message, w.File, w.Line)
+}
+
+// NewWarning returns the warning about the field path positioned in the spec document
+func NewWarning(document *yamlv3.Node, file, path, message string) Warning {
+	return Warning{Path: path, Message: message, File: file, Line: nodeLine(document, path)}
+}
+
+// NewGroupWarning returns the warning about the field of the named group, e.g. filters,
+// or about the group itself if the field is empty, positioned in the spec document
+func NewGroupWarning(document *yamlv3.Node, file, group, field, message string) Warning {
+	path := fmt.Sprintf("groups[%v]", groupIndex(document, group))
+	if field != "" {
+		path += "." + field
+	}
+	return NewWarning(document, file, path, message)
+}
+
+// Lint returns the warnings about the stack which is valid, yet may not act as intended: groups
+// with identical filters, groups whose filters can never match and fields set with the deprecated tag.
+// The stack is expected to be validated by ValidateStack.
+func Lint(stack *types.Stack, file string, document *yamlv3.Node) []Warning {
+	warnings := make([]Warning, 0)
+	warnings = append(warnings, lintIdenticalFilters(stack, file, document)...)
+	warnings = append(warnings, lintConflictingFilters(stack, file, document)...)
+	warnings = append(warnings, lintDeprecatedFields(reflect.ValueOf(*stack), "", file, document)...)
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Line < warnings[j].Line
+	})
+	return warnings
+}
+
+// lintIdenticalFilters warns about the groups matching the same instances with identical filters
+func lintIdenticalFilters(stack *types.Stack, file string, document *yamlv3.Node) []Warning {
+	var warnings []Warning
+	firstGroups := make(map[string]string, len(stack.Groups))
+	for _, group := range stack.Groups {
+		// groups without filters consist of clusters only
+		if len(group.Filters) == 0 {
+			continue
+		}
+
+		key := filtersKey(group.Filters) + "|" + strings.Join(curator.GroupInstanceStates(&group), ",")
+		if first, ok := firstGroups[key]; ok {
+			warnings = append(warnings, NewGroupWarning(document, file, *group.Name, "filters",
+				fmt.Sprintf("filters are identical to the filters of group %v", first)))
+			continue
+		}
+		firstGroups[key] = *group.Name
+	}
+	return warnings
+}
+
+// filtersKey returns the key of the filters equal for the filters matching the same instances
+func filtersKey(filters types.Filters) string {
+	keys := make([]string, 0, len(filters))
+	for _, f := range filters {
+		values := slices.Clone(f.Values)
+		sort.Strings(values)
+		keys = append(keys, aws.ToString(f.Name)+"="+strings.Join(values, ","))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ";")
+}
+
+// lintConflictingFilters warns about the groups which can never match any instance, as the stack
+// and the group filters or the instance states of the group by the same name have no common values
+func lintConflictingFilters(stack *types.Stack, file string, document *yamlv3.Node) []Warning {
+	var warnings []Warning
+	for _, group := range stack.Groups {
+		if len(group.Filters) == 0 {
+			continue
+		}
+
+		filters := make([]ec2Types.Filter, 0, len(stack.Filters)+len(group.Filters)+1)
+		filters = append(filters, stack.Filters...)
+		filters = append(filters, group.Filters...)
+		filters = append(filters, ec2Types.Filter{
+			Name:   aws.String("instance-state-name"),
+			Values: curator.GroupInstanceStates(&group),
+		})
+
+		values := make(map[string][]string, len(filters))
+		names := make([]string, 0, len(filters))
+		for _, f := range filters {
+			name := aws.ToString(f.Name)
+			previous, ok := values[name]
+			if !ok {
+				values[name] = f.Values
+				names = append(names, name)
+				continue
+			}
+			// the wildcard values may match values of the other filter
+			if hasWildcard(previous) || hasWildcard(f.Values) {
+				continue
+			}
+			values[name] = intersect(previous, f.Values)
+		}
+
+		for _, name := range names {
+			if len(values[name]) == 0 {
+				warnings = append(warnings, NewGroupWarning(document, file, *group.Name, "filters",
+					fmt.Sprintf("group can never match any instance, the %v filters have no common values", name)))
+			}
+		}
+	}
+	return warnings
+}
+
+// hasWildcard reports whether any of the filter values is a wildcard pattern
+func hasWildcard(values []string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.ContainsAny(v, "*?")
+	})
+}
+
+// intersect returns the values present in both a and b
+func intersect(a, b []string) []string {
+	values := make([]string, 0, len(a))
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// lintDeprecatedFields warns about the fields set in the spec which are tagged deprecated,
+// the tag value naming the replacement, e.g. `deprecated:"use role-chain instead"`
+func lintDeprecatedFields(v reflect.Value, path, file string, document *yamlv3.Node) []Warning {
+	var warnings []Warning
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			warnings = append(warnings, lintDeprecatedFields(v.Elem(), path, file, document)...)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			warnings = append(warnings, lintDeprecatedFields(v.Index(i), fmt.Sprintf("%v[%v]", path, i), file, document)...)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if hint, ok := field.Tag.Lookup("deprecated"); ok && !v.Field(i).IsZero() {
+				warnings = append(warnings, NewWarning(document, file, fieldPath, "field is deprecated, "+hint))
+			}
+			warnings = append(warnings, lintDeprecatedFields(v.Field(i), fieldPath, file, document)...)
+		}
+	}
+	return warnings
+}
+
+// groupIndex returns the index of the named group in the groups of the spec document, or -1 if absent
+func groupIndex(root *yamlv3.Node, name string) int {
+	node := root
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	groups := mappingNode(node, "groups")
+	if groups == nil || groups.Kind != yamlv3.SequenceNode {
+		return -1
+	}
+	for i, group := range groups.Content {
+		if n := mappingNode(group, "name"); n != nil && n.Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingNode returns the value of the key of the mapping node, or nil if absent
+func mappingNode(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
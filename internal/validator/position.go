@@ -0,0 +1,133 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// FieldError is a validation error of a stack spec field with its position in the spec source
+type FieldError struct {
+	// Path of the field in the spec, e.g. groups[2].filters[0].values
+	Path string
+
+	// The failed validation, e.g. required or oneof=create verify
+	Validation string
+
+	// The spec source file
+	File string
+
+	// Line of the field, or of its closest parent present in the spec. Zero when unknown
+	Line int
+}
+
+func (e FieldError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%v: %v", e.Path, e.Validation)
+	}
+	return fmt.Sprintf("%v: %v (%v:%v)", e.Path, e.Validation, e.File, e.Line)
+}
+
+// FieldErrors are all the validation errors of a stack spec
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// yamlFieldName names the struct fields as decoded by yaml.v2: the yaml tag name, or the lowercased field name
+func yamlFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "-"
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// pathSegments splits a field path, e.g. groups[2].filters[0], into keys and indexes
+func pathSegments(path string) []string {
+	segments := make([]string, 0)
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		segments = append(segments, key)
+		for rest != "" {
+			var index string
+			index, rest, _ = strings.Cut(rest, "]")
+			segments = append(segments, index)
+			rest = strings.TrimPrefix(rest, "[")
+		}
+	}
+	return segments
+}
+
+// nodeLine returns the line of the field path in the YAML document, or of its closest parent present
+func nodeLine(root *yamlv3.Node, path string) int {
+	node := root
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	line := node.Line
+	for _, segment := range pathSegments(path) {
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					next = node.Content[i+1]
+					line = node.Content[i].Line
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
+
+// withPositions converts the validation errors into the field errors positioned in the spec document
+func withPositions(err error, file string, document *yamlv3.Node) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fieldErrors := make(FieldErrors, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		// the namespace starts with the name of the validated struct
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+
+		validation := fe.Tag()
+		if fe.Param() != "" {
+			validation += "=" + fe.Param()
+		}
+
+		fieldErrors = append(fieldErrors, FieldError{
+			Path:       path,
+			Validation: validation,
+			File:       file,
+			Line:       nodeLine(document, path),
+		})
+	}
+	return fieldErrors
+}
@@ -5,8 +5,10 @@ import (
 
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/go-playground/validator/v10"
+	yamlv3 "gopkg.in/yaml.v3"
 
-	"github.com/ikorchynskyi/instance-stack-curator/internal/types"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
 )
 
 var validate *validator.Validate
@@ -15,22 +17,62 @@ func FilterStructLevelValidation(sl validator.StructLevel) {
 	filter := sl.Current().Interface().(ec2Types.Filter)
 
 	if filter.Name == nil || len(*filter.Name) == 0 {
-		sl.ReportError(filter.Name, "Name", "", "required", "")
+		sl.ReportError(filter.Name, "name", "Name", "required", "")
 	}
+	validateFilterName(sl, filter)
 
 	if len(filter.Values) == 0 {
-		sl.ReportError(filter.Values, "Values", "", "required", "")
+		sl.ReportError(filter.Values, "values", "Values", "required", "")
 	}
 
 	for i, value := range filter.Values {
 		if len(value) == 0 {
-			sl.ReportError(value, fmt.Sprintf("Values[%v]", i), "", "required", "")
+			sl.ReportError(value, fmt.Sprintf("values[%v]", i), fmt.Sprintf("Values[%v]", i), "required", "")
+		}
+	}
+}
+
+func StackStructLevelValidation(sl validator.StructLevel) {
+	stack := sl.Current().Interface().(types.Stack)
+
+	validateRolePartition(sl, stack)
+
+	names := make(map[string]bool, len(stack.Groups))
+	for i, group := range stack.Groups {
+		if group.Name == nil {
+			continue
+		}
+		if names[*group.Name] {
+			sl.ReportError(group.Name, fmt.Sprintf("groups[%v].name", i), "Name", "unique", "")
+		}
+		names[*group.Name] = true
+
+		// the forced stop is awaited within the rest of the maximum wait time
+		if group.ForceStopAfter != nil {
+			maxWait := curator.DefaultWaitDuration
+			if group.Waiters != nil && group.Waiters.MaxWait != nil {
+				maxWait = *group.Waiters.MaxWait
+			}
+			if *group.ForceStopAfter >= maxWait {
+				sl.ReportError(group.ForceStopAfter, fmt.Sprintf("groups[%v].force-stop-after", i), "ForceStopAfter", "ltfield", maxWait.String())
+			}
 		}
 	}
 }
 
 func ValidateStack(stack *types.Stack) error {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(yamlFieldName)
 	validate.RegisterStructValidation(FilterStructLevelValidation, ec2Types.Filter{})
+	validate.RegisterStructValidation(StackStructLevelValidation, types.Stack{})
+	if err := validate.RegisterValidation("arn", isARN); err != nil {
+		return err
+	}
 	return validate.Struct(stack)
 }
+
+// ValidateStackDocument validates the stack decoded from the document of the source file, reporting
+// the failed fields by their spec paths and source lines
+func ValidateStackDocument(stack *types.Stack, file string, document *yamlv3.Node) error {
+	return withPositions(ValidateStack(stack), file, document)
+}
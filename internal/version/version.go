@@ -0,0 +1,64 @@
+// Package version holds the build metadata of the curator, injected with -ldflags, e.g.:
+//
+//	go build -ldflags="-X github.com/ikorchynskyi/instance-stack-curator/internal/version.Version=v1.2.3"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Build metadata injected with -ldflags
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info is the build metadata of the curator
+type Info struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	Date       string `json:"date,omitempty"`
+	GoVersion  string `json:"goVersion"`
+	SDKVersion string `json:"sdkVersion"`
+}
+
+// Get returns the build metadata. The commit and the build date not injected are taken
+// from the version control information embedded by the Go toolchain, if any.
+func Get() Info {
+	info := Info{
+		Version:    Version,
+		Commit:     Commit,
+		Date:       Date,
+		GoVersion:  runtime.Version(),
+		SDKVersion: aws.SDKVersion,
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range buildInfo.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// String formats the build metadata on a single line
+func (i Info) String() string {
+	return fmt.Sprintf("%v (commit %v, built %v, %v, AWS SDK %v)", i.Version, valueOrUnknown(i.Commit), valueOrUnknown(i.Date), i.GoVersion, i.SDKVersion)
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
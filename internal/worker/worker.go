@@ -0,0 +1,292 @@
+// Package worker executes the curation requests received from an SQS queue.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+
+	"github.com/ikorchynskyi/instance-stack-curator/internal/run"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// receiveRetryDelay is the delay before receiving again after a failed receive
+const receiveRetryDelay = 5 * time.Second
+
+// SQSAPI is the subset of the SQS client operations used by the Worker.
+type SQSAPI interface {
+	ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	ChangeMessageVisibility(context.Context, *sqs.ChangeMessageVisibilityInput, ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessage(context.Context, *sqs.DeleteMessageInput, ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	SendMessage(context.Context, *sqs.SendMessageInput, ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// SNSAPI is the subset of the SNS client operations used by the Worker.
+type SNSAPI interface {
+	Publish(context.Context, *sns.PublishInput, ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Request is a curation request received as a JSON message
+type Request struct {
+	// Reference of the stack spec, relative to the stack directory of the worker. Required
+	Stack string `json:"stack"`
+
+	// Action executed on the stack: shutdown, startup, restart or reboot. Required
+	Action types.Action `json:"action"`
+
+	// Name of the environment whose overlay is layered onto the stack spec.
+	Env string `json:"env,omitempty"`
+
+	// Resolve the instances without changing them.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// StackPath returns the cleaned reference of the stack spec rooted at the stack directory,
+// so that it may not escape the directory and names a stack the same way however it is spelled.
+func (r Request) StackPath() string {
+	return filepath.Clean(string(filepath.Separator) + r.Stack)
+}
+
+// Validate checks that the request names a stack and a supported action.
+func (r Request) Validate() error {
+	if r.Stack == "" {
+		return errors.New("stack is required")
+	}
+	switch r.Action {
+	case types.ActionShutdown, types.ActionStartup, types.ActionRestart, types.ActionReboot:
+		return nil
+	}
+	return fmt.Errorf("unsupported action %q", r.Action)
+}
+
+// Response is the result of a curation request sent to the response queue and topic
+type Response struct {
+	MessageId string       `json:"messageId"`
+	RunId     string       `json:"runId"`
+	Request   Request      `json:"request"`
+	Result    string       `json:"result"`
+	Error     string       `json:"error,omitempty"`
+	Summary   *run.Summary `json:"summary,omitempty"`
+}
+
+// Executor executes the request as the run of the ID, returning the summary of the run, if any.
+type Executor func(ctx context.Context, request Request, runId string) (*run.Summary, error)
+
+// Config is the configuration of a Worker
+type Config struct {
+	// URL of the queue the requests are received from. Required
+	QueueURL string
+
+	// URL of the queue the responses are sent to, if any.
+	ResponseQueueURL string
+
+	// ARN of the topic the responses are published to, if any.
+	ResponseTopicARN string
+
+	// Number of requests executed concurrently. Requests of the same stack are executed one at a time.
+	Concurrency int
+
+	// Visibility timeout of a received request, extended while the request is executed.
+	VisibilityTimeout time.Duration
+
+	// Long polling wait time of a receive.
+	WaitTime time.Duration
+}
+
+// Worker receives the curation requests, executes them one at a time per stack and reports the responses.
+// A request is deleted only once its response is reported, so that it is redelivered otherwise.
+// The requests of a stack are serialized within the Worker only: the Workers of several hosts
+// rely on the lock of the stack taken by the executed runs in a state backend shared by the hosts.
+type Worker struct {
+	sqsClient SQSAPI
+	snsClient SNSAPI
+	config    Config
+	execute   Executor
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// New constructs a Worker executing the requests with the executor.
+func New(sqsClient SQSAPI, snsClient SNSAPI, config Config, execute Executor) *Worker {
+	return &Worker{
+		sqsClient: sqsClient,
+		snsClient: snsClient,
+		config:    config,
+		execute:   execute,
+		locks:     make(map[string]*sync.Mutex),
+	}
+}
+
+// Run receives and executes the requests until the context is done. The requests being executed
+// are completed with a context which is not cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < max(w.config.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		output, err := w.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(w.config.QueueURL),
+			MaxNumberOfMessages: 1,
+			VisibilityTimeout:   int32(w.config.VisibilityTimeout.Seconds()),
+			WaitTimeSeconds:     int32(w.config.WaitTime.Seconds()),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Error: unable to receive requests from %v: %v\n", w.config.QueueURL, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(receiveRetryDelay):
+			}
+			continue
+		}
+
+		for _, message := range output.Messages {
+			w.handle(context.WithoutCancel(ctx), message)
+		}
+	}
+}
+
+// handle executes the request of the message and deletes the message once the response is reported
+func (w *Worker) handle(ctx context.Context, message sqsTypes.Message) {
+	messageId := aws.ToString(message.MessageId)
+	response := Response{MessageId: messageId, RunId: uuid.NewString(), Result: run.ResultFailed}
+
+	var err error
+	if err = json.Unmarshal([]byte(aws.ToString(message.Body)), &response.Request); err == nil {
+		err = response.Request.Validate()
+	}
+	if err == nil {
+		response.Summary, err = w.executeLocked(ctx, message, response.Request, response.RunId)
+	} else {
+		err = fmt.Errorf("invalid request %v: %w", messageId, err)
+	}
+
+	if err == nil {
+		response.Result = run.ResultSucceeded
+	} else if response.Summary != nil && response.Summary.Error != "" {
+		response.Error = response.Summary.Error
+	} else {
+		response.Error = err.Error()
+	}
+
+	if err := w.respond(ctx, response); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to report the response to request %v: %v\n", messageId, err)
+		return
+	}
+
+	if _, err := w.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(w.config.QueueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to delete request %v: %v\n", messageId, err)
+	}
+}
+
+// executeLocked executes the request holding the lock of its stack, extending the visibility
+// of the message while waiting for the lock and until the execution is completed
+func (w *Worker) executeLocked(ctx context.Context, message sqsTypes.Message, request Request, runId string) (*run.Summary, error) {
+	done := make(chan struct{})
+	defer close(done)
+	go w.extendVisibility(ctx, message, done)
+
+	lock := w.lock(request.StackPath())
+	lock.Lock()
+	defer lock.Unlock()
+
+	return w.execute(ctx, request, runId)
+}
+
+func (w *Worker) lock(stack string) *sync.Mutex {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	lock, ok := w.locks[stack]
+	if !ok {
+		lock = &sync.Mutex{}
+		w.locks[stack] = lock
+	}
+	return lock
+}
+
+// extendVisibility keeps the message invisible to other consumers until done
+func (w *Worker) extendVisibility(ctx context.Context, message sqsTypes.Message, done <-chan struct{}) {
+	ticker := time.NewTicker(w.config.VisibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := w.sqsClient.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(w.config.QueueURL),
+				ReceiptHandle:     message.ReceiptHandle,
+				VisibilityTimeout: int32(w.config.VisibilityTimeout.Seconds()),
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: unable to extend the visibility of request %v: %v\n", aws.ToString(message.MessageId), err)
+			}
+		}
+	}
+}
+
+// respond sends the response to the response queue and publishes it to the response topic, if any
+func (w *Worker) respond(ctx context.Context, response Response) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if w.config.ResponseQueueURL != "" {
+		input := &sqs.SendMessageInput{
+			QueueUrl:    aws.String(w.config.ResponseQueueURL),
+			MessageBody: aws.String(string(body)),
+		}
+		if strings.HasSuffix(w.config.ResponseQueueURL, ".fifo") {
+			// the responses of a stack are ordered, unless the request is invalid
+			groupId := response.Request.Stack
+			if groupId == "" {
+				groupId = response.MessageId
+			}
+			input.MessageGroupId = aws.String(groupId)
+			input.MessageDeduplicationId = aws.String(response.MessageId)
+		}
+		if _, err := w.sqsClient.SendMessage(ctx, input); err != nil {
+			errs = append(errs, fmt.Errorf("error sending response to %v: %w", w.config.ResponseQueueURL, err))
+		}
+	}
+
+	if w.config.ResponseTopicARN != "" {
+		if _, err := w.snsClient.Publish(ctx, &sns.PublishInput{
+			TopicArn: aws.String(w.config.ResponseTopicARN),
+			Message:  aws.String(string(body)),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("error publishing response to %v: %w", w.config.ResponseTopicARN, err))
+		}
+	}
+	return errors.Join(errs...)
+}
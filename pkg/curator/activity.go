@@ -0,0 +1,150 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// maxActivityIds is the maximum number of activity IDs accepted by a DescribeScalingActivities call
+const maxActivityIds = 50
+
+// ScalingActivitiesWaiterOptions are waiter options for ScalingActivitiesWaiter
+type ScalingActivitiesWaiterOptions struct {
+
+	// Set of options to modify how an operation is invoked. These apply to all
+	// operations invoked for this client. Use functional options on operation call to
+	// modify this list for per operation behavior.
+	APIOptions []func(*middleware.Stack) error
+
+	// MinDelay is the minimum amount of time to delay between retries. If unset,
+	// ScalingActivitiesWaiter will use default minimum delay of 5 seconds. Note that
+	// MinDelay must resolve to a value lesser than or equal to the MaxDelay.
+	MinDelay time.Duration
+
+	// MaxDelay is the maximum amount of time to delay between retries. If unset or set
+	// to zero, ScalingActivitiesWaiter will use default max delay of 60 seconds. Note
+	// that MaxDelay must resolve to value greater than or equal to the MinDelay.
+	MaxDelay time.Duration
+
+	// LogWaitAttempts is used to enable logging for waiter retry attempts
+	LogWaitAttempts bool
+
+	// attempts emits the attempts of the waiter of a Curator with their group
+	attempts *waiterAttempts
+}
+
+// ScalingActivitiesWaiter waits for Auto Scaling activities to complete and
+// fails with the activity status message and cause if any of them fails or is cancelled
+type ScalingActivitiesWaiter struct {
+	client autoscaling.DescribeScalingActivitiesAPIClient
+
+	options ScalingActivitiesWaiterOptions
+}
+
+// NewScalingActivitiesWaiter constructs a ScalingActivitiesWaiter.
+func NewScalingActivitiesWaiter(client autoscaling.DescribeScalingActivitiesAPIClient, optFns ...func(*ScalingActivitiesWaiterOptions)) *ScalingActivitiesWaiter {
+	options := ScalingActivitiesWaiterOptions{}
+	options.MinDelay = 5 * time.Second
+	options.MaxDelay = 60 * time.Second
+
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &ScalingActivitiesWaiter{
+		client:  client,
+		options: options,
+	}
+}
+
+// Wait waits for the activities to complete successfully. The maxWaitDur is the
+// maximum wait duration the waiter will wait. The maxWaitDur is required and must
+// be greater than zero.
+func (w *ScalingActivitiesWaiter) Wait(ctx context.Context, activities []asTypes.Activity, maxWaitDur time.Duration, optFns ...func(*ScalingActivitiesWaiterOptions)) error {
+	_, err := w.WaitForOutput(ctx, activities, maxWaitDur, optFns...)
+	return err
+}
+
+// WaitForOutput waits for the activities to complete successfully and returns
+// their final description. The maxWaitDur is the maximum wait duration the waiter
+// will wait. The maxWaitDur is required and must be greater than zero.
+func (w *ScalingActivitiesWaiter) WaitForOutput(ctx context.Context, activities []asTypes.Activity, maxWaitDur time.Duration, optFns ...func(*ScalingActivitiesWaiterOptions)) ([]asTypes.Activity, error) {
+	if maxWaitDur <= 0 {
+		return nil, fmt.Errorf("maximum wait time for waiter must be greater than zero")
+	}
+
+	options := w.options
+	for _, fn := range optFns {
+		fn(&options)
+	}
+
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = 60 * time.Second
+	}
+
+	if options.MinDelay > options.MaxDelay {
+		return nil, fmt.Errorf("minimum waiter delay %v must be lesser than or equal to maximum waiter delay of %v.", options.MinDelay, options.MaxDelay)
+	}
+
+	// activity IDs are only unique within an Auto Scaling Group
+	pending := make(map[string][]string)
+	for _, a := range activities {
+		pending[*a.AutoScalingGroupName] = append(pending[*a.AutoScalingGroupName], *a.ActivityId)
+	}
+
+	completed := make([]asTypes.Activity, 0, len(activities))
+	err := waitLoop(ctx, options.attempts, maxWaitDur, options.MinDelay, options.MaxDelay, options.LogWaitAttempts, options.APIOptions, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		for asgName, activityIds := range pending {
+			stillPending := make([]string, 0, len(activityIds))
+			for start := 0; start < len(activityIds); start += maxActivityIds {
+				end := min(start+maxActivityIds, len(activityIds))
+				out, err := w.client.DescribeScalingActivities(ctx, &autoscaling.DescribeScalingActivitiesInput{
+					AutoScalingGroupName: aws.String(asgName),
+					ActivityIds:          activityIds[start:end],
+				}, func(o *autoscaling.Options) {
+					o.APIOptions = append(o.APIOptions, apiOptions...)
+				})
+				if err != nil {
+					// the activity may not be visible yet
+					stillPending = append(stillPending, activityIds[start:end]...)
+					continue
+				}
+
+				for _, a := range out.Activities {
+					switch a.StatusCode {
+					case asTypes.ScalingActivityStatusCodeSuccessful:
+						completed = append(completed, a)
+					case asTypes.ScalingActivityStatusCodeFailed, asTypes.ScalingActivityStatusCodeCancelled:
+						return false, scalingActivityError(a)
+					default:
+						stillPending = append(stillPending, *a.ActivityId)
+					}
+				}
+			}
+
+			if len(stillPending) == 0 {
+				delete(pending, asgName)
+			} else {
+				pending[asgName] = stillPending
+			}
+		}
+		return len(pending) > 0, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			return nil, &WaiterTimeoutError{Waiter: fmt.Sprintf("ScalingActivities waiter, pending activities: %v", pending)}
+		}
+		return nil, err
+	}
+	return completed, nil
+}
+
+func scalingActivityError(activity asTypes.Activity) error {
+	return &ASGOperationError{AutoScalingGroupName: aws.ToString(activity.AutoScalingGroupName), Activity: &activity}
+}
@@ -0,0 +1,115 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// WaitForInstanceGroupAlarms waits until all CloudWatch alarms of the group are in OK state
+// and stay in it for the sustain duration. A state change of any alarm restarts the sustain duration.
+func WaitForInstanceGroupAlarms(ctx context.Context, cloudwatchClient CloudWatchAPI, group types.Group) error {
+	return packageCurator().waitForInstanceGroupAlarms(ctx, cloudwatchClient, group)
+}
+
+// waitForInstanceGroupAlarms is WaitForInstanceGroupAlarms with the output and the wait duration of the Curator
+func (c *Curator) waitForInstanceGroupAlarms(ctx context.Context, cloudwatchClient CloudWatchAPI, group types.Group) error {
+	if group.Alarms == nil {
+		return nil
+	}
+	if cloudwatchClient == nil {
+		return errors.New("a CloudWatch client is required to wait for the alarms")
+	}
+
+	var sustain time.Duration
+	if group.Alarms.Sustain != nil {
+		sustain = *group.Alarms.Sustain
+	}
+	timeout := c.waitDuration
+	if group.Alarms.Timeout != nil {
+		timeout = *group.Alarms.Timeout
+	}
+
+	c.Printf("Instance group %v: waiting for alarms %v to be OK\n", *group.Name, group.Alarms.Names)
+	var okSince time.Time
+	var last map[string]cwTypes.StateValue
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, 10*time.Second, time.Minute, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		states, updated, err := describeAlarmStates(ctx, cloudwatchClient, group.Alarms.Names, apiOptions)
+		if err != nil {
+			return false, err
+		}
+		last = states
+
+		for _, name := range group.Alarms.Names {
+			state, ok := states[name]
+			if !ok {
+				return false, fmt.Errorf("alarm %v of instance group %v does not exist", name, *group.Name)
+			}
+			if state != cwTypes.StateValueOk {
+				okSince = time.Time{}
+				return true, nil
+			}
+		}
+
+		// the alarms may have left and returned to OK state between the attempts
+		now := time.Now()
+		if okSince.IsZero() || updated.After(okSince) {
+			okSince = now
+		}
+		return now.Sub(okSince) < sustain, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			lastStates := make(map[string]string, len(last))
+			for name, state := range last {
+				lastStates[name] = string(state)
+			}
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("alarms of instance group %v to be OK", *group.Name), LastStates: lastStates}
+		}
+		return err
+	}
+
+	c.Printf("Instance group %v: alarms %v are OK\n", *group.Name, group.Alarms.Names)
+	return nil
+}
+
+// describeAlarmStates returns the states of the named metric and composite alarms by name
+// and the latest time any of them changed its state
+func describeAlarmStates(ctx context.Context, cloudwatchClient CloudWatchAPI, names []string, apiOptions []func(*middleware.Stack) error) (map[string]cwTypes.StateValue, time.Time, error) {
+	states := make(map[string]cwTypes.StateValue, len(names))
+	var updated time.Time
+	paginator := cloudwatch.NewDescribeAlarmsPaginator(cloudwatchClient, &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: names,
+		AlarmTypes: []cwTypes.AlarmType{cwTypes.AlarmTypeMetricAlarm, cwTypes.AlarmTypeCompositeAlarm},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx, func(o *cloudwatch.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err != nil {
+			return nil, updated, err
+		}
+
+		for _, a := range output.MetricAlarms {
+			states[aws.ToString(a.AlarmName)] = a.StateValue
+			if t := aws.ToTime(a.StateUpdatedTimestamp); t.After(updated) {
+				updated = t
+			}
+		}
+		for _, a := range output.CompositeAlarms {
+			states[aws.ToString(a.AlarmName)] = a.StateValue
+			if t := aws.ToTime(a.StateUpdatedTimestamp); t.After(updated) {
+				updated = t
+			}
+		}
+	}
+	return states, updated, nil
+}
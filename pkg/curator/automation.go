@@ -0,0 +1,148 @@
+package curator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// SSMAPI is the subset of the SSM client operations used by the curator.
+type SSMAPI interface {
+	ssm.ListCommandInvocationsAPIClient
+	ssm.DescribeInstanceInformationAPIClient
+
+	StartAutomationExecution(context.Context, *ssm.StartAutomationExecutionInput, ...func(*ssm.Options)) (*ssm.StartAutomationExecutionOutput, error)
+	GetAutomationExecution(context.Context, *ssm.GetAutomationExecutionInput, ...func(*ssm.Options)) (*ssm.GetAutomationExecutionOutput, error)
+	SendCommand(context.Context, *ssm.SendCommandInput, ...func(*ssm.Options)) (*ssm.SendCommandOutput, error)
+}
+
+// AutomationTemplateData is the data available to automation parameter templates
+type AutomationTemplateData struct {
+	Group       string
+	Phase       types.AutomationPhase
+	InstanceIds []string
+}
+
+// renderAutomationParameters renders the parameter templates, splitting every rendered value
+// into lines so that a range over the instances yields one value per instance
+func renderAutomationParameters(parameters map[string][]string, data AutomationTemplateData) (map[string][]string, error) {
+	rendered := make(map[string][]string, len(parameters))
+	for name, templates := range parameters {
+		values := make([]string, 0, len(templates))
+		for _, t := range templates {
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(t)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing automation parameter %v template: %w", name, err)
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("error rendering automation parameter %v template: %w", name, err)
+			}
+
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					values = append(values, line)
+				}
+			}
+		}
+		rendered[name] = values
+	}
+	return rendered, nil
+}
+
+// RunInstanceGroupAutomations executes the group automations of the phase one by one
+// and waits for every execution to succeed.
+func RunInstanceGroupAutomations(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string, phase types.AutomationPhase) error {
+	return packageCurator().runInstanceGroupAutomations(ctx, ssmClient, group, instanceIds, phase)
+}
+
+// runInstanceGroupAutomations is RunInstanceGroupAutomations with the output and the wait duration of the Curator
+func (c *Curator) runInstanceGroupAutomations(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string, phase types.AutomationPhase) error {
+	for _, a := range group.Automations {
+		if a.Phase != phase {
+			continue
+		}
+
+		parameters, err := renderAutomationParameters(a.Parameters, AutomationTemplateData{
+			Group:       *group.Name,
+			Phase:       phase,
+			InstanceIds: instanceIds,
+		})
+		if err != nil {
+			return err
+		}
+
+		output, err := ssmClient.StartAutomationExecution(ctx, &ssm.StartAutomationExecutionInput{
+			DocumentName:    a.Document,
+			DocumentVersion: a.DocumentVersion,
+			Parameters:      parameters,
+		})
+		if err != nil {
+			return err
+		}
+		c.Printf("Instance group %v: %v automation %v has been started: %v\n", *group.Name, phase, *a.Document, *output.AutomationExecutionId)
+
+		timeout := c.waitDuration
+		if a.Timeout != nil {
+			timeout = *a.Timeout
+		}
+		if err := c.waitForAutomationExecution(ctx, ssmClient, group, *output.AutomationExecutionId, timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Curator) waitForAutomationExecution(ctx context.Context, ssmClient SSMAPI, group types.Group, executionId string, timeout time.Duration) error {
+	var status ssmTypes.AutomationExecutionStatus
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, 5*time.Second, 30*time.Second, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		output, err := ssmClient.GetAutomationExecution(ctx, &ssm.GetAutomationExecutionInput{
+			AutomationExecutionId: aws.String(executionId),
+		}, func(o *ssm.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err != nil {
+			return false, err
+		}
+
+		status = output.AutomationExecution.AutomationExecutionStatus
+		switch status {
+		case ssmTypes.AutomationExecutionStatusSuccess, ssmTypes.AutomationExecutionStatusCompletedWithSuccess:
+			return false, nil
+		case ssmTypes.AutomationExecutionStatusFailed,
+			ssmTypes.AutomationExecutionStatusTimedout,
+			ssmTypes.AutomationExecutionStatusCancelled,
+			ssmTypes.AutomationExecutionStatusRejected,
+			ssmTypes.AutomationExecutionStatusCompletedWithFailure,
+			ssmTypes.AutomationExecutionStatusChangeCalendarOverrideRejected,
+			ssmTypes.AutomationExecutionStatusExited:
+			return false, fmt.Errorf("automation execution %v of instance group %v is %v: %v", executionId, *group.Name, status, aws.ToString(output.AutomationExecution.FailureMessage))
+		}
+		return true, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			return &WaiterTimeoutError{
+				Waiter:     fmt.Sprintf("automation execution %v of instance group %v", executionId, *group.Name),
+				LastStates: map[string]string{executionId: string(status)},
+			}
+		}
+		return err
+	}
+
+	c.Printf("Instance group %v: automation execution %v has succeeded\n", *group.Name, executionId)
+	return nil
+}
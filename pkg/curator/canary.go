@@ -0,0 +1,58 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ErrCanaryRejected is returned when the continuation past a canary group is rejected
+var ErrCanaryRejected = errors.New("canary has been rejected")
+
+// ConfirmFunc asks whether the run should continue past the canary group,
+// e.g. interactively or through an external approval API.
+type ConfirmFunc func(ctx context.Context, group types.Group) (bool, error)
+
+// PauseAfterCanary pauses the run after the startup of a canary group for the configured duration,
+// and then waits for the confirmation to continue, if required.
+func PauseAfterCanary(ctx context.Context, group types.Group, confirm ConfirmFunc) error {
+	return packageCurator().PauseAfterCanary(ctx, group, confirm)
+}
+
+// PauseAfterCanary is PauseAfterCanary with the output of the Curator
+func (c *Curator) PauseAfterCanary(ctx context.Context, group types.Group, confirm ConfirmFunc) error {
+	if group.Canary == nil {
+		return nil
+	}
+
+	if group.Canary.Pause != nil {
+		c.Printf("Instance group %v: canary pause for %v\n", *group.Name, group.Canary.Pause.String())
+		timer := time.NewTimer(*group.Canary.Pause)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if group.Canary.Confirm {
+		if confirm == nil {
+			return fmt.Errorf("canary instance group %v requires a confirmation", *group.Name)
+		}
+
+		ok, err := confirm(ctx, group)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: instance group %v", ErrCanaryRejected, *group.Name)
+		}
+	}
+
+	c.Printf("Instance group %v: canary has been confirmed\n", *group.Name)
+	return nil
+}
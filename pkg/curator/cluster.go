@@ -0,0 +1,158 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdsTypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DB cluster statuses handled by the curator
+const (
+	ClusterStatusAvailable string = "available"
+	ClusterStatusStarting  string = "starting"
+	ClusterStatusStopping  string = "stopping"
+	ClusterStatusStopped   string = "stopped"
+)
+
+// ClusterWaitDuration is the maximum duration of a wait for clusters to stop or start
+const ClusterWaitDuration time.Duration = 3 * DefaultWaitDuration
+
+// RDSAPI is the subset of the RDS client operations used by the curator.
+// DocumentDB and Neptune clusters are managed through the RDS management API.
+type RDSAPI interface {
+	rds.DescribeDBClustersAPIClient
+
+	StopDBCluster(context.Context, *rds.StopDBClusterInput, ...func(*rds.Options)) (*rds.StopDBClusterOutput, error)
+	StartDBCluster(context.Context, *rds.StartDBClusterInput, ...func(*rds.Options)) (*rds.StartDBClusterOutput, error)
+}
+
+// DescribeGroupClusters describes the group clusters, verifying their engines.
+func DescribeGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group) ([]rdsTypes.DBCluster, error) {
+	clusters := make([]rdsTypes.DBCluster, 0, len(group.Clusters))
+	for _, c := range group.Clusters {
+		output, err := rdsClient.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+			DBClusterIdentifier: c.Identifier,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(output.DBClusters) != 1 {
+			return nil, fmt.Errorf("cluster %v of instance group %v not found", *c.Identifier, *group.Name)
+		}
+
+		cluster := output.DBClusters[0]
+		if aws.ToString(cluster.Engine) != *c.Engine {
+			return nil, fmt.Errorf("cluster %v of instance group %v has engine %v, expected %v", *c.Identifier, *group.Name, aws.ToString(cluster.Engine), *c.Engine)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// StopGroupClusters stops the available group clusters and waits until all of them are stopped.
+func StopGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group) error {
+	return packageCurator().stopGroupClusters(ctx, rdsClient, group)
+}
+
+// stopGroupClusters is StopGroupClusters with the output and the wait duration of the Curator
+func (c *Curator) stopGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group) error {
+	clusters, err := DescribeGroupClusters(ctx, rdsClient, group)
+	if err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters {
+		switch status := aws.ToString(cluster.Status); status {
+		case ClusterStatusAvailable:
+			if _, err := rdsClient.StopDBCluster(ctx, &rds.StopDBClusterInput{
+				DBClusterIdentifier: cluster.DBClusterIdentifier,
+			}); err != nil {
+				return err
+			}
+			c.Printf("Instance group %v: stopping cluster %v\n", *group.Name, *cluster.DBClusterIdentifier)
+		case ClusterStatusStopping, ClusterStatusStopped:
+		default:
+			return fmt.Errorf("cluster %v of instance group %v cannot be stopped in status %v", *cluster.DBClusterIdentifier, *group.Name, status)
+		}
+	}
+
+	return c.waitForGroupClusters(ctx, rdsClient, group, ClusterStatusStopped)
+}
+
+// StartGroupClusters starts the stopped group clusters and waits until all of them are available.
+func StartGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group) error {
+	return packageCurator().startGroupClusters(ctx, rdsClient, group)
+}
+
+// startGroupClusters is StartGroupClusters with the output and the wait duration of the Curator
+func (c *Curator) startGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group) error {
+	clusters, err := DescribeGroupClusters(ctx, rdsClient, group)
+	if err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters {
+		switch status := aws.ToString(cluster.Status); status {
+		case ClusterStatusStopped:
+			if _, err := rdsClient.StartDBCluster(ctx, &rds.StartDBClusterInput{
+				DBClusterIdentifier: cluster.DBClusterIdentifier,
+			}); err != nil {
+				return err
+			}
+			c.Printf("Instance group %v: starting cluster %v\n", *group.Name, *cluster.DBClusterIdentifier)
+		case ClusterStatusStarting, ClusterStatusAvailable:
+		default:
+			return fmt.Errorf("cluster %v of instance group %v cannot be started in status %v", *cluster.DBClusterIdentifier, *group.Name, status)
+		}
+	}
+
+	return c.waitForGroupClusters(ctx, rdsClient, group, ClusterStatusAvailable)
+}
+
+func (c *Curator) waitForGroupClusters(ctx context.Context, rdsClient RDSAPI, group types.Group, status string) error {
+	if len(group.Clusters) == 0 {
+		return nil
+	}
+
+	pending := make([]string, 0, len(group.Clusters))
+	for _, cluster := range group.Clusters {
+		pending = append(pending, *cluster.Identifier)
+	}
+
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), ClusterWaitDuration, 30*time.Second, time.Minute, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		stillPending := make([]string, 0, len(pending))
+		for _, id := range pending {
+			output, err := rdsClient.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+				DBClusterIdentifier: aws.String(id),
+			}, func(o *rds.Options) {
+				o.APIOptions = append(o.APIOptions, apiOptions...)
+			})
+			if err != nil {
+				return false, err
+			}
+
+			if len(output.DBClusters) != 1 || aws.ToString(output.DBClusters[0].Status) != status {
+				stillPending = append(stillPending, id)
+			}
+		}
+		pending = stillPending
+		return len(pending) > 0, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("clusters of instance group %v to become %v: %v", *group.Name, status, pending)}
+		}
+		return err
+	}
+
+	c.Printf("Instance group %v: clusters are %v\n", *group.Name, status)
+	return nil
+}
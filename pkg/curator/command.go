@@ -0,0 +1,257 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// SSM Run Command documents running the commands of group steps
+const (
+	RunShellScriptDocument      string = "AWS-RunShellScript"
+	RunPowerShellScriptDocument string = "AWS-RunPowerShellScript"
+)
+
+// maxSendCommandInstanceIds is the maximum number of instance IDs of a single SendCommand request
+const maxSendCommandInstanceIds = 50
+
+// VerifyInstanceGroup runs the group verification commands on every instance via SSM Run Command
+// and waits until they exit, failing unless all of them exit with zero.
+func VerifyInstanceGroup(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().VerifyInstanceGroup(ctx, ssmClient, group, instanceIds)
+}
+
+// VerifyInstanceGroup is VerifyInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) VerifyInstanceGroup(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	if group.Verification == nil || len(instanceIds) == 0 {
+		return nil
+	}
+
+	return c.runInstanceGroupCommands(ctx, ssmClient, group, instanceIds, "verification", group.Verification.Commands, group.Verification.PowerShell, group.Verification.Timeout, group.Verification.Parallelism)
+}
+
+// WaitForInstanceGroupBoot waits via SSM Run Command until cloud-init has finished and the sentinel file
+// exists on every group instance, failing if cloud-init reports an error.
+func WaitForInstanceGroupBoot(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().WaitForInstanceGroupBoot(ctx, ssmClient, group, instanceIds)
+}
+
+// WaitForInstanceGroupBoot is WaitForInstanceGroupBoot with the output and the wait duration of the Curator
+func (c *Curator) WaitForInstanceGroupBoot(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	if group.BootCompletion == nil || len(instanceIds) == 0 {
+		return nil
+	}
+
+	commands := make([]string, 0, 2)
+	if group.BootCompletion.CloudInit {
+		commands = append(commands, "cloud-init status --wait")
+	}
+	if group.BootCompletion.SentinelFile != nil {
+		commands = append(commands, fmt.Sprintf("while [ ! -e '%v' ]; do sleep 5; done", *group.BootCompletion.SentinelFile))
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	return c.runInstanceGroupCommands(ctx, ssmClient, group, instanceIds, "boot completion", commands, false, group.BootCompletion.Timeout, nil)
+}
+
+// ShutdownInstanceGroupServices stops the group services and runs the group shutdown commands on every instance
+// via SSM Run Command, and waits until they exit, failing unless all of them exit with zero.
+func ShutdownInstanceGroupServices(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().shutdownInstanceGroupServices(ctx, ssmClient, group, instanceIds)
+}
+
+// shutdownInstanceGroupServices is ShutdownInstanceGroupServices with the output and the wait duration of the Curator
+func (c *Curator) shutdownInstanceGroupServices(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string) error {
+	if group.GracefulShutdown == nil || len(instanceIds) == 0 {
+		return nil
+	}
+
+	commands := make([]string, 0, len(group.GracefulShutdown.Services)+len(group.GracefulShutdown.Commands))
+	for _, s := range group.GracefulShutdown.Services {
+		if group.GracefulShutdown.PowerShell {
+			commands = append(commands, fmt.Sprintf("Stop-Service -Name '%v'", s))
+		} else {
+			commands = append(commands, fmt.Sprintf("systemctl stop '%v'", s))
+		}
+	}
+	commands = append(commands, group.GracefulShutdown.Commands...)
+
+	return c.runInstanceGroupCommands(ctx, ssmClient, group, instanceIds, "graceful shutdown", commands, group.GracefulShutdown.PowerShell, group.GracefulShutdown.Timeout, group.GracefulShutdown.Parallelism)
+}
+
+// runInstanceGroupCommands runs the commands of a group step on every instance via SSM Run Command
+// and waits until they exit, failing unless all of them exit with zero.
+func (c *Curator) runInstanceGroupCommands(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string, step string, commands []string, powerShell bool, timeoutOverride *time.Duration, parallelism *int32) error {
+	timeout := c.waitDuration
+	if timeoutOverride != nil {
+		timeout = *timeoutOverride
+	}
+
+	documentName := RunShellScriptDocument
+	if powerShell {
+		documentName = RunPowerShellScriptDocument
+	}
+
+	var maxConcurrency *string
+	if parallelism != nil {
+		maxConcurrency = aws.String(strconv.Itoa(int(*parallelism)))
+	}
+
+	if err := c.waitForSSMAgents(ctx, ssmClient, group, instanceIds, timeout); err != nil {
+		return err
+	}
+
+	commandIds := make([]string, 0)
+	for start := 0; start < len(instanceIds); start += maxSendCommandInstanceIds {
+		end := min(start+maxSendCommandInstanceIds, len(instanceIds))
+		output, err := ssmClient.SendCommand(ctx, &ssm.SendCommandInput{
+			DocumentName:   aws.String(documentName),
+			InstanceIds:    instanceIds[start:end],
+			MaxConcurrency: maxConcurrency,
+			Comment:        aws.String(fmt.Sprintf("%v of instance group %v", step, *group.Name)),
+			Parameters: map[string][]string{
+				"commands":         commands,
+				"executionTimeout": {strconv.Itoa(int(timeout.Seconds()))},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		commandIds = append(commandIds, *output.Command.CommandId)
+	}
+	c.Printf("Instance group %v: %v commands have been sent: %v\n", *group.Name, step, commandIds)
+
+	pending := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		pending[id] = true
+	}
+	failures := make([]string, 0)
+
+	// the wait outlasts the execution timeout to collect the timed out invocations
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout+time.Minute, 5*time.Second, 30*time.Second, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		for _, commandId := range commandIds {
+			paginator := ssm.NewListCommandInvocationsPaginator(ssmClient, &ssm.ListCommandInvocationsInput{
+				CommandId: aws.String(commandId),
+				Details:   true,
+			})
+			for paginator.HasMorePages() {
+				output, err := paginator.NextPage(ctx, func(o *ssm.Options) {
+					o.APIOptions = append(o.APIOptions, apiOptions...)
+				})
+				if err != nil {
+					return false, err
+				}
+
+				for _, invocation := range output.CommandInvocations {
+					id := aws.ToString(invocation.InstanceId)
+					if !pending[id] {
+						continue
+					}
+
+					switch invocation.Status {
+					case ssmTypes.CommandInvocationStatusSuccess:
+						delete(pending, id)
+					case ssmTypes.CommandInvocationStatusFailed,
+						ssmTypes.CommandInvocationStatusTimedOut,
+						ssmTypes.CommandInvocationStatusCancelled:
+						delete(pending, id)
+						failures = append(failures, describeCommandInvocationFailure(invocation))
+					}
+				}
+			}
+		}
+		return len(pending) > 0, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			ids := make([]string, 0, len(pending))
+			for id := range pending {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("%v of instance group %v", step, *group.Name), InstanceIds: ids}
+		}
+		return err
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%v of instance group %v has failed: %v", step, *group.Name, strings.Join(failures, "; "))
+	}
+
+	c.Printf("Instance group %v: %v has succeeded\n", *group.Name, step)
+	return nil
+}
+
+// waitForSSMAgents waits until the SSM agents of the instances are online, e.g. after their startup
+func (c *Curator) waitForSSMAgents(ctx context.Context, ssmClient SSMAPI, group types.Group, instanceIds []string, timeout time.Duration) error {
+	pending := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		pending[id] = true
+	}
+
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, 5*time.Second, 30*time.Second, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		for start := 0; start < len(instanceIds); start += maxSendCommandInstanceIds {
+			end := min(start+maxSendCommandInstanceIds, len(instanceIds))
+			paginator := ssm.NewDescribeInstanceInformationPaginator(ssmClient, &ssm.DescribeInstanceInformationInput{
+				Filters: []ssmTypes.InstanceInformationStringFilter{
+					{
+						Key:    aws.String(string(ssmTypes.InstanceInformationFilterKeyInstanceIds)),
+						Values: instanceIds[start:end],
+					},
+				},
+			})
+			for paginator.HasMorePages() {
+				output, err := paginator.NextPage(ctx, func(o *ssm.Options) {
+					o.APIOptions = append(o.APIOptions, apiOptions...)
+				})
+				if err != nil {
+					return false, err
+				}
+
+				for _, i := range output.InstanceInformationList {
+					if i.PingStatus == ssmTypes.PingStatusOnline {
+						delete(pending, aws.ToString(i.InstanceId))
+					}
+				}
+			}
+		}
+		return len(pending) > 0, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			ids := make([]string, 0, len(pending))
+			for id := range pending {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("SSM agents of instance group %v", *group.Name), InstanceIds: ids}
+		}
+		return err
+	}
+	return nil
+}
+
+func describeCommandInvocationFailure(invocation ssmTypes.CommandInvocation) string {
+	description := fmt.Sprintf("instance %v is %v", aws.ToString(invocation.InstanceId), invocation.Status)
+	for _, p := range invocation.CommandPlugins {
+		description += fmt.Sprintf(", exit code %v", p.ResponseCode)
+		if output := strings.TrimSpace(aws.ToString(p.Output)); output != "" {
+			description += fmt.Sprintf(": %v", output)
+		}
+	}
+	return description
+}
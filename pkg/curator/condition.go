@@ -0,0 +1,174 @@
+package curator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/jmespath/go-jmespath"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// WaitForInstanceGroupConditions waits for the group wait conditions of the phase one by one,
+// and fails on the first condition not met within its timeout.
+func WaitForInstanceGroupConditions(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string, phase types.WaitConditionPhase) error {
+	return packageCurator().waitForInstanceGroupConditions(ctx, ec2Client, autoscalingClient, group, instanceIds, phase)
+}
+
+// waitForInstanceGroupConditions is WaitForInstanceGroupConditions with the output and the wait duration of the Curator
+func (c *Curator) waitForInstanceGroupConditions(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string, phase types.WaitConditionPhase) error {
+	for _, w := range group.WaitConditions {
+		if w.Phase != phase {
+			continue
+		}
+
+		name := aws.ToString(w.Name)
+		if name == "" {
+			name = *w.Expression
+		}
+
+		expression, err := jmespath.Compile(*w.Expression)
+		if err != nil {
+			return fmt.Errorf("error compiling wait condition %v of instance group %v: %w", name, *group.Name, err)
+		}
+
+		timeout := c.waitDuration
+		if w.Timeout != nil {
+			timeout = *w.Timeout
+		}
+
+		c.Printf("Instance group %v: waiting for condition %v\n", *group.Name, name)
+		var last []interface{}
+		err = waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, 10*time.Second, time.Minute, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+			values, err := evaluateWaitCondition(ctx, ec2Client, autoscalingClient, group, instanceIds, w, expression, apiOptions)
+			if err != nil {
+				return false, err
+			}
+			last = values
+
+			match, err := waitConditionComparator(w)(values, *w.Expected)
+			return !match, err
+		})
+		if err != nil {
+			if errors.Is(err, ErrWaitTimeout) {
+				return &WaiterTimeoutError{Waiter: fmt.Sprintf("condition %v of instance group %v, last values %v", name, *group.Name, last)}
+			}
+			return err
+		}
+		c.Printf("Instance group %v: condition %v has been met\n", *group.Name, name)
+	}
+
+	return nil
+}
+
+// evaluateWaitCondition returns the values selected by the condition expression, converting
+// the scalar values to strings so that the comparators can match numbers and booleans too
+func evaluateWaitCondition(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string, w types.WaitCondition, expression *jmespath.JMESPath, apiOptions []func(*middleware.Stack) error) ([]interface{}, error) {
+	var data interface{}
+	var err error
+	if w.Source != nil && *w.Source == types.WaitConditionSourceAutoScalingInstances {
+		data, err = describeConditionAutoScalingInstances(ctx, autoscalingClient, instanceIds, apiOptions)
+	} else {
+		data, err = describeConditionInstances(ctx, ec2Client, instanceIds, apiOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// normalize the output as the AWS CLI does, so that the expression compares values rather than pointers
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, err
+	}
+
+	value, err := expression.Search(document)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating wait condition of instance group %v: %w", *group.Name, err)
+	}
+
+	var values []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		values = v
+	default:
+		values = []interface{}{v}
+	}
+	for i, v := range values {
+		switch v.(type) {
+		case string:
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+// waitConditionComparator returns the comparator of the condition
+func waitConditionComparator(w types.WaitCondition) Comparator {
+	if w.Comparator == nil {
+		return AllStringEquals
+	}
+	switch *w.Comparator {
+	case types.WaitConditionAnyStringEquals:
+		return AnyStringEquals
+	case types.WaitConditionAllStringEqualsOrEmpty:
+		return AllStringEqualsOrEmpty
+	}
+	return AllStringEquals
+}
+
+func describeConditionInstances(ctx context.Context, ec2Client EC2API, instanceIds []string, apiOptions []func(*middleware.Stack) error) (*ec2.DescribeInstancesOutput, error) {
+	output := &ec2.DescribeInstancesOutput{}
+	if len(instanceIds) == 0 {
+		return output, nil
+	}
+
+	// merge the pages so that the expression sees every reservation
+	paginator := ec2.NewDescribeInstancesPaginator(ec2Client, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIds,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, func(o *ec2.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		output.Reservations = append(output.Reservations, page.Reservations...)
+	}
+	return output, nil
+}
+
+func describeConditionAutoScalingInstances(ctx context.Context, autoscalingClient AutoScalingAPI, instanceIds []string, apiOptions []func(*middleware.Stack) error) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+	// merge the chunks so that the expression sees every instance
+	output := &autoscaling.DescribeAutoScalingInstancesOutput{}
+	for _, chunk := range chunkInstanceIds(instanceIds, maxAutoScalingInstanceIds) {
+		page, err := autoscalingClient.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: chunk,
+		}, func(o *autoscaling.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		output.AutoScalingInstances = append(output.AutoScalingInstances, page.AutoScalingInstances...)
+	}
+	return output, nil
+}
@@ -0,0 +1,171 @@
+package curator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingTypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// PricingRegion is the region of the AWS Price List API endpoint used by the curator
+const PricingRegion string = "us-east-1"
+
+// PricingAPI is the subset of the Price List client operations used by the curator.
+type PricingAPI interface {
+	GetProducts(context.Context, *pricing.GetProductsInput, ...func(*pricing.Options)) (*pricing.GetProductsOutput, error)
+}
+
+// InstanceCost is the on-demand compute price of an instance
+type InstanceCost struct {
+	Group        string
+	InstanceId   string
+	InstanceType string
+	Platform     string
+	HourlyPrice  float64
+}
+
+// CostEstimate is the on-demand compute cost saved by keeping instances down
+type CostEstimate struct {
+	Region      string
+	Hours       float64
+	Instances   []InstanceCost
+	HourlyPrice float64
+	Savings     float64
+}
+
+// priceListProduct is the part of a Price List product document read by the curator
+type priceListProduct struct {
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]struct {
+				Unit         string            `json:"unit"`
+				PricePerUnit map[string]string `json:"pricePerUnit"`
+			} `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+// pricingPlatform maps the instance platform details to the Price List operating system and license model
+func pricingPlatform(instance ec2Types.Instance) (operatingSystem, licenseModel string) {
+	switch aws.ToString(instance.PlatformDetails) {
+	case "Windows", "Windows BYOL":
+		return "Windows", "License Included"
+	case "Red Hat Enterprise Linux":
+		return "RHEL", "No License required"
+	case "SUSE Linux":
+		return "SUSE", "No License required"
+	}
+	return "Linux", "No License required"
+}
+
+// pricingTenancy maps the instance placement tenancy to the Price List tenancy
+func pricingTenancy(instance ec2Types.Instance) string {
+	if instance.Placement != nil {
+		switch instance.Placement.Tenancy {
+		case ec2Types.TenancyDedicated:
+			return "Dedicated"
+		case ec2Types.TenancyHost:
+			return "Host"
+		}
+	}
+	return "Shared"
+}
+
+// GetInstanceHourlyPrice returns the on-demand hourly price in USD of the instance type in the region.
+func GetInstanceHourlyPrice(ctx context.Context, pricingClient PricingAPI, region, instanceType, operatingSystem, licenseModel, tenancy string) (float64, error) {
+	filter := func(field, value string) pricingTypes.Filter {
+		return pricingTypes.Filter{
+			Type:  pricingTypes.FilterTypeTermMatch,
+			Field: aws.String(field),
+			Value: aws.String(value),
+		}
+	}
+
+	output, err := pricingClient.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []pricingTypes.Filter{
+			filter("regionCode", region),
+			filter("instanceType", instanceType),
+			filter("operatingSystem", operatingSystem),
+			filter("licenseModel", licenseModel),
+			filter("tenancy", tenancy),
+			filter("preInstalledSw", "NA"),
+			filter("capacitystatus", "Used"),
+		},
+		MaxResults: aws.Int32(10),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, doc := range output.PriceList {
+		var product priceListProduct
+		if err := json.Unmarshal([]byte(doc), &product); err != nil {
+			return 0, err
+		}
+		for _, term := range product.Terms.OnDemand {
+			for _, dimension := range term.PriceDimensions {
+				if dimension.Unit != "Hrs" {
+					continue
+				}
+				if usd, ok := dimension.PricePerUnit["USD"]; ok {
+					return strconv.ParseFloat(usd, 64)
+				}
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no on-demand price of %v %v instances in %v", instanceType, operatingSystem, region)
+}
+
+// EstimateShutdownSavings estimates the on-demand compute cost saved by keeping
+// the running instances of the resolved groups down for the given number of hours.
+// Storage, Elastic IP and other charges which continue while instances are stopped are not accounted.
+func EstimateShutdownSavings(ctx context.Context, pricingClient PricingAPI, region string, groups []types.Group, hours float64) (*CostEstimate, error) {
+	estimate := &CostEstimate{
+		Region:    region,
+		Hours:     hours,
+		Instances: make([]InstanceCost, 0),
+	}
+
+	prices := make(map[string]float64)
+	for _, group := range groups {
+		for _, i := range group.Instances {
+			if i.State == nil || (i.State.Name != ec2Types.InstanceStateNameRunning && i.State.Name != ec2Types.InstanceStateNamePending) {
+				continue
+			}
+
+			operatingSystem, licenseModel := pricingPlatform(i)
+			tenancy := pricingTenancy(i)
+			key := fmt.Sprintf("%v/%v/%v/%v", i.InstanceType, operatingSystem, licenseModel, tenancy)
+			price, ok := prices[key]
+			if !ok {
+				var err error
+				price, err = GetInstanceHourlyPrice(ctx, pricingClient, region, string(i.InstanceType), operatingSystem, licenseModel, tenancy)
+				if err != nil {
+					return nil, err
+				}
+				prices[key] = price
+			}
+
+			estimate.Instances = append(estimate.Instances, InstanceCost{
+				Group:        *group.Name,
+				InstanceId:   *i.InstanceId,
+				InstanceType: string(i.InstanceType),
+				Platform:     operatingSystem,
+				HourlyPrice:  price,
+			})
+			estimate.HourlyPrice += price
+		}
+	}
+	estimate.Savings = estimate.HourlyPrice * hours
+
+	return estimate, nil
+}
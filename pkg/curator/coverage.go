@@ -0,0 +1,82 @@
+package curator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// maxAutoScalingInstanceIds is the maximum number of instance IDs of a single DescribeAutoScalingInstances request
+const maxAutoScalingInstanceIds = 50
+
+// maxAutoScalingGroupNames is the maximum number of group names of a single DescribeAutoScalingGroups request
+const maxAutoScalingGroupNames = 100
+
+// FindUncoveredInstances resolves the instances of all the stack groups and returns the InService instances
+// of their Auto Scaling Groups matched by no group, by the Auto Scaling Group name. Lowering the MinSize
+// of an Auto Scaling Group by the curated instances alone may let it terminate the uncovered ones.
+func FindUncoveredInstances(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, stack *types.Stack) (map[string][]string, error) {
+	return packageCurator().FindUncoveredInstances(ctx, ec2Client, autoscalingClient, stack)
+}
+
+// FindUncoveredInstances is FindUncoveredInstances with the events of the Curator
+func (c *Curator) FindUncoveredInstances(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, stack *types.Stack) (map[string][]string, error) {
+	covered := make(map[string]bool)
+	instanceIds := make([]string, 0)
+	for _, group := range stack.Groups {
+		group.Instances = nil
+		if err := c.ResolveGroupInstances(ctx, ec2Client, stack, &group); err != nil {
+			return nil, err
+		}
+		for _, id := range GroupInstanceIds(group) {
+			if !covered[id] {
+				covered[id] = true
+				instanceIds = append(instanceIds, id)
+			}
+		}
+	}
+
+	asgNames := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, chunk := range chunkInstanceIds(instanceIds, maxAutoScalingInstanceIds) {
+		output, err := autoscalingClient.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range output.AutoScalingInstances {
+			if name := aws.ToString(i.AutoScalingGroupName); !seen[name] {
+				seen[name] = true
+				asgNames = append(asgNames, name)
+			}
+		}
+	}
+
+	uncovered := make(map[string][]string)
+	for _, chunk := range chunkInstanceIds(asgNames, maxAutoScalingGroupNames) {
+		output, err := autoscalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range output.AutoScalingGroups {
+			for _, i := range g.Instances {
+				id := aws.ToString(i.InstanceId)
+				if string(i.LifecycleState) == LifecycleStateNameInService && !covered[id] {
+					uncovered[*g.AutoScalingGroupName] = append(uncovered[*g.AutoScalingGroupName], id)
+				}
+			}
+		}
+	}
+
+	for _, ids := range uncovered {
+		sort.Strings(ids)
+	}
+	return uncovered, nil
+}
@@ -0,0 +1,678 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Auto Scaling instance lifecycle states handled by the curator
+const (
+	LifecycleStateNameInService       string = "InService"
+	LifecycleStateNameStandby         string = "Standby"
+	LifecycleStateNamePending         string = "Pending"
+	LifecycleStateNameTerminatingWait string = "Terminating:Wait"
+	LifecycleStateNameDetached        string = "Detached"
+)
+
+// DefaultWaitDuration is the maximum duration of every wait performed by the curator
+const (
+	DefaultWaitDuration time.Duration = 10 * time.Minute
+)
+
+func (c *Curator) describeAutoScalingGroupChanges(ctx context.Context, group types.Group, lifecycleState string) (map[string][]string, []asTypes.AutoScalingGroup, error) {
+	autoscalingInstances := make(map[string][]string)
+	if len(group.Instances) == 0 {
+		return autoscalingInstances, nil, nil
+	}
+
+	autoScalingInstancesOutput, err := c.autoscaling.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: GroupInstanceIds(group),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, i := range autoScalingInstancesOutput.AutoScalingInstances {
+		if *i.LifecycleState == lifecycleState {
+			autoscalingInstances[*i.AutoScalingGroupName] = append(autoscalingInstances[*i.AutoScalingGroupName], *i.InstanceId)
+		}
+	}
+
+	if len(autoscalingInstances) == 0 {
+		c.Printf("No Auto Scaling Groups in instance group %v\n", *group.Name)
+		return autoscalingInstances, nil, nil
+	}
+
+	asgNames := make([]string, 0, len(autoscalingInstances))
+	for k := range autoscalingInstances {
+		asgNames = append(asgNames, k)
+	}
+	c.Printf("Auto Scaling Groups in instance group %v: %v\n", *group.Name, asgNames)
+
+	describeAutoScalingGroupsOutput, err := c.autoscaling.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: asgNames,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return autoscalingInstances, describeAutoScalingGroupsOutput.AutoScalingGroups, nil
+}
+
+// PlanInstanceGroupForShutdown computes the Auto Scaling Group changes required
+// to put the InService instances of the group into Standby without mutating anything.
+//
+// Deprecated: use Curator.PlanGroupShutdown.
+func PlanInstanceGroupForShutdown(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group) ([]types.AutoScalingGroupChange, error) {
+	return defaultCurator(autoscalingClient).PlanGroupShutdown(ctx, group)
+}
+
+// PlanGroupShutdown computes the Auto Scaling Group changes required
+// to put the InService instances of the group into Standby without mutating anything.
+// No changes are planned for the groups skipping the Auto Scaling phase.
+func (c *Curator) PlanGroupShutdown(ctx context.Context, group types.Group) ([]types.AutoScalingGroupChange, error) {
+	if group.SkipAutoScaling {
+		c.Printf("Instance group %v: Auto Scaling Standby is skipped\n", *group.Name)
+		return []types.AutoScalingGroupChange{}, nil
+	}
+
+	defer c.emitPhase(*group.Name, PhaseDescribe, time.Now())
+
+	// only InService instances may be put into Standby
+	autoscalingInstances, autoscalingGroups, err := c.describeAutoScalingGroupChanges(ctx, group, LifecycleStateNameInService)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]types.AutoScalingGroupChange, 0, len(autoscalingGroups))
+	for _, g := range autoscalingGroups {
+		instanceIds, ok := autoscalingInstances[*g.AutoScalingGroupName]
+		if !ok {
+			continue
+		}
+
+		change := types.AutoScalingGroupChange{
+			AutoScalingGroupName: g.AutoScalingGroupName,
+			InstanceIds:          instanceIds,
+			MinSize:              g.MinSize,
+			MaxSize:              g.MaxSize,
+			DesiredCapacity:      g.DesiredCapacity,
+			ProtectedInstanceIds: protectedInstanceIds(g, instanceIds),
+		}
+
+		// the processes suspended before the shutdown are left suspended by the startup
+		if group.SuspendScaling {
+			change.SuspendProcesses = scalingProcesses(g, false)
+		}
+
+		// Update ASG(s) MinSize before a putting into standby
+		if c.adjustSizes && *g.MinSize > 0 {
+			c.warnMixedInstancesPolicy(g, instanceIds)
+			minSize := *g.MinSize - instanceCapacity(g, instanceIds)
+			if minSize < 0 {
+				minSize = 0
+			}
+			change.NewMinSize = aws.Int32(minSize)
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// ApplyInstanceGroupShutdownPlan puts the planned instances into Standby,
+// updating the Auto Scaling Groups as planned by PlanInstanceGroupForShutdown.
+//
+// Deprecated: use Curator.ApplyGroupShutdownPlan.
+func ApplyInstanceGroupShutdownPlan(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group, changes []types.AutoScalingGroupChange) error {
+	return defaultCurator(autoscalingClient).ApplyGroupShutdownPlan(ctx, group, changes)
+}
+
+// ApplyGroupShutdownPlan puts the planned instances into Standby,
+// updating the Auto Scaling Groups as planned by PlanGroupShutdown. Once a change fails or the context
+// is cancelled, the changes already applied are undone, unless the group is configured to continue.
+func (c *Curator) ApplyGroupShutdownPlan(ctx context.Context, group types.Group, changes []types.AutoScalingGroupChange) (err error) {
+	if c.dryRun {
+		c.Printf("Dry run: Auto Scaling Group changes of instance group %v: %v\n", *group.Name, changes)
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseStandby, time.Now())
+
+	restoreScaleInProtection, err := c.disableScaleInProtection(ctx, c.autoscaling, group, changes)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, restoreScaleInProtection())
+	}()
+
+	continueOnFailure := group.OnShutdownFailure != nil && *group.OnShutdownFailure == types.ShutdownFailureContinue
+
+	// the changes applied so far are tracked per ASG, so that they may be undone
+	activities := make([]asTypes.Activity, 0)
+	waitForInstanceIds := make([]string, 0)
+	paused := make([]types.AutoScalingGroupChange, 0, len(changes))
+	lowered := make([]types.AutoScalingGroupChange, 0, len(changes))
+	entered := make([]types.AutoScalingGroupChange, 0, len(changes))
+	var errs []error
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		if err := c.suspendProcesses(ctx, change); err != nil {
+			errs = append(errs, fmt.Errorf("unable to suspend scaling processes of ASG %v: %w", *change.AutoScalingGroupName, err))
+			if continueOnFailure {
+				continue
+			}
+			break
+		}
+		pausedPolicies, err := c.pausePredictiveScaling(ctx, group, change.AutoScalingGroupName)
+		if len(change.SuspendProcesses) > 0 || len(pausedPolicies) > 0 {
+			paused = append(paused, change)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to pause predictive scaling of ASG %v: %w", *change.AutoScalingGroupName, err))
+			if continueOnFailure {
+				continue
+			}
+			break
+		}
+
+		if change.NewMinSize != nil {
+			err := c.retryTransient(ctx, "UpdateAutoScalingGroup of ASG "+*change.AutoScalingGroupName, func() error {
+				_, err := c.autoscaling.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+					AutoScalingGroupName: change.AutoScalingGroupName,
+					MinSize:              change.NewMinSize,
+				})
+				return asgOperationError(change.AutoScalingGroupName, "UpdateAutoScalingGroup", err)
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to update ASG %v: %w", *change.AutoScalingGroupName, err))
+				if continueOnFailure {
+					continue
+				}
+				break
+			}
+			lowered = append(lowered, change)
+		}
+
+		var enterStandbyOutput *autoscaling.EnterStandbyOutput
+		err = c.retryTransient(ctx, "EnterStandby of ASG "+*change.AutoScalingGroupName, func() error {
+			var err error
+			enterStandbyOutput, err = c.autoscaling.EnterStandby(ctx, &autoscaling.EnterStandbyInput{
+				AutoScalingGroupName:           change.AutoScalingGroupName,
+				InstanceIds:                    change.InstanceIds,
+				ShouldDecrementDesiredCapacity: aws.Bool(true),
+			})
+			return asgOperationError(change.AutoScalingGroupName, "EnterStandby", err)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to put instances of ASG %v into Standby: %w", *change.AutoScalingGroupName, err))
+			if continueOnFailure {
+				continue
+			}
+			break
+		}
+		entered = append(entered, change)
+
+		c.Printf("Scaling activities in ASG %v: %v\n", *change.AutoScalingGroupName, enterStandbyOutput.Activities)
+		activities = append(activities, enterStandbyOutput.Activities...)
+		waitForInstanceIds = append(waitForInstanceIds, change.InstanceIds...)
+	}
+
+	defer func() {
+		if err != nil && (ctx.Err() != nil || !continueOnFailure) {
+			err = c.compensateGroupShutdown(ctx, group, paused, lowered, entered, activities, err)
+			return
+		}
+		c.recordShutdownChanges(context.WithoutCancel(ctx), group, lowered)
+	}()
+
+	if len(errs) > 0 && !continueOnFailure {
+		return errors.Join(errs...)
+	}
+	if len(waitForInstanceIds) == 0 {
+		return errors.Join(errs...)
+	}
+	activitiesWaiter := NewScalingActivitiesWaiter(c.autoscaling, func(o *ScalingActivitiesWaiterOptions) {
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.attempts = c.newWaiterAttempts(*group.Name)
+	})
+	if err := activitiesWaiter.Wait(ctx, activities, groupWaitDuration(group, c.waitDuration)); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	standbyWaiter := NewAutoScalingInstanceStandbyWaiter(c.autoscaling, func(o *AutoScalingInstanceLifecycleStateWaiterOptions) {
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.attempts = c.newWaiterAttempts(*group.Name)
+		o.MaxDelay = c.waiterMaxDelay
+		setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+	})
+
+	if output, err := standbyWaiter.WaitForOutput(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: waitForInstanceIds,
+	}, groupWaitDuration(group, c.waitDuration)); err != nil {
+		return errors.Join(append(errs, err)...)
+	} else {
+		c.Printf("Auto Scaling instances in instance group %v: %v\n", *group.Name, output.AutoScalingInstances)
+		c.Emit(Event{Type: EventInstancesEnteredStandby, Group: *group.Name, InstanceIds: waitForInstanceIds})
+	}
+
+	return errors.Join(errs...)
+}
+
+// compensateGroupShutdown undoes the Auto Scaling Group changes applied by a failed shutdown of the group:
+// returns the instances put into Standby to service, restores the lowered MinSize of the Auto Scaling Groups
+// and resumes their paused scaling. The compensation is best-effort and lasts up to CleanupTimeout.
+func (c *Curator) compensateGroupShutdown(ctx context.Context, group types.Group, paused, lowered, entered []types.AutoScalingGroupChange, activities []asTypes.Activity, shutdownErr error) error {
+	if len(paused) == 0 && len(lowered) == 0 && len(entered) == 0 {
+		return shutdownErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CleanupTimeout)
+	defer cancel()
+
+	c.Printf("Instance group %v: shutdown has failed, undoing the Auto Scaling Group changes: %v\n", *group.Name, shutdownErr)
+	var errs []error
+
+	restored := make(map[string]bool)
+	if len(entered) > 0 {
+		// the instances still entering Standby may be returned to service once they are in Standby
+		activitiesWaiter := NewScalingActivitiesWaiter(c.autoscaling, func(o *ScalingActivitiesWaiterOptions) {
+			o.LogWaitAttempts = c.logWaitAttempts()
+			o.attempts = c.newWaiterAttempts(*group.Name)
+		})
+		if err := activitiesWaiter.Wait(ctx, activities, CleanupTimeout); err != nil {
+			errs = append(errs, err)
+		}
+
+		startupChanges, err := c.PlanGroupStartup(ctx, group)
+		if err == nil {
+			startupChanges = changedInstances(startupChanges, entered)
+			RestoreMinSizes(startupChanges, lowered)
+			err = c.ApplyGroupStartupPlan(ctx, group, startupChanges)
+			for _, change := range startupChanges {
+				restored[*change.AutoScalingGroupName] = true
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, change := range lowered {
+		if restored[*change.AutoScalingGroupName] {
+			continue
+		}
+		if err := c.retryTransient(ctx, "UpdateAutoScalingGroup of ASG "+*change.AutoScalingGroupName, func() error {
+			_, err := c.autoscaling.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: change.AutoScalingGroupName,
+				MinSize:              change.MinSize,
+			})
+			return asgOperationError(change.AutoScalingGroupName, "UpdateAutoScalingGroup", err)
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, change := range paused {
+		if restored[*change.AutoScalingGroupName] {
+			continue
+		}
+		if err := c.resumeProcesses(ctx, change.AutoScalingGroupName, change.SuspendProcesses); err != nil {
+			errs = append(errs, err)
+		}
+		if err := c.resumePredictiveScaling(ctx, group, change.AutoScalingGroupName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(shutdownErr, fmt.Errorf("unable to undo the Auto Scaling Group changes of instance group %v: %w", *group.Name, errors.Join(errs...)))
+	}
+	c.Printf("Instance group %v: Auto Scaling Group changes have been undone\n", *group.Name)
+	return shutdownErr
+}
+
+// changedInstances keeps the startup changes of the instances of the applied shutdown changes only
+func changedInstances(startup, applied []types.AutoScalingGroupChange) []types.AutoScalingGroupChange {
+	changed := make(map[string]bool)
+	for _, change := range applied {
+		for _, id := range change.InstanceIds {
+			changed[id] = true
+		}
+	}
+
+	kept := make([]types.AutoScalingGroupChange, 0, len(startup))
+	for _, change := range startup {
+		instanceIds := make([]string, 0, len(change.InstanceIds))
+		for _, id := range change.InstanceIds {
+			if changed[id] {
+				instanceIds = append(instanceIds, id)
+			}
+		}
+		if len(instanceIds) == 0 {
+			continue
+		}
+		change.InstanceIds = instanceIds
+		kept = append(kept, change)
+	}
+	return kept
+}
+
+// PrepareInstanceGroupForShutdown puts the InService Auto Scaling instances of the group into Standby,
+// lowering the MinSize of their Auto Scaling Groups when required. The options control the waits,
+// the logging, the size adjustment and the dry run as they do for a Curator constructed with New.
+func PrepareInstanceGroupForShutdown(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group, optFns ...Option) error {
+	return defaultCurator(autoscalingClient, optFns...).PrepareGroupForShutdown(ctx, group)
+}
+
+// PrepareGroupForShutdown puts the InService Auto Scaling instances of the group into Standby,
+// lowering the MinSize of their Auto Scaling Groups when required.
+func (c *Curator) PrepareGroupForShutdown(ctx context.Context, group types.Group) error {
+	changes, err := c.PlanGroupShutdown(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	return c.ApplyGroupShutdownPlan(ctx, group, changes)
+}
+
+// PlanInstanceGroupForStartup computes the Auto Scaling Group changes required
+// to return the Standby instances of the group to service without mutating anything.
+//
+// Deprecated: use Curator.PlanGroupStartup.
+func PlanInstanceGroupForStartup(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group) ([]types.AutoScalingGroupChange, error) {
+	return defaultCurator(autoscalingClient).PlanGroupStartup(ctx, group)
+}
+
+// PlanGroupStartup computes the Auto Scaling Group changes required
+// to return the Standby instances of the group to service without mutating anything.
+// No changes are planned for the groups skipping the Auto Scaling phase.
+func (c *Curator) PlanGroupStartup(ctx context.Context, group types.Group) ([]types.AutoScalingGroupChange, error) {
+	if group.SkipAutoScaling {
+		c.Printf("Instance group %v: Auto Scaling return to service is skipped\n", *group.Name)
+		return []types.AutoScalingGroupChange{}, nil
+	}
+
+	defer c.emitPhase(*group.Name, PhaseDescribe, time.Now())
+
+	// only Standby instances may be put into InService
+	autoscalingInstances, autoscalingGroups, err := c.describeAutoScalingGroupChanges(ctx, group, LifecycleStateNameStandby)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]types.AutoScalingGroupChange, 0, len(autoscalingGroups))
+	for _, g := range autoscalingGroups {
+		instanceIds, ok := autoscalingInstances[*g.AutoScalingGroupName]
+		if !ok {
+			continue
+		}
+
+		change := types.AutoScalingGroupChange{
+			AutoScalingGroupName: g.AutoScalingGroupName,
+			InstanceIds:          instanceIds,
+			MinSize:              g.MinSize,
+			MaxSize:              g.MaxSize,
+			DesiredCapacity:      g.DesiredCapacity,
+			ProtectedInstanceIds: protectedInstanceIds(g, instanceIds),
+			TargetGroupARNs:      g.TargetGroupARNs,
+		}
+
+		if group.SuspendScaling {
+			change.ResumeProcesses = scalingProcesses(g, true)
+		}
+
+		if c.adjustSizes {
+			c.warnMixedInstancesPolicy(g, instanceIds)
+		}
+
+		// Update ASG(s) MaxSize before a returning an instance to service
+		if maxSize := groupCapacity(g); c.adjustSizes && *g.MaxSize < maxSize {
+			change.NewMaxSize = aws.Int32(maxSize)
+		}
+
+		// Update ASG(s) MinSize after a returning an instance to service
+		if minSize := instanceCapacity(g, instanceIds); c.adjustSizes && *g.MinSize < minSize {
+			change.NewMinSize = aws.Int32(minSize)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if c.adjustSizes {
+		c.restoreRecordedMinSizes(ctx, group, changes)
+	}
+	return changes, nil
+}
+
+// RestoreMinSizes updates the startup changes to restore the MinSize of the Auto Scaling Groups
+// lowered by the shutdown changes, as startup only raises it up to the number of returned instances.
+func RestoreMinSizes(startup, shutdown []types.AutoScalingGroupChange) {
+	for i, c := range startup {
+		for _, s := range shutdown {
+			if *s.AutoScalingGroupName != *c.AutoScalingGroupName || s.NewMinSize == nil {
+				continue
+			}
+
+			if *c.MinSize < *s.MinSize && (c.NewMinSize == nil || *c.NewMinSize < *s.MinSize) {
+				startup[i].NewMinSize = aws.Int32(*s.MinSize)
+			}
+		}
+	}
+}
+
+// ApplyInstanceGroupStartupPlan returns the planned instances to service,
+// updating the Auto Scaling Groups as planned by PlanInstanceGroupForStartup.
+//
+// Deprecated: use Curator.ApplyGroupStartupPlan.
+func ApplyInstanceGroupStartupPlan(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group, changes []types.AutoScalingGroupChange) error {
+	return defaultCurator(autoscalingClient).ApplyGroupStartupPlan(ctx, group, changes)
+}
+
+// ApplyGroupStartupPlan returns the planned instances to service,
+// updating the Auto Scaling Groups as planned by PlanGroupStartup.
+func (c *Curator) ApplyGroupStartupPlan(ctx context.Context, group types.Group, changes []types.AutoScalingGroupChange) (err error) {
+	if c.dryRun {
+		c.Printf("Dry run: Auto Scaling Group changes of instance group %v: %v\n", *group.Name, changes)
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseInService, time.Now())
+
+	restoreScaleInProtection, err := c.disableScaleInProtection(ctx, c.autoscaling, group, changes)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, restoreScaleInProtection())
+	}()
+
+	// the Auto Scaling Groups failing to return to service do not stop the others, their errors are aggregated
+	errs := make([]error, 0)
+	failed := make(map[string]bool)
+	activities := make([]asTypes.Activity, 0)
+	waitForInstanceIds := make([]string, 0)
+	for _, change := range changes {
+		if change.NewMaxSize != nil {
+			err := c.retryTransient(ctx, "UpdateAutoScalingGroup of ASG "+*change.AutoScalingGroupName, func() error {
+				_, err := c.autoscaling.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+					AutoScalingGroupName: change.AutoScalingGroupName,
+					MaxSize:              change.NewMaxSize,
+				})
+				return asgOperationError(change.AutoScalingGroupName, "UpdateAutoScalingGroup", err)
+			})
+			if err != nil {
+				errs = append(errs, err)
+				failed[*change.AutoScalingGroupName] = true
+				continue
+			}
+		}
+
+		var exitStandbyOutput *autoscaling.ExitStandbyOutput
+		err := c.retryTransient(ctx, "ExitStandby of ASG "+*change.AutoScalingGroupName, func() error {
+			var err error
+			exitStandbyOutput, err = c.autoscaling.ExitStandby(ctx, &autoscaling.ExitStandbyInput{
+				AutoScalingGroupName: change.AutoScalingGroupName,
+				InstanceIds:          change.InstanceIds,
+			})
+			return asgOperationError(change.AutoScalingGroupName, "ExitStandby", err)
+		})
+		if err != nil {
+			errs = append(errs, err)
+			failed[*change.AutoScalingGroupName] = true
+			continue
+		}
+
+		c.Printf("Scaling activities in ASG %v: %v\n", *change.AutoScalingGroupName, exitStandbyOutput.Activities)
+		activities = append(activities, exitStandbyOutput.Activities...)
+		waitForInstanceIds = append(waitForInstanceIds, change.InstanceIds...)
+	}
+
+	if len(waitForInstanceIds) == 0 {
+		return errors.Join(errs...)
+	}
+	activitiesWaiter := NewScalingActivitiesWaiter(c.autoscaling, func(o *ScalingActivitiesWaiterOptions) {
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.attempts = c.newWaiterAttempts(*group.Name)
+	})
+	if err := activitiesWaiter.Wait(ctx, activities, groupWaitDuration(group, c.waitDuration)); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	inServiceWaiter := NewAutoScalingInstanceInServiceWaiter(c.autoscaling, func(o *AutoScalingInstanceLifecycleStateWaiterOptions) {
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.attempts = c.newWaiterAttempts(*group.Name)
+		o.MaxDelay = c.waiterMaxDelay
+		setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+	})
+
+	if output, err := inServiceWaiter.WaitForOutput(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: waitForInstanceIds,
+	}, groupWaitDuration(group, c.waitDuration)); err != nil {
+		return errors.Join(append(errs, err)...)
+	} else {
+		c.Printf("Auto Scaling instances in instance group %v: %v\n", *group.Name, output.AutoScalingInstances)
+		c.Emit(Event{Type: EventInstancesReturnedToService, Group: *group.Name, InstanceIds: waitForInstanceIds})
+	}
+
+	// Update ASG(s) MinSize after a returning an instance to service
+	for _, change := range changes {
+		if change.NewMinSize == nil || failed[*change.AutoScalingGroupName] {
+			continue
+		}
+
+		err := c.retryTransient(ctx, "UpdateAutoScalingGroup of ASG "+*change.AutoScalingGroupName, func() error {
+			_, err := c.autoscaling.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: change.AutoScalingGroupName,
+				MinSize:              change.NewMinSize,
+			})
+			return asgOperationError(change.AutoScalingGroupName, "UpdateAutoScalingGroup", err)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// the scaling is resumed once the instances are back in service and the sizes restored
+	for _, change := range changes {
+		if failed[*change.AutoScalingGroupName] {
+			continue
+		}
+		if err := c.resumeProcesses(ctx, change.AutoScalingGroupName, change.ResumeProcesses); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := c.resumePredictiveScaling(ctx, group, change.AutoScalingGroupName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// the recorded sizes are kept for the next startup to restore the failed changes
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	c.forgetShutdownChanges(ctx, group)
+
+	if group.WaitTargetHealth {
+		return c.waitForAutoScalingTargetHealth(ctx, group, changes)
+	}
+
+	return nil
+}
+
+// PrepareInstanceGroupForStartup returns the Standby Auto Scaling instances of the group to service,
+// raising the MaxSize and MinSize of their Auto Scaling Groups when required. The options control the waits,
+// the logging, the size adjustment and the dry run as they do for a Curator constructed with New.
+func PrepareInstanceGroupForStartup(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group, optFns ...Option) error {
+	return defaultCurator(autoscalingClient, optFns...).PrepareGroupForStartup(ctx, group)
+}
+
+// PrepareGroupForStartup returns the Standby Auto Scaling instances of the group to service,
+// raising the MaxSize and MinSize of their Auto Scaling Groups when required.
+func (c *Curator) PrepareGroupForStartup(ctx context.Context, group types.Group) error {
+	changes, err := c.PlanGroupStartup(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	return c.ApplyGroupStartupPlan(ctx, group, changes)
+}
+
+// returnGroupToService returns the Standby instances of the group to service following the startup failure
+// policy of the group: the failure fails the startup unless the policy retries or continues the startup
+func (c *Curator) returnGroupToService(ctx context.Context, group types.Group) error {
+	action := types.StartupFailureFail
+	retries := 0
+	interval := 30 * time.Second
+	if policy := group.OnStartupFailure; policy != nil {
+		if policy.Action != nil {
+			action = *policy.Action
+		}
+		if action == types.StartupFailureRetry {
+			retries = 3
+			if policy.Retries != nil {
+				retries = *policy.Retries
+			}
+		}
+		if policy.Interval != nil {
+			interval = *policy.Interval
+		}
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		// the startup is planned again, so that only the instances still in Standby are retried
+		if err = c.PrepareGroupForStartup(ctx, group); err == nil {
+			return nil
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			break
+		}
+
+		c.Printf("Instance group %v: unable to return instances to service, retrying in %v: %v\n", *group.Name, interval.String(), err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	err = fmt.Errorf("unable to return instances of instance group %v to service: %w", *group.Name, err)
+	if action == types.StartupFailureContinue {
+		c.Printf("Warning: %v, continuing the startup\n", err)
+		return nil
+	}
+	return err
+}
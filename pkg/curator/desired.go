@@ -0,0 +1,55 @@
+package curator
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// GroupInDesiredState reports whether the resolved group is already in the state the shutdown or the startup
+// brings it to, so that reruns after partial failures may skip it: for the shutdown every instance is stopped,
+// for the startup every instance is running and none of the Auto Scaling instances is out of service.
+// The groups with clusters or skipping the EC2 phase are never considered in the desired state.
+func (c *Curator) GroupInDesiredState(ctx context.Context, group types.Group, action types.Action) (bool, error) {
+	if len(group.Instances) == 0 || len(group.Clusters) > 0 || group.SkipEC2 {
+		return false, nil
+	}
+
+	state := ec2Types.InstanceStateNameStopped
+	if action == types.ActionStartup {
+		state = ec2Types.InstanceStateNameRunning
+	}
+	for _, i := range group.Instances {
+		if i.State == nil || i.State.Name != state {
+			return false, nil
+		}
+	}
+
+	if action != types.ActionStartup {
+		c.Printf("Instance group %v: skipped, instances are already stopped\n", *group.Name)
+		return true, nil
+	}
+
+	if !group.SkipAutoScaling {
+		for _, chunk := range chunkInstanceIds(GroupInstanceIds(group), maxAutoScalingInstanceIds) {
+			output, err := c.autoscaling.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+				InstanceIds: chunk,
+			})
+			if err != nil {
+				return false, err
+			}
+			for _, i := range output.AutoScalingInstances {
+				if aws.ToString(i.LifecycleState) != LifecycleStateNameInService {
+					return false, nil
+				}
+			}
+		}
+	}
+
+	c.Printf("Instance group %v: skipped, instances are already running and in service\n", *group.Name)
+	return true, nil
+}
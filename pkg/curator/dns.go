@@ -0,0 +1,162 @@
+package curator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53Types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DefaultDNSRecordTTL is the TTL of the upserted records without an override
+const DefaultDNSRecordTTL int64 = 60
+
+// UpsertInstanceGroupRecords points the DNS records of the group at the private IPs of all the running
+// group instances, including the members of a rolling group outside the started batch,
+// and waits until the changes are propagated to the Route53 name servers.
+func UpsertInstanceGroupRecords(ctx context.Context, route53Client Route53API, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	return packageCurator().upsertInstanceGroupRecords(ctx, route53Client, ec2Client, group, instanceIds)
+}
+
+// upsertInstanceGroupRecords is UpsertInstanceGroupRecords with the output and the wait duration of the Curator
+func (c *Curator) upsertInstanceGroupRecords(ctx context.Context, route53Client Route53API, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	if len(group.DNSRecords) == 0 || len(instanceIds) == 0 {
+		return nil
+	}
+
+	allInstanceIds := slices.Clone(instanceIds)
+	for _, instances := range [][]ec2Types.Instance{group.Instances, group.AllInstances} {
+		for _, i := range instances {
+			if !slices.Contains(allInstanceIds, *i.InstanceId) {
+				allInstanceIds = append(allInstanceIds, *i.InstanceId)
+			}
+		}
+	}
+
+	ips := make([]string, 0, len(allInstanceIds))
+	paginator := ec2.NewDescribeInstancesPaginator(ec2Client, &ec2.DescribeInstancesInput{
+		InstanceIds: allInstanceIds,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range output.Reservations {
+			for _, i := range r.Instances {
+				if i.State != nil && i.State.Name == ec2Types.InstanceStateNameRunning && i.PrivateIpAddress != nil {
+					ips = append(ips, *i.PrivateIpAddress)
+				}
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("no running instances of instance group %v to point the DNS records at", *group.Name)
+	}
+	sort.Strings(ips)
+
+	if err := c.changeInstanceGroupRecords(ctx, route53Client, group, group.DNSRecords, func(types.DNSRecord) []string { return ips }); err != nil {
+		return err
+	}
+	c.Printf("Instance group %v: DNS records point at %v\n", *group.Name, ips)
+	return nil
+}
+
+// DowngradeInstanceGroupRecords points the DNS records of the group with maintenance values
+// at their maintenance target before the group instances are stopped. The records are kept
+// while only a subset of the group instances is stopped, e.g. a batch of a rolling group.
+func DowngradeInstanceGroupRecords(ctx context.Context, route53Client Route53API, group types.Group) error {
+	return packageCurator().downgradeInstanceGroupRecords(ctx, route53Client, group)
+}
+
+// downgradeInstanceGroupRecords is DowngradeInstanceGroupRecords with the output and the wait duration of the Curator
+func (c *Curator) downgradeInstanceGroupRecords(ctx context.Context, route53Client Route53API, group types.Group) error {
+	if group.AllInstances != nil {
+		return nil
+	}
+
+	records := make([]types.DNSRecord, 0, len(group.DNSRecords))
+	for _, r := range group.DNSRecords {
+		if len(r.MaintenanceValues) > 0 {
+			records = append(records, r)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := c.changeInstanceGroupRecords(ctx, route53Client, group, records, func(r types.DNSRecord) []string { return r.MaintenanceValues }); err != nil {
+		return err
+	}
+	c.Printf("Instance group %v: DNS records point at their maintenance targets\n", *group.Name)
+	return nil
+}
+
+// changeInstanceGroupRecords upserts the A records with the values, in a single change batch per hosted zone,
+// and waits until the changes are propagated
+func (c *Curator) changeInstanceGroupRecords(ctx context.Context, route53Client Route53API, group types.Group, records []types.DNSRecord, values func(types.DNSRecord) []string) error {
+	zones := make([]string, 0)
+	changes := make(map[string][]route53Types.Change)
+	for _, r := range records {
+		resourceRecords := make([]route53Types.ResourceRecord, 0)
+		for _, v := range values(r) {
+			resourceRecords = append(resourceRecords, route53Types.ResourceRecord{Value: aws.String(v)})
+		}
+
+		ttl := DefaultDNSRecordTTL
+		if r.TTL != nil {
+			ttl = *r.TTL
+		}
+		zone := *r.HostedZoneId
+		if _, ok := changes[zone]; !ok {
+			zones = append(zones, zone)
+		}
+		changes[zone] = append(changes[zone], route53Types.Change{
+			Action: route53Types.ChangeActionUpsert,
+			ResourceRecordSet: &route53Types.ResourceRecordSet{
+				Name:            r.Name,
+				Type:            route53Types.RRTypeA,
+				TTL:             aws.Int64(ttl),
+				ResourceRecords: resourceRecords,
+			},
+		})
+	}
+
+	attempts := c.newWaiterAttempts(*group.Name)
+	waiter := route53.NewResourceRecordSetsChangedWaiter(route53Client, func(o *route53.ResourceRecordSetsChangedWaiterOptions) {
+		o.APIOptions = append(o.APIOptions, attempts.addCounter)
+		o.LogWaitAttempts = c.logWaitAttempts()
+	})
+	for _, zone := range zones {
+		output, err := route53Client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(zone),
+			ChangeBatch: &route53Types.ChangeBatch{
+				Changes: changes[zone],
+				Comment: aws.String(fmt.Sprintf("instance-stack-curator: instance group %v", *group.Name)),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to change the DNS records of instance group %v in hosted zone %v: %w", *group.Name, zone, err)
+		}
+
+		attempts.reset()
+		deadline := time.Now().Add(c.waitDuration)
+		err = waiter.Wait(ctx, &route53.GetChangeInput{Id: output.ChangeInfo.Id}, c.waitDuration)
+		attempts.emit()
+		if err != nil {
+			if sdkWaiterTimedOut(ctx, deadline, err) {
+				return &WaiterTimeoutError{Waiter: fmt.Sprintf("ResourceRecordSetsChanged waiter of instance group %v in hosted zone %v", *group.Name, zone)}
+			}
+			return err
+		}
+	}
+	return nil
+}
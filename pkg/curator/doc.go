@@ -0,0 +1,24 @@
+// Package curator implements the orchestration of ASG based stacks of EC2 instances.
+//
+// A stack is processed group by group in the order returned by OrderGroups:
+//
+//   - ResolveGroupInstances resolves the group instances using the stack and group filters;
+//   - PrepareInstanceGroupForShutdown puts the Auto Scaling instances into Standby
+//     before StopInstanceGroup stops them;
+//   - StartInstanceGroup starts the instances before PrepareInstanceGroupForStartup
+//     returns the Auto Scaling instances to service.
+//
+// A Curator, constructed with New and the With* options, holds the clients and settings
+// of the orchestration: ShutdownGroup and StartupGroup run all the steps of a group,
+// calling the configured Hooks along the way, and the Plan* and Apply* methods split
+// the Auto Scaling Group orchestration into a read-only planning step and an execution
+// of the planned changes.
+//
+// The package also provides waiters for Auto Scaling instance lifecycle states
+// modeled after the waiters generated by the AWS SDK.
+//
+// The failures may be told apart with errors.As: a WaiterTimeoutError is returned by the waits
+// exceeding their maximum duration, a GroupResolutionError when the group instances cannot be resolved
+// and an ASGOperationError when an Auto Scaling Group operation or its scaling activity fails.
+// Independent failures are joined, Failures splits them with their group, ASG and instance context.
+package curator
@@ -0,0 +1,83 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// maxECSServices is the maximum number of services of a single DescribeServices request
+const maxECSServices = 10
+
+// ECSAPI is the subset of the ECS client operations used by the curator.
+type ECSAPI interface {
+	DescribeServices(context.Context, *ecs.DescribeServicesInput, ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error)
+}
+
+// WaitForGroupECSServices waits until the ECS services of the group reach a steady state,
+// once the instances providing their capacity are started, so that the workloads have been rescheduled.
+func WaitForGroupECSServices(ctx context.Context, ecsClient ECSAPI, group types.Group) error {
+	return packageCurator().waitForGroupECSServices(ctx, ecsClient, group)
+}
+
+// waitForGroupECSServices is WaitForGroupECSServices with the output and the wait duration of the Curator
+func (c *Curator) waitForGroupECSServices(ctx context.Context, ecsClient ECSAPI, group types.Group) error {
+	if group.ECSServices == nil {
+		return nil
+	}
+	if ecsClient == nil {
+		return errors.New("an ECS client is required to wait for the services")
+	}
+
+	timeout := c.waitDuration
+	if group.ECSServices.Timeout != nil {
+		timeout = *group.ECSServices.Timeout
+	}
+
+	c.Printf("Instance group %v: waiting for ECS services %v of cluster %v to be stable\n", *group.Name, group.ECSServices.Services, *group.ECSServices.Cluster)
+	attempts := c.newWaiterAttempts(*group.Name)
+	waiter := ecs.NewServicesStableWaiter(ecsClient, func(o *ecs.ServicesStableWaiterOptions) {
+		o.APIOptions = append(o.APIOptions, attempts.addCounter)
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.MaxDelay = time.Minute
+	})
+	deadline := time.Now().Add(timeout)
+	chunks := chunkInstanceIds(group.ECSServices.Services, maxECSServices)
+	for i, chunk := range chunks {
+		// the services left once the deadline has passed are not awaited at all
+		if time.Until(deadline) <= 0 {
+			return servicesWaiterTimeoutError(group, chunks[i:])
+		}
+		attempts.reset()
+		err := waiter.Wait(ctx, &ecs.DescribeServicesInput{
+			Cluster:  group.ECSServices.Cluster,
+			Services: chunk,
+		}, time.Until(deadline))
+		attempts.emit()
+		if err != nil {
+			if sdkWaiterTimedOut(ctx, deadline, err) {
+				return servicesWaiterTimeoutError(group, chunks[i:i+1])
+			}
+			return err
+		}
+	}
+
+	c.Printf("Instance group %v: ECS services %v are stable\n", *group.Name, group.ECSServices.Services)
+	return nil
+}
+
+// servicesWaiterTimeoutError returns the timeout of the ServicesStable waiter on the ECS services of the chunks
+func servicesWaiterTimeoutError(group types.Group, chunks [][]string) error {
+	services := make([]string, 0)
+	for _, chunk := range chunks {
+		services = append(services, chunk...)
+	}
+	return &WaiterTimeoutError{
+		Waiter: fmt.Sprintf("ServicesStable waiter of ECS services %v of instance group %v", services, *group.Name),
+	}
+}
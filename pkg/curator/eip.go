@@ -0,0 +1,238 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ElasticIPsTag records the Elastic IP associations of an instance at the shutdown, re-associated after the startup
+const ElasticIPsTag string = DefaultRunTagsPrefix + "elastic-ips"
+
+// EC2AddressesAPI is the subset of the EC2 client operations recording and re-associating the Elastic IPs.
+type EC2AddressesAPI interface {
+	ec2.DescribeInstancesAPIClient
+	EC2TagsAPI
+
+	DescribeAddresses(context.Context, *ec2.DescribeAddressesInput, ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error)
+	AssociateAddress(context.Context, *ec2.AssociateAddressInput, ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error)
+}
+
+// ElasticIPAssociation is an Elastic IP association of an instance recorded in the ElasticIPsTag
+type ElasticIPAssociation struct {
+	AllocationId       string
+	NetworkInterfaceId string
+	PrivateIpAddress   string
+}
+
+func (a ElasticIPAssociation) String() string {
+	return a.AllocationId + "/" + a.NetworkInterfaceId + "/" + a.PrivateIpAddress
+}
+
+// parseElasticIPAssociations parses the value of the ElasticIPsTag
+func parseElasticIPAssociations(value string) ([]ElasticIPAssociation, error) {
+	associations := make([]ElasticIPAssociation, 0)
+	for _, s := range strings.Split(value, ",") {
+		parts := strings.Split(s, "/")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid Elastic IP association %q", s)
+		}
+		associations = append(associations, ElasticIPAssociation{AllocationId: parts[0], NetworkInterfaceId: parts[1], PrivateIpAddress: parts[2]})
+	}
+	return associations, nil
+}
+
+// describeInstanceAddresses returns the Elastic IP addresses associated with the instances by the instance ID
+func describeInstanceAddresses(ctx context.Context, ec2Client EC2AddressesAPI, instanceIds []string) (map[string][]ec2Types.Address, error) {
+	addresses := make(map[string][]ec2Types.Address)
+	for _, chunk := range chunkInstanceIds(instanceIds, maxEC2InstanceIds) {
+		output, err := ec2Client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+			Filters: []ec2Types.Filter{{Name: aws.String("instance-id"), Values: chunk}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range output.Addresses {
+			// only the VPC addresses are re-associated by their allocation ID
+			if a.AllocationId != nil && a.InstanceId != nil {
+				addresses[*a.InstanceId] = append(addresses[*a.InstanceId], a)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// RecordInstanceGroupElasticIPs records the Elastic IP associations of the group instances in their
+// ElasticIPsTag before they are stopped, removing the tag of the instances without any, if enabled for the group.
+func RecordInstanceGroupElasticIPs(ctx context.Context, ec2Client EC2AddressesAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().recordInstanceGroupElasticIPs(ctx, ec2Client, group, instanceIds)
+}
+
+// recordInstanceGroupElasticIPs is RecordInstanceGroupElasticIPs with the output and the wait duration of the Curator
+func (c *Curator) recordInstanceGroupElasticIPs(ctx context.Context, ec2Client EC2AddressesAPI, group types.Group, instanceIds []string) error {
+	if !group.ElasticIPs || len(instanceIds) == 0 {
+		return nil
+	}
+
+	addresses, err := describeInstanceAddresses(ctx, ec2Client, instanceIds)
+	if err != nil {
+		return err
+	}
+
+	unassociated := make([]string, 0)
+	for _, id := range instanceIds {
+		if len(addresses[id]) == 0 {
+			unassociated = append(unassociated, id)
+			continue
+		}
+
+		values := make([]string, 0, len(addresses[id]))
+		for _, a := range addresses[id] {
+			values = append(values, ElasticIPAssociation{
+				AllocationId:       aws.ToString(a.AllocationId),
+				NetworkInterfaceId: aws.ToString(a.NetworkInterfaceId),
+				PrivateIpAddress:   aws.ToString(a.PrivateIpAddress),
+			}.String())
+		}
+		sort.Strings(values)
+		if _, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{id},
+			Tags:      []ec2Types.Tag{{Key: aws.String(ElasticIPsTag), Value: aws.String(strings.Join(values, ","))}},
+		}); err != nil {
+			return &InstanceError{InstanceIds: []string{id}, Err: err}
+		}
+		c.Printf("Instance group %v: Elastic IP associations of instance %v have been recorded: %v\n", *group.Name, id, values)
+	}
+
+	for _, chunk := range chunkInstanceIds(unassociated, maxEC2InstanceIds) {
+		if _, err := ec2Client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+			Resources: chunk,
+			Tags:      []ec2Types.Tag{{Key: aws.String(ElasticIPsTag)}},
+		}); err != nil {
+			return &InstanceError{InstanceIds: chunk, Err: err}
+		}
+	}
+	return nil
+}
+
+// ReassociateInstanceGroupElasticIPs verifies the Elastic IP associations of the started group instances
+// recorded by RecordInstanceGroupElasticIPs, re-associating the unassociated Elastic IPs, if enabled for the group.
+// It fails when an Elastic IP is associated elsewhere or remains unassociated.
+func ReassociateInstanceGroupElasticIPs(ctx context.Context, ec2Client EC2AddressesAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().reassociateInstanceGroupElasticIPs(ctx, ec2Client, group, instanceIds)
+}
+
+// reassociateInstanceGroupElasticIPs is ReassociateInstanceGroupElasticIPs with the output and the wait duration of the Curator
+func (c *Curator) reassociateInstanceGroupElasticIPs(ctx context.Context, ec2Client EC2AddressesAPI, group types.Group, instanceIds []string) error {
+	if !group.ElasticIPs || len(instanceIds) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseHealth, time.Now())
+
+	expected := make(map[string][]ElasticIPAssociation)
+	paginator := ec2.NewDescribeInstancesPaginator(ec2Client, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIds,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range output.Reservations {
+			for _, i := range r.Instances {
+				for _, t := range i.Tags {
+					if aws.ToString(t.Key) != ElasticIPsTag {
+						continue
+					}
+					associations, err := parseElasticIPAssociations(aws.ToString(t.Value))
+					if err != nil {
+						return &InstanceError{InstanceIds: []string{*i.InstanceId}, Err: err}
+					}
+					expected[*i.InstanceId] = associations
+				}
+			}
+		}
+	}
+	if len(expected) == 0 {
+		return nil
+	}
+
+	allocationIds := make([]string, 0)
+	for _, associations := range expected {
+		for _, a := range associations {
+			allocationIds = append(allocationIds, a.AllocationId)
+		}
+	}
+	// the addresses are filtered rather than requested by their allocation IDs, so that a released one
+	// fails the re-association of its instance only instead of the whole lookup
+	addresses := make(map[string]ec2Types.Address, len(allocationIds))
+	for _, chunk := range chunkInstanceIds(allocationIds, maxEC2InstanceIds) {
+		output, err := ec2Client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+			Filters: []ec2Types.Filter{{Name: aws.String("allocation-id"), Values: chunk}},
+		})
+		if err != nil {
+			return err
+		}
+		for _, a := range output.Addresses {
+			addresses[aws.ToString(a.AllocationId)] = a
+		}
+	}
+
+	instanceIdsWithAssociations := make([]string, 0, len(expected))
+	for id := range expected {
+		instanceIdsWithAssociations = append(instanceIdsWithAssociations, id)
+	}
+	sort.Strings(instanceIdsWithAssociations)
+
+	errs := make([]error, 0)
+	for _, id := range instanceIdsWithAssociations {
+		for _, a := range expected[id] {
+			if err := c.reassociateElasticIP(ctx, ec2Client, group, id, a, addresses[a.AllocationId]); err != nil {
+				errs = append(errs, &InstanceError{InstanceIds: []string{id}, Err: err})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reassociateElasticIP associates the Elastic IP address with the instance as recorded, unless it is already
+func (c *Curator) reassociateElasticIP(ctx context.Context, ec2Client EC2AddressesAPI, group types.Group, instanceId string, association ElasticIPAssociation, address ec2Types.Address) error {
+	switch {
+	case address.AllocationId == nil:
+		return fmt.Errorf("Elastic IP %v does not exist", association.AllocationId)
+	case aws.ToString(address.InstanceId) == instanceId:
+		return nil
+	case address.AssociationId != nil:
+		associatedWith := aws.ToString(address.InstanceId)
+		if associatedWith == "" {
+			associatedWith = aws.ToString(address.NetworkInterfaceId)
+		}
+		return fmt.Errorf("Elastic IP %v is associated with %v rather than instance %v", association.AllocationId, associatedWith, instanceId)
+	}
+
+	input := &ec2.AssociateAddressInput{
+		AllocationId: aws.String(association.AllocationId),
+	}
+	if association.NetworkInterfaceId != "" {
+		input.NetworkInterfaceId = aws.String(association.NetworkInterfaceId)
+		input.PrivateIpAddress = aws.String(association.PrivateIpAddress)
+	} else {
+		input.InstanceId = aws.String(instanceId)
+	}
+	if _, err := ec2Client.AssociateAddress(ctx, input); err != nil {
+		return fmt.Errorf("unable to re-associate Elastic IP %v: %w", association.AllocationId, err)
+	}
+
+	c.Printf("Instance group %v: Elastic IP %v has been re-associated with instance %v\n", *group.Name, aws.ToString(address.PublicIp), instanceId)
+	c.Emit(Event{Type: EventElasticIPReassociated, Group: *group.Name, InstanceIds: []string{instanceId}, Message: association.AllocationId})
+	return nil
+}
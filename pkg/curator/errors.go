@@ -0,0 +1,176 @@
+package curator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+)
+
+// ErrWaitTimeout is matched with errors.Is by the errors of the waits exceeding their maximum duration
+var ErrWaitTimeout = errors.New("exceeded max wait time")
+
+// WaiterTimeoutError is returned by a wait exceeding its maximum duration.
+// It matches ErrWaitTimeout with errors.Is.
+type WaiterTimeoutError struct {
+	// Waiter describes the wait, e.g. AutoScalingInstance InService lifecycle state waiter
+	Waiter string
+
+	// The instances waited for, if the wait is of instances
+	InstanceIds []string
+
+	// The states the waited instances or resources were last seen in, by the instance ID or the resource name, if known
+	LastStates map[string]string
+}
+
+func (e *WaiterTimeoutError) Error() string {
+	message := "exceeded max wait time for " + e.Waiter
+	switch {
+	case len(e.LastStates) > 0:
+		message += fmt.Sprintf(", last states %v", e.LastStates)
+	case len(e.InstanceIds) > 0:
+		message += fmt.Sprintf(" on instances %v", e.InstanceIds)
+	}
+	return message
+}
+
+func (e *WaiterTimeoutError) Unwrap() error {
+	return ErrWaitTimeout
+}
+
+// GroupResolutionError is returned when the instances of a group cannot be resolved
+type GroupResolutionError struct {
+	// Name of the instance group
+	Group string
+
+	// The error resolving the instances
+	Err error
+}
+
+func (e *GroupResolutionError) Error() string {
+	return fmt.Sprintf("unable to resolve instances of instance group %v: %v", e.Group, e.Err)
+}
+
+func (e *GroupResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// ASGOperationError is returned when an operation on an Auto Scaling Group fails,
+// or when a scaling activity started by the operation fails or is cancelled
+type ASGOperationError struct {
+	// Name of the Auto Scaling Group
+	AutoScalingGroupName string
+
+	// The Auto Scaling API operation, e.g. EnterStandby, empty for a failed scaling activity
+	Operation string
+
+	// The failed or cancelled scaling activity, if any
+	Activity *asTypes.Activity
+
+	// The error of the operation, nil for a failed scaling activity
+	Err error
+}
+
+func (e *ASGOperationError) Error() string {
+	if e.Activity == nil {
+		return e.Err.Error()
+	}
+
+	details := make([]string, 0, 2)
+	if e.Activity.StatusMessage != nil {
+		details = append(details, *e.Activity.StatusMessage)
+	}
+	if e.Activity.Cause != nil {
+		details = append(details, "cause: "+*e.Activity.Cause)
+	}
+	return fmt.Sprintf(
+		"scaling activity %v in ASG %v is %v: %v",
+		aws.ToString(e.Activity.ActivityId),
+		e.AutoScalingGroupName,
+		e.Activity.StatusCode,
+		strings.Join(details, "; "),
+	)
+}
+
+func (e *ASGOperationError) Unwrap() error {
+	return e.Err
+}
+
+// asgOperationError returns the error of the failed operation on the Auto Scaling Group, nil if none
+func asgOperationError(asgName *string, operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ASGOperationError{AutoScalingGroupName: aws.ToString(asgName), Operation: operation, Err: err}
+}
+
+// InstanceError is returned when an EC2 operation on the instances fails
+type InstanceError struct {
+	// The instances of the operation
+	InstanceIds []string
+
+	// The error of the operation
+	Err error
+}
+
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("instances %v: %v", e.InstanceIds, e.Err)
+}
+
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// Failure is an individual failure of an aggregated error with the context of its typed errors
+type Failure struct {
+	// Name of the instance group, if known
+	Group string
+
+	// Name of the Auto Scaling Group, if any
+	AutoScalingGroupName string
+
+	// The instances of the failure, if any
+	InstanceIds []string
+
+	// The individual error
+	Err error
+}
+
+// Failures splits the error, e.g. joined with errors.Join, into the individual failures
+// with the group, the Auto Scaling Group and the instances of the typed errors wrapping them.
+func Failures(err error) []Failure {
+	failures := make([]Failure, 0)
+	if err != nil {
+		collectFailures(err, Failure{}, &failures)
+	}
+	return failures
+}
+
+func collectFailures(err error, failure Failure, failures *[]Failure) {
+	failure.Err = err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch typed := e.(type) {
+		case *GroupResolutionError:
+			failure.Group = typed.Group
+		case *ASGOperationError:
+			failure.AutoScalingGroupName = typed.AutoScalingGroupName
+		case *InstanceError:
+			failure.InstanceIds = typed.InstanceIds
+		case *WaiterTimeoutError:
+			if len(typed.InstanceIds) > 0 {
+				failure.InstanceIds = typed.InstanceIds
+			}
+		}
+
+		// the joined errors are individual failures sharing the context of their wrappers
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			for _, child := range joined.Unwrap() {
+				collectFailures(child, failure, failures)
+			}
+			return
+		}
+	}
+	*failures = append(*failures, failure)
+}
@@ -0,0 +1,132 @@
+package curator
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// EventType is the type of an orchestration event
+type EventType string
+
+// Orchestration event types
+const (
+	EventRunStarted                 EventType = "run-started"
+	EventRunFinished                EventType = "run-finished"
+	EventGroupStarted               EventType = "group-started"
+	EventGroupCompleted             EventType = "group-completed"
+	EventInstancesEnteredStandby    EventType = "instances-entered-standby"
+	EventInstancesReturnedToService EventType = "instances-returned-to-service"
+	EventInstancesStopped           EventType = "instances-stopped"
+	EventInstancesStarted           EventType = "instances-started"
+	EventInstancesRebooted          EventType = "instances-rebooted"
+	EventWaiterAttempt              EventType = "waiter-attempt"
+	EventWaiterCompleted            EventType = "waiter-completed"
+	EventPhaseCompleted             EventType = "phase-completed"
+	EventCapacityRetry              EventType = "capacity-retry"
+	EventInstanceTypeChanged        EventType = "instance-type-changed"
+	EventElasticIPReassociated      EventType = "elastic-ip-reassociated"
+	EventError                      EventType = "error"
+)
+
+// Phase is a timed phase of the processing of a group
+type Phase string
+
+// Group processing phases
+const (
+	PhaseDescribe  Phase = "describe"
+	PhaseStandby   Phase = "standby"
+	PhaseStop      Phase = "stop"
+	PhaseStart     Phase = "start"
+	PhaseReboot    Phase = "reboot"
+	PhaseInService Phase = "in-service"
+	PhaseHealth    Phase = "health"
+	PhaseWarmup    Phase = "warmup"
+)
+
+// Event is an orchestration event
+type Event struct {
+	Time        time.Time     `json:"time"`
+	Type        EventType     `json:"type"`
+	Group       string        `json:"group,omitempty"`
+	InstanceIds []string      `json:"instanceIds,omitempty"`
+	Attempt     int64         `json:"attempt,omitempty"`
+	Phase       Phase         `json:"phase,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// eventHandler receives every orchestration event of the package-level functions, if set
+var eventHandler func(Event)
+
+// SetEventHandler sets a function receiving the orchestration events of the package-level functions as they happen.
+// A nil handler disables the events. A Curator is configured with WithEventHandler instead.
+func SetEventHandler(h func(Event)) {
+	eventHandler = h
+}
+
+// Emit delivers the event of the package-level functions to the event handler, if any, stamping the event time.
+func Emit(e Event) {
+	packageCurator().Emit(e)
+}
+
+// Emit delivers the event to the event handler of the Curator, if any, stamping the event time.
+func (c *Curator) Emit(e Event) {
+	if c.events == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	c.events(e)
+}
+
+// emitPhase emits the completion of the phase of the group started at the given time
+func (c *Curator) emitPhase(group string, phase Phase, start time.Time) {
+	c.Emit(Event{Type: EventPhaseCompleted, Group: group, Phase: phase, Duration: time.Since(start)})
+}
+
+// emitWaiter emits the completion of a waiter started at the given time after the number of attempts, if known
+func (c *Curator) emitWaiter(group string, attempts int64, start time.Time) {
+	c.Emit(Event{Type: EventWaiterCompleted, Group: group, Attempt: attempts, Duration: time.Since(start)})
+}
+
+// waiterAttempts counts the attempts of a waiter of the group, emitting every attempt and the completion of the wait
+type waiterAttempts struct {
+	curator *Curator
+	group   string
+	count   int64
+	start   time.Time
+}
+
+// newWaiterAttempts returns the counter of the waiter attempts of the group
+func (c *Curator) newWaiterAttempts(group string) *waiterAttempts {
+	return &waiterAttempts{curator: c, group: group}
+}
+
+// addCounter is a waiter API option emitting every operation invoked by the waiter as an attempt
+func (a *waiterAttempts) addCounter(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("WaiterAttemptCounter", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		a.attempt("")
+		return next.HandleInitialize(ctx, in)
+	}), middleware.After)
+}
+
+// attempt counts and emits the next attempt of the wait with the message, if any
+func (a *waiterAttempts) attempt(message string) {
+	a.count++
+	a.curator.Emit(Event{Type: EventWaiterAttempt, Group: a.group, Attempt: a.count, Message: message})
+}
+
+// reset starts counting the attempts of the next wait
+func (a *waiterAttempts) reset() {
+	a.count = 0
+	a.start = time.Now()
+}
+
+// emit emits the completion of the wait after the counted attempts
+func (a *waiterAttempts) emit() {
+	a.curator.emitWaiter(a.group, a.count, a.start)
+}
@@ -0,0 +1,112 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// AddAddress adds an Elastic IP address to the fake, associated with the instance or the network interface if set.
+func (c *Cloud) AddAddress(address ec2Types.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addresses[*address.AllocationId] = &address
+}
+
+// DescribeAddresses returns the Elastic IP addresses with the allocation IDs, or all of them,
+// matching the instance-id and allocation-id filters.
+func (c *Cloud) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeAddresses"); err != nil {
+		return nil, err
+	}
+
+	allocationIds := params.AllocationIds
+	if len(allocationIds) == 0 {
+		for id := range c.addresses {
+			allocationIds = append(allocationIds, id)
+		}
+		sort.Strings(allocationIds)
+	}
+
+	output := &ec2.DescribeAddressesOutput{}
+	for _, id := range allocationIds {
+		a, ok := c.addresses[id]
+		if !ok {
+			return nil, apiError("InvalidAllocationID.NotFound", "The allocation ID '%v' does not exist", id)
+		}
+
+		match := true
+		for _, f := range params.Filters {
+			var candidate string
+			switch aws.ToString(f.Name) {
+			case "instance-id":
+				candidate = aws.ToString(a.InstanceId)
+			case "allocation-id":
+				candidate = aws.ToString(a.AllocationId)
+			default:
+				return nil, apiError("InvalidParameterValue", "The filter '%v' is invalid", aws.ToString(f.Name))
+			}
+			match = match && slices.Contains(f.Values, candidate)
+		}
+		if match {
+			output.Addresses = append(output.Addresses, *a)
+		}
+	}
+	return output, nil
+}
+
+// AssociateAddress associates the Elastic IP address with the instance or the network interface.
+func (c *Cloud) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("AssociateAddress"); err != nil {
+		return nil, err
+	}
+	c.record("AssociateAddress", params)
+	a, ok := c.addresses[aws.ToString(params.AllocationId)]
+	if !ok {
+		return nil, apiError("InvalidAllocationID.NotFound", "The allocation ID '%v' does not exist", aws.ToString(params.AllocationId))
+	}
+	if a.AssociationId != nil && !aws.ToBool(params.AllowReassociation) {
+		return nil, apiError("Resource.AlreadyAssociated", "resource %v is already associated with associate-id %v", *a.AllocationId, *a.AssociationId)
+	}
+
+	instanceId := params.InstanceId
+	if instanceId == nil && params.NetworkInterfaceId != nil {
+		instanceId = c.networkInterfaceInstanceId(*params.NetworkInterfaceId)
+	}
+	if instanceId == nil {
+		return nil, apiError("InvalidParameterCombination", "The instance or the attached network interface is required")
+	}
+	if _, err := c.lookupInstances([]string{*instanceId}); err != nil {
+		return nil, err
+	}
+
+	a.AssociationId = aws.String(fmt.Sprintf("eipassoc-%v", len(c.calls)))
+	a.InstanceId = instanceId
+	a.NetworkInterfaceId = params.NetworkInterfaceId
+	a.PrivateIpAddress = params.PrivateIpAddress
+	return &ec2.AssociateAddressOutput{AssociationId: a.AssociationId}, nil
+}
+
+// networkInterfaceInstanceId returns the ID of the instance the network interface is attached to, if any
+func (c *Cloud) networkInterfaceInstanceId(networkInterfaceId string) *string {
+	for _, i := range c.instances {
+		for _, n := range i.NetworkInterfaces {
+			if aws.ToString(n.NetworkInterfaceId) == networkInterfaceId {
+				return i.InstanceId
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package fake
+
+import (
+	"math/rand"
+	"time"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Chaos configures the failures injected by the Cloud, so that the rollback and resume logic
+// and the operators may be rehearsed before a real incident. The probabilities range from 0 to 1.
+type Chaos struct {
+	// Probability of an EnterStandby call failing.
+	EnterStandbyFailure float64
+
+	// Probability of a stopped instance getting stuck in the stopping state until it is stopped
+	// by force, so that the stop waiter times out.
+	StuckStopping float64
+
+	// Probability of any call failing with a throttling error.
+	Throttling float64
+
+	// Seed of the random failures, making the rehearsals reproducible. A random seed if zero.
+	Seed int64
+}
+
+// SetChaos injects the failures configured by the chaos into the following calls.
+func (c *Cloud) SetChaos(chaos Chaos) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seed := chaos.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c.chaos = chaos
+	c.random = rand.New(rand.NewSource(seed))
+}
+
+// injected reports whether a failure of the probability is injected
+func (c *Cloud) injected(probability float64) bool {
+	return c.random != nil && probability > 0 && c.random.Float64() < probability
+}
+
+// throttled returns the throttling error injected into the operation, if any
+func (c *Cloud) throttled(operation string) error {
+	if c.injected(c.chaos.Throttling) {
+		return apiError("Throttling", "Rate exceeded, injected into %v", operation)
+	}
+	return nil
+}
+
+// stickStopping leaves the stopped instances stuck in the stopping state by chance
+func (c *Cloud) stickStopping(changes []ec2Types.InstanceStateChange) {
+	for k, change := range changes {
+		if change.PreviousState.Name == ec2Types.InstanceStateNameRunning && c.injected(c.chaos.StuckStopping) {
+			i := c.instances[*change.InstanceId]
+			i.State = &ec2Types.InstanceState{Name: ec2Types.InstanceStateNameStopping}
+			changes[k].CurrentState = i.State
+		}
+	}
+}
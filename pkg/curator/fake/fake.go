@@ -0,0 +1,1026 @@
+// Package fake provides an in-memory implementation of the EC2 and Auto Scaling
+// operations used by the curator, so that the orchestration logic can be exercised without AWS.
+//
+// State transitions are applied immediately: stopped instances are reported as stopped
+// by the following describe call, and instances entering or exiting Standby reach
+// the target lifecycle state at once. SetChaos injects random failures for rehearsals.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+)
+
+// Call is a record of a mutating call made against the fake
+type Call struct {
+	// The name of the operation, e.g. EnterStandby.
+	Operation string
+
+	// The operation input.
+	Input interface{}
+}
+
+// Cloud is an in-memory EC2 and Auto Scaling backend.
+// It implements both curator.EC2API and curator.AutoScalingAPI.
+type Cloud struct {
+	mu sync.Mutex
+
+	instances         map[string]*ec2Types.Instance
+	autoScalingGroups map[string]*asTypes.AutoScalingGroup
+	activities        []asTypes.Activity
+	policies          []asTypes.ScalingPolicy
+	images            map[string]*ec2Types.Image
+	reservations      map[string]*ec2Types.CapacityReservation
+	addresses         map[string]*ec2Types.Address
+
+	calls []Call
+
+	// failures injected by SetChaos
+	chaos  Chaos
+	random *rand.Rand
+}
+
+// New constructs an empty Cloud.
+func New() *Cloud {
+	return &Cloud{
+		instances:         make(map[string]*ec2Types.Instance),
+		autoScalingGroups: make(map[string]*asTypes.AutoScalingGroup),
+		images:            make(map[string]*ec2Types.Image),
+		reservations:      make(map[string]*ec2Types.CapacityReservation),
+		addresses:         make(map[string]*ec2Types.Address),
+	}
+}
+
+// AddInstance adds an EC2 instance to the fake.
+func (c *Cloud) AddInstance(instance ec2Types.Instance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if instance.State == nil {
+		instance.State = &ec2Types.InstanceState{Name: ec2Types.InstanceStateNameRunning}
+	}
+	c.instances[*instance.InstanceId] = &instance
+}
+
+// AddAutoScalingGroup adds an Auto Scaling Group to the fake.
+// Members are taken from the Instances field of the group.
+func (c *Cloud) AddAutoScalingGroup(group asTypes.AutoScalingGroup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group.Instances = append([]asTypes.Instance{}, group.Instances...)
+	c.autoScalingGroups[*group.AutoScalingGroupName] = &group
+}
+
+// Instance returns a copy of the EC2 instance with the given ID.
+func (c *Cloud) Instance(instanceId string) (ec2Types.Instance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i, ok := c.instances[instanceId]
+	if !ok {
+		return ec2Types.Instance{}, false
+	}
+	return *i, true
+}
+
+// AutoScalingGroup returns a copy of the Auto Scaling Group with the given name.
+func (c *Cloud) AutoScalingGroup(name string) (asTypes.AutoScalingGroup, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, ok := c.autoScalingGroups[name]
+	if !ok {
+		return asTypes.AutoScalingGroup{}, false
+	}
+	group := *g
+	group.Instances = append([]asTypes.Instance{}, g.Instances...)
+	return group, true
+}
+
+// Calls returns the mutating calls made against the fake in order.
+func (c *Cloud) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Call{}, c.calls...)
+}
+
+func (c *Cloud) record(operation string, input interface{}) {
+	c.calls = append(c.calls, Call{Operation: operation, Input: input})
+}
+
+func apiError(code, format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (c *Cloud) sortedInstanceIds() []string {
+	ids := make([]string, 0, len(c.instances))
+	for id := range c.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (c *Cloud) lookupInstances(instanceIds []string) ([]*ec2Types.Instance, error) {
+	instances := make([]*ec2Types.Instance, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		i, ok := c.instances[id]
+		if !ok {
+			return nil, apiError("InvalidInstanceID.NotFound", "The instance ID '%v' does not exist", id)
+		}
+		instances = append(instances, i)
+	}
+	return instances, nil
+}
+
+func matchFilter(instance *ec2Types.Instance, filter ec2Types.Filter) (bool, error) {
+	name := aws.ToString(filter.Name)
+	var candidates []string
+	switch {
+	case name == "instance-state-name":
+		candidates = []string{string(instance.State.Name)}
+	case name == "instance-id":
+		candidates = []string{aws.ToString(instance.InstanceId)}
+	case name == "instance-type":
+		candidates = []string{string(instance.InstanceType)}
+	case name == "private-ip-address":
+		candidates = []string{aws.ToString(instance.PrivateIpAddress)}
+	case name == "tag-key":
+		for _, t := range instance.Tags {
+			candidates = append(candidates, aws.ToString(t.Key))
+		}
+	case name == "tag-value":
+		for _, t := range instance.Tags {
+			candidates = append(candidates, aws.ToString(t.Value))
+		}
+	case strings.HasPrefix(name, "tag:"):
+		for _, t := range instance.Tags {
+			if aws.ToString(t.Key) == strings.TrimPrefix(name, "tag:") {
+				candidates = append(candidates, aws.ToString(t.Value))
+			}
+		}
+	default:
+		return false, apiError("InvalidParameterValue", "The filter '%v' is invalid", name)
+	}
+
+	for _, candidate := range candidates {
+		for _, value := range filter.Values {
+			if candidate == value {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// DescribeInstances returns the instances matching the instance IDs and filters in a single reservation.
+func (c *Cloud) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeInstances"); err != nil {
+		return nil, err
+	}
+	var candidates []*ec2Types.Instance
+	if len(params.InstanceIds) > 0 {
+		var err error
+		if candidates, err = c.lookupInstances(params.InstanceIds); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, id := range c.sortedInstanceIds() {
+			candidates = append(candidates, c.instances[id])
+		}
+	}
+
+	instances := make([]ec2Types.Instance, 0, len(candidates))
+	for _, i := range candidates {
+		match := true
+		for _, f := range params.Filters {
+			ok, err := matchFilter(i, f)
+			if err != nil {
+				return nil, err
+			}
+			match = match && ok
+		}
+		if match {
+			instances = append(instances, *i)
+		}
+	}
+
+	output := &ec2.DescribeInstancesOutput{}
+	if len(instances) > 0 {
+		output.Reservations = []ec2Types.Reservation{{Instances: instances}}
+	}
+	return output, nil
+}
+
+// DescribeInstanceStatus reports running instances as passing both status checks.
+func (c *Cloud) DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeInstanceStatus"); err != nil {
+		return nil, err
+	}
+	instanceIds := params.InstanceIds
+	if len(instanceIds) == 0 {
+		instanceIds = c.sortedInstanceIds()
+	}
+	instances, err := c.lookupInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeInstanceStatusOutput{}
+	for _, i := range instances {
+		if i.State.Name != ec2Types.InstanceStateNameRunning && !aws.ToBool(params.IncludeAllInstances) {
+			continue
+		}
+		status := ec2Types.SummaryStatusOk
+		if i.State.Name != ec2Types.InstanceStateNameRunning {
+			status = ec2Types.SummaryStatusNotApplicable
+		}
+		output.InstanceStatuses = append(output.InstanceStatuses, ec2Types.InstanceStatus{
+			InstanceId:     i.InstanceId,
+			InstanceState:  i.State,
+			InstanceStatus: &ec2Types.InstanceStatusSummary{Status: status},
+			SystemStatus:   &ec2Types.InstanceStatusSummary{Status: status},
+		})
+	}
+	return output, nil
+}
+
+func (c *Cloud) changeInstanceStates(instanceIds []string, from, to ec2Types.InstanceStateName) ([]ec2Types.InstanceStateChange, error) {
+	instances, err := c.lookupInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]ec2Types.InstanceStateChange, 0, len(instances))
+	for _, i := range instances {
+		previous := *i.State
+		if i.State.Name == from {
+			i.State = &ec2Types.InstanceState{Name: to}
+		} else if i.State.Name != to {
+			return nil, apiError("IncorrectInstanceState", "The instance '%v' is not in a state from which it can be changed to %v", *i.InstanceId, to)
+		}
+		changes = append(changes, ec2Types.InstanceStateChange{
+			InstanceId:    i.InstanceId,
+			PreviousState: &previous,
+			CurrentState:  i.State,
+		})
+	}
+	return changes, nil
+}
+
+// StartInstances moves stopped instances to the running state.
+func (c *Cloud) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("StartInstances"); err != nil {
+		return nil, err
+	}
+	c.record("StartInstances", params)
+	changes, err := c.changeInstanceStates(params.InstanceIds, ec2Types.InstanceStateNameStopped, ec2Types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.StartInstancesOutput{StartingInstances: changes}, nil
+}
+
+// StopInstances moves running instances to the stopped state.
+func (c *Cloud) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("StopInstances"); err != nil {
+		return nil, err
+	}
+	c.record("StopInstances", params)
+	if aws.ToBool(params.Force) {
+		// the instances stuck stopping are stopped by force
+		if _, err := c.changeInstanceStates(params.InstanceIds, ec2Types.InstanceStateNameStopping, ec2Types.InstanceStateNameStopped); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := c.changeInstanceStates(params.InstanceIds, ec2Types.InstanceStateNameRunning, ec2Types.InstanceStateNameStopped)
+	if err != nil {
+		return nil, err
+	}
+	c.stickStopping(changes)
+	return &ec2.StopInstancesOutput{StoppingInstances: changes}, nil
+}
+
+// RebootInstances reboots running instances, leaving their state unchanged.
+func (c *Cloud) RebootInstances(ctx context.Context, params *ec2.RebootInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RebootInstancesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("RebootInstances"); err != nil {
+		return nil, err
+	}
+	c.record("RebootInstances", params)
+	instances, err := c.lookupInstances(params.InstanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		if i.State.Name != ec2Types.InstanceStateNameRunning {
+			return nil, apiError("IncorrectInstanceState", "The instance '%v' is not in a state from which it can be rebooted", *i.InstanceId)
+		}
+	}
+	return &ec2.RebootInstancesOutput{}, nil
+}
+
+// ModifyInstanceAttribute changes the instance type of a stopped instance.
+func (c *Cloud) ModifyInstanceAttribute(ctx context.Context, params *ec2.ModifyInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyInstanceAttributeOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("ModifyInstanceAttribute"); err != nil {
+		return nil, err
+	}
+	c.record("ModifyInstanceAttribute", params)
+	instances, err := c.lookupInstances([]string{aws.ToString(params.InstanceId)})
+	if err != nil {
+		return nil, err
+	}
+	i := instances[0]
+	if params.InstanceType != nil {
+		if i.State.Name != ec2Types.InstanceStateNameStopped {
+			return nil, apiError("IncorrectInstanceState", "The instance '%v' is not in the 'stopped' state", *i.InstanceId)
+		}
+		i.InstanceType = ec2Types.InstanceType(aws.ToString(params.InstanceType.Value))
+	}
+	return &ec2.ModifyInstanceAttributeOutput{}, nil
+}
+
+// CreateTags adds or overwrites tags of the instances.
+func (c *Cloud) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("CreateTags"); err != nil {
+		return nil, err
+	}
+	c.record("CreateTags", params)
+	instances, err := c.lookupInstances(params.Resources)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		for _, t := range params.Tags {
+			i.Tags = append(removeInstanceTag(i.Tags, aws.ToString(t.Key)), ec2Types.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// DeleteTags removes tags of the instances.
+func (c *Cloud) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DeleteTags"); err != nil {
+		return nil, err
+	}
+	c.record("DeleteTags", params)
+	instances, err := c.lookupInstances(params.Resources)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		for _, t := range params.Tags {
+			i.Tags = removeInstanceTag(i.Tags, aws.ToString(t.Key))
+		}
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+func removeInstanceTag(tags []ec2Types.Tag, key string) []ec2Types.Tag {
+	kept := make([]ec2Types.Tag, 0, len(tags))
+	for _, t := range tags {
+		if aws.ToString(t.Key) != key {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// CreateImage creates an available image of the instance.
+func (c *Cloud) CreateImage(ctx context.Context, params *ec2.CreateImageInput, optFns ...func(*ec2.Options)) (*ec2.CreateImageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("CreateImage"); err != nil {
+		return nil, err
+	}
+	c.record("CreateImage", params)
+	if _, err := c.lookupInstances([]string{aws.ToString(params.InstanceId)}); err != nil {
+		return nil, err
+	}
+
+	image := &ec2Types.Image{
+		ImageId:     aws.String(fmt.Sprintf("ami-%v", len(c.images)+1)),
+		Name:        params.Name,
+		Description: params.Description,
+		State:       ec2Types.ImageStateAvailable,
+	}
+	for _, spec := range params.TagSpecifications {
+		if spec.ResourceType == ec2Types.ResourceTypeImage {
+			image.Tags = append(image.Tags, spec.Tags...)
+		}
+	}
+	c.images[*image.ImageId] = image
+	return &ec2.CreateImageOutput{ImageId: image.ImageId}, nil
+}
+
+// DescribeImages returns the images with the given IDs.
+func (c *Cloud) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeImages"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeImagesOutput{}
+	for _, id := range params.ImageIds {
+		image, ok := c.images[id]
+		if !ok {
+			return nil, apiError("InvalidAMIID.NotFound", "The image id '[%v]' does not exist", id)
+		}
+		output.Images = append(output.Images, *image)
+	}
+	return output, nil
+}
+
+// AddCapacityReservation adds an EC2 capacity reservation to the fake.
+func (c *Cloud) AddCapacityReservation(reservation ec2Types.CapacityReservation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reservations[*reservation.CapacityReservationId] = &reservation
+}
+
+// DescribeCapacityReservations returns the capacity reservations matching the state, instance-type,
+// availability-zone and instance-platform filters. Other filters are ignored.
+func (c *Cloud) DescribeCapacityReservations(ctx context.Context, params *ec2.DescribeCapacityReservationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeCapacityReservations"); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(c.reservations))
+	for id := range c.reservations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := &ec2.DescribeCapacityReservationsOutput{}
+	for _, id := range ids {
+		r := c.reservations[id]
+		if len(params.CapacityReservationIds) > 0 && !slices.Contains(params.CapacityReservationIds, id) {
+			continue
+		}
+
+		matched := true
+		for _, f := range params.Filters {
+			var value string
+			switch aws.ToString(f.Name) {
+			case "state":
+				value = string(r.State)
+			case "instance-type":
+				value = aws.ToString(r.InstanceType)
+			case "availability-zone":
+				value = aws.ToString(r.AvailabilityZone)
+			case "instance-platform":
+				value = string(r.InstancePlatform)
+			default:
+				continue
+			}
+			if !slices.Contains(f.Values, value) {
+				matched = false
+			}
+		}
+		if matched {
+			output.CapacityReservations = append(output.CapacityReservations, *r)
+		}
+	}
+	return output, nil
+}
+
+// CreateCapacityReservation creates an active capacity reservation with all the instances available.
+func (c *Cloud) CreateCapacityReservation(ctx context.Context, params *ec2.CreateCapacityReservationInput, optFns ...func(*ec2.Options)) (*ec2.CreateCapacityReservationOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("CreateCapacityReservation"); err != nil {
+		return nil, err
+	}
+	c.record("CreateCapacityReservation", params)
+	reservation := &ec2Types.CapacityReservation{
+		CapacityReservationId:  aws.String(fmt.Sprintf("cr-%v", len(c.reservations)+1)),
+		AvailabilityZone:       params.AvailabilityZone,
+		InstanceType:           params.InstanceType,
+		InstancePlatform:       params.InstancePlatform,
+		Tenancy:                params.Tenancy,
+		TotalInstanceCount:     params.InstanceCount,
+		AvailableInstanceCount: params.InstanceCount,
+		InstanceMatchCriteria:  params.InstanceMatchCriteria,
+		EndDateType:            params.EndDateType,
+		EndDate:                params.EndDate,
+		State:                  ec2Types.CapacityReservationStateActive,
+	}
+	c.reservations[*reservation.CapacityReservationId] = reservation
+	return &ec2.CreateCapacityReservationOutput{CapacityReservation: reservation}, nil
+}
+
+// CancelCapacityReservation cancels an active capacity reservation.
+func (c *Cloud) CancelCapacityReservation(ctx context.Context, params *ec2.CancelCapacityReservationInput, optFns ...func(*ec2.Options)) (*ec2.CancelCapacityReservationOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("CancelCapacityReservation"); err != nil {
+		return nil, err
+	}
+	c.record("CancelCapacityReservation", params)
+	reservation, ok := c.reservations[aws.ToString(params.CapacityReservationId)]
+	if !ok {
+		return nil, apiError("InvalidCapacityReservationId.NotFound", "The capacity reservation '%v' does not exist", aws.ToString(params.CapacityReservationId))
+	}
+	if reservation.State != ec2Types.CapacityReservationStateActive {
+		return nil, apiError("InvalidCapacityReservationId.Malformed", "The capacity reservation '%v' is not active", aws.ToString(params.CapacityReservationId))
+	}
+	reservation.State = ec2Types.CapacityReservationStateCancelled
+	return &ec2.CancelCapacityReservationOutput{Return: aws.Bool(true)}, nil
+}
+
+// DescribeAutoScalingInstances returns the Auto Scaling Group membership of the instances.
+func (c *Cloud) DescribeAutoScalingInstances(ctx context.Context, params *autoscaling.DescribeAutoScalingInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeAutoScalingInstances"); err != nil {
+		return nil, err
+	}
+	requested := make(map[string]bool, len(params.InstanceIds))
+	for _, id := range params.InstanceIds {
+		requested[id] = true
+	}
+
+	output := &autoscaling.DescribeAutoScalingInstancesOutput{}
+	for _, name := range c.sortedAutoScalingGroupNames() {
+		g := c.autoScalingGroups[name]
+		for _, i := range g.Instances {
+			if len(requested) > 0 && !requested[*i.InstanceId] {
+				continue
+			}
+			output.AutoScalingInstances = append(output.AutoScalingInstances, asTypes.AutoScalingInstanceDetails{
+				AutoScalingGroupName: g.AutoScalingGroupName,
+				AvailabilityZone:     i.AvailabilityZone,
+				HealthStatus:         i.HealthStatus,
+				InstanceId:           i.InstanceId,
+				InstanceType:         i.InstanceType,
+				LifecycleState:       aws.String(string(i.LifecycleState)),
+				ProtectedFromScaleIn: i.ProtectedFromScaleIn,
+			})
+		}
+	}
+	return output, nil
+}
+
+func (c *Cloud) sortedAutoScalingGroupNames() []string {
+	names := make([]string, 0, len(c.autoScalingGroups))
+	for name := range c.autoScalingGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DescribeAutoScalingGroups returns the requested Auto Scaling Groups, or all of them.
+func (c *Cloud) DescribeAutoScalingGroups(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeAutoScalingGroups"); err != nil {
+		return nil, err
+	}
+	names := params.AutoScalingGroupNames
+	if len(names) == 0 {
+		names = c.sortedAutoScalingGroupNames()
+	}
+
+	output := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, name := range names {
+		g, ok := c.autoScalingGroups[name]
+		if !ok {
+			continue
+		}
+		group := *g
+		group.Instances = append([]asTypes.Instance{}, g.Instances...)
+		output.AutoScalingGroups = append(output.AutoScalingGroups, group)
+	}
+	return output, nil
+}
+
+func (c *Cloud) lookupAutoScalingGroup(name *string) (*asTypes.AutoScalingGroup, error) {
+	g, ok := c.autoScalingGroups[aws.ToString(name)]
+	if !ok {
+		return nil, apiError("ValidationError", "AutoScalingGroup name not found - %v", aws.ToString(name))
+	}
+	return g, nil
+}
+
+// AddScalingActivity adds a scaling activity to the fake, e.g. to simulate a failed activity.
+func (c *Cloud) AddScalingActivity(activity asTypes.Activity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.activities = append(c.activities, activity)
+}
+
+// DescribeScalingActivities returns the recorded scaling activities, most recent first.
+func (c *Cloud) DescribeScalingActivities(ctx context.Context, params *autoscaling.DescribeScalingActivitiesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribeScalingActivities"); err != nil {
+		return nil, err
+	}
+	requested := make(map[string]bool, len(params.ActivityIds))
+	for _, id := range params.ActivityIds {
+		requested[id] = true
+	}
+
+	output := &autoscaling.DescribeScalingActivitiesOutput{}
+	for idx := len(c.activities) - 1; idx >= 0; idx-- {
+		a := c.activities[idx]
+		if params.AutoScalingGroupName != nil && *params.AutoScalingGroupName != aws.ToString(a.AutoScalingGroupName) {
+			continue
+		}
+		if len(requested) > 0 && !requested[aws.ToString(a.ActivityId)] {
+			continue
+		}
+		output.Activities = append(output.Activities, a)
+	}
+	return output, nil
+}
+
+// UpdateAutoScalingGroup updates the Auto Scaling Group sizes.
+func (c *Cloud) UpdateAutoScalingGroup(ctx context.Context, params *autoscaling.UpdateAutoScalingGroupInput, optFns ...func(*autoscaling.Options)) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("UpdateAutoScalingGroup"); err != nil {
+		return nil, err
+	}
+	c.record("UpdateAutoScalingGroup", params)
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	minSize, maxSize, desiredCapacity := aws.ToInt32(g.MinSize), aws.ToInt32(g.MaxSize), aws.ToInt32(g.DesiredCapacity)
+	if params.MinSize != nil {
+		minSize = *params.MinSize
+	}
+	if params.MaxSize != nil {
+		maxSize = *params.MaxSize
+	}
+	if params.DesiredCapacity != nil {
+		desiredCapacity = *params.DesiredCapacity
+	}
+	if minSize > maxSize {
+		return nil, apiError("ValidationError", "Max bound, %v, must be greater than or equal to min bound, %v", maxSize, minSize)
+	}
+	if desiredCapacity < minSize {
+		desiredCapacity = minSize
+	}
+	if desiredCapacity > maxSize {
+		desiredCapacity = maxSize
+	}
+
+	g.MinSize, g.MaxSize, g.DesiredCapacity = aws.Int32(minSize), aws.Int32(maxSize), aws.Int32(desiredCapacity)
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+func (c *Cloud) changeLifecycleStates(name *string, instanceIds []string, from, to asTypes.LifecycleState) (*asTypes.AutoScalingGroup, []asTypes.Activity, error) {
+	g, err := c.lookupAutoScalingGroup(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members := make(map[string]int, len(g.Instances))
+	for idx, i := range g.Instances {
+		members[*i.InstanceId] = idx
+	}
+	for _, id := range instanceIds {
+		idx, ok := members[id]
+		if !ok {
+			return nil, nil, apiError("ValidationError", "The instance %v is not part of Auto Scaling group %v", id, *g.AutoScalingGroupName)
+		}
+		if g.Instances[idx].LifecycleState != from {
+			return nil, nil, apiError("ValidationError", "The instance %v is not in %v", id, from)
+		}
+	}
+
+	activities := make([]asTypes.Activity, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		g.Instances[members[id]].LifecycleState = to
+		activities = append(activities, asTypes.Activity{
+			ActivityId:           aws.String(fmt.Sprintf("activity-%v", len(c.activities)+len(activities)+1)),
+			AutoScalingGroupName: g.AutoScalingGroupName,
+			Description:          aws.String(fmt.Sprintf("Moving EC2 instance %v to %v", id, to)),
+			StatusCode:           asTypes.ScalingActivityStatusCodeSuccessful,
+			Progress:             aws.Int32(100),
+		})
+	}
+	c.activities = append(c.activities, activities...)
+	return g, activities, nil
+}
+
+// EnterStandby moves InService instances into Standby.
+func (c *Cloud) EnterStandby(ctx context.Context, params *autoscaling.EnterStandbyInput, optFns ...func(*autoscaling.Options)) (*autoscaling.EnterStandbyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("EnterStandby"); err != nil {
+		return nil, err
+	}
+	c.record("EnterStandby", params)
+	if c.injected(c.chaos.EnterStandbyFailure) {
+		return nil, apiError("ServiceUnavailable", "EnterStandby failure injected into AutoScalingGroup %v", aws.ToString(params.AutoScalingGroupName))
+	}
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToBool(params.ShouldDecrementDesiredCapacity) && aws.ToInt32(g.DesiredCapacity)-int32(len(params.InstanceIds)) < aws.ToInt32(g.MinSize) {
+		return nil, apiError("ValidationError", "AutoScalingGroup %v has min-size=%v, max-size=%v, and desired-size=%v. To place into standby %v instances, please update the AutoScalingGroup sizes appropriately.", *g.AutoScalingGroupName, aws.ToInt32(g.MinSize), aws.ToInt32(g.MaxSize), aws.ToInt32(g.DesiredCapacity), len(params.InstanceIds))
+	}
+
+	g, activities, err := c.changeLifecycleStates(params.AutoScalingGroupName, params.InstanceIds, asTypes.LifecycleStateInService, asTypes.LifecycleStateStandby)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToBool(params.ShouldDecrementDesiredCapacity) {
+		g.DesiredCapacity = aws.Int32(aws.ToInt32(g.DesiredCapacity) - int32(len(params.InstanceIds)))
+	}
+	return &autoscaling.EnterStandbyOutput{Activities: activities}, nil
+}
+
+// ExitStandby returns Standby instances to service, incrementing the desired capacity.
+func (c *Cloud) ExitStandby(ctx context.Context, params *autoscaling.ExitStandbyInput, optFns ...func(*autoscaling.Options)) (*autoscaling.ExitStandbyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("ExitStandby"); err != nil {
+		return nil, err
+	}
+	c.record("ExitStandby", params)
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToInt32(g.DesiredCapacity)+int32(len(params.InstanceIds)) > aws.ToInt32(g.MaxSize) {
+		return nil, apiError("ValidationError", "AutoScalingGroup %v has min-size=%v, max-size=%v, and desired-size=%v. To move %v instances out of standby, please update the AutoScalingGroup sizes appropriately.", *g.AutoScalingGroupName, aws.ToInt32(g.MinSize), aws.ToInt32(g.MaxSize), aws.ToInt32(g.DesiredCapacity), len(params.InstanceIds))
+	}
+
+	g, activities, err := c.changeLifecycleStates(params.AutoScalingGroupName, params.InstanceIds, asTypes.LifecycleStateStandby, asTypes.LifecycleStateInService)
+	if err != nil {
+		return nil, err
+	}
+	g.DesiredCapacity = aws.Int32(aws.ToInt32(g.DesiredCapacity) + int32(len(params.InstanceIds)))
+	return &autoscaling.ExitStandbyOutput{Activities: activities}, nil
+}
+
+// SetInstanceProtection sets the scale-in protection of the Auto Scaling instances.
+func (c *Cloud) SetInstanceProtection(ctx context.Context, params *autoscaling.SetInstanceProtectionInput, optFns ...func(*autoscaling.Options)) (*autoscaling.SetInstanceProtectionOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("SetInstanceProtection"); err != nil {
+		return nil, err
+	}
+	c.record("SetInstanceProtection", params)
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]int, len(g.Instances))
+	for idx, i := range g.Instances {
+		members[*i.InstanceId] = idx
+	}
+	for _, id := range params.InstanceIds {
+		if _, ok := members[id]; !ok {
+			return nil, apiError("ValidationError", "The instance %v is not part of Auto Scaling group %v", id, *g.AutoScalingGroupName)
+		}
+	}
+	for _, id := range params.InstanceIds {
+		g.Instances[members[id]].ProtectedFromScaleIn = aws.Bool(aws.ToBool(params.ProtectedFromScaleIn))
+	}
+	return &autoscaling.SetInstanceProtectionOutput{}, nil
+}
+
+// SuspendProcesses suspends the processes of the Auto Scaling Group.
+func (c *Cloud) SuspendProcesses(ctx context.Context, params *autoscaling.SuspendProcessesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.SuspendProcessesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("SuspendProcesses"); err != nil {
+		return nil, err
+	}
+	c.record("SuspendProcesses", params)
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range params.ScalingProcesses {
+		if !slices.ContainsFunc(g.SuspendedProcesses, func(s asTypes.SuspendedProcess) bool { return aws.ToString(s.ProcessName) == p }) {
+			g.SuspendedProcesses = append(g.SuspendedProcesses, asTypes.SuspendedProcess{
+				ProcessName:      aws.String(p),
+				SuspensionReason: aws.String("User suspended"),
+			})
+		}
+	}
+	return &autoscaling.SuspendProcessesOutput{}, nil
+}
+
+// ResumeProcesses resumes the suspended processes of the Auto Scaling Group.
+func (c *Cloud) ResumeProcesses(ctx context.Context, params *autoscaling.ResumeProcessesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.ResumeProcessesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("ResumeProcesses"); err != nil {
+		return nil, err
+	}
+	c.record("ResumeProcesses", params)
+	g, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	g.SuspendedProcesses = slices.DeleteFunc(g.SuspendedProcesses, func(s asTypes.SuspendedProcess) bool {
+		return slices.Contains(params.ScalingProcesses, aws.ToString(s.ProcessName))
+	})
+	return &autoscaling.ResumeProcessesOutput{}, nil
+}
+
+// AddScalingPolicy adds a scaling policy to the fake, e.g. a predictive scaling policy.
+func (c *Cloud) AddScalingPolicy(policy asTypes.ScalingPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policies = append(c.policies, policy)
+}
+
+// DescribePolicies returns the scaling policies of the Auto Scaling Group of the given names and types.
+func (c *Cloud) DescribePolicies(ctx context.Context, params *autoscaling.DescribePoliciesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribePoliciesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DescribePolicies"); err != nil {
+		return nil, err
+	}
+	output := &autoscaling.DescribePoliciesOutput{}
+	for _, p := range c.policies {
+		if params.AutoScalingGroupName != nil && aws.ToString(p.AutoScalingGroupName) != *params.AutoScalingGroupName {
+			continue
+		}
+		if len(params.PolicyNames) > 0 && !slices.Contains(params.PolicyNames, aws.ToString(p.PolicyName)) {
+			continue
+		}
+		if len(params.PolicyTypes) > 0 && !slices.Contains(params.PolicyTypes, aws.ToString(p.PolicyType)) {
+			continue
+		}
+		output.ScalingPolicies = append(output.ScalingPolicies, p)
+	}
+	return output, nil
+}
+
+// PutScalingPolicy creates or replaces the predictive scaling policy of the Auto Scaling Group.
+func (c *Cloud) PutScalingPolicy(ctx context.Context, params *autoscaling.PutScalingPolicyInput, optFns ...func(*autoscaling.Options)) (*autoscaling.PutScalingPolicyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("PutScalingPolicy"); err != nil {
+		return nil, err
+	}
+	c.record("PutScalingPolicy", params)
+	if _, err := c.lookupAutoScalingGroup(params.AutoScalingGroupName); err != nil {
+		return nil, err
+	}
+
+	policy := asTypes.ScalingPolicy{
+		AutoScalingGroupName:           params.AutoScalingGroupName,
+		PolicyName:                     params.PolicyName,
+		PolicyType:                     params.PolicyType,
+		PredictiveScalingConfiguration: params.PredictiveScalingConfiguration,
+	}
+	for i, p := range c.policies {
+		if aws.ToString(p.AutoScalingGroupName) == aws.ToString(params.AutoScalingGroupName) && aws.ToString(p.PolicyName) == aws.ToString(params.PolicyName) {
+			c.policies[i] = policy
+			return &autoscaling.PutScalingPolicyOutput{}, nil
+		}
+	}
+	c.policies = append(c.policies, policy)
+	return &autoscaling.PutScalingPolicyOutput{}, nil
+}
+
+// AutoScalingTags is the view of the Cloud implementing curator.AutoScalingTagsAPI and curator.AutoScalingPoliciesAPI,
+// whose DeleteTags operation conflicts with the EC2 one implemented by Cloud.
+type AutoScalingTags struct {
+	*Cloud
+}
+
+// AutoScalingTags returns the Auto Scaling tagging view of the Cloud.
+func (c *Cloud) AutoScalingTags() AutoScalingTags {
+	return AutoScalingTags{c}
+}
+
+// CreateOrUpdateTags adds or overwrites tags of the Auto Scaling Groups.
+func (c AutoScalingTags) CreateOrUpdateTags(ctx context.Context, params *autoscaling.CreateOrUpdateTagsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("CreateOrUpdateTags"); err != nil {
+		return nil, err
+	}
+	c.record("CreateOrUpdateTags", params)
+	for _, t := range params.Tags {
+		g, err := c.lookupAutoScalingGroup(t.ResourceId)
+		if err != nil {
+			return nil, err
+		}
+		g.Tags = append(removeAutoScalingGroupTag(g.Tags, aws.ToString(t.Key)), asTypes.TagDescription{
+			Key:               t.Key,
+			Value:             t.Value,
+			ResourceId:        t.ResourceId,
+			ResourceType:      t.ResourceType,
+			PropagateAtLaunch: t.PropagateAtLaunch,
+		})
+	}
+	return &autoscaling.CreateOrUpdateTagsOutput{}, nil
+}
+
+// DeleteTags removes tags of the Auto Scaling Groups.
+func (c AutoScalingTags) DeleteTags(ctx context.Context, params *autoscaling.DeleteTagsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DeleteTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.throttled("DeleteTags"); err != nil {
+		return nil, err
+	}
+	c.record("DeleteTags", params)
+	for _, t := range params.Tags {
+		g, err := c.lookupAutoScalingGroup(t.ResourceId)
+		if err != nil {
+			return nil, err
+		}
+		g.Tags = removeAutoScalingGroupTag(g.Tags, aws.ToString(t.Key))
+	}
+	return &autoscaling.DeleteTagsOutput{}, nil
+}
+
+func removeAutoScalingGroupTag(tags []asTypes.TagDescription, key string) []asTypes.TagDescription {
+	kept := make([]asTypes.TagDescription, 0, len(tags))
+	for _, t := range tags {
+		if aws.ToString(t.Key) != key {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+var (
+	_ curator.EC2API         = (*Cloud)(nil)
+	_ curator.AutoScalingAPI = (*Cloud)(nil)
+
+	_ curator.EC2TagsAPI             = (*Cloud)(nil)
+	_ curator.EC2ImagesAPI           = (*Cloud)(nil)
+	_ curator.EC2AddressesAPI        = (*Cloud)(nil)
+	_ curator.AutoScalingTagsAPI     = AutoScalingTags{}
+	_ curator.AutoScalingPoliciesAPI = AutoScalingTags{}
+)
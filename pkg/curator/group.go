@@ -0,0 +1,672 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithytime "github.com/aws/smithy-go/time"
+	"github.com/jmespath/go-jmespath"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// OrderGroups returns the stack groups in the order they are processed by the action:
+// shutdown processes groups in the declared order, startup in the reverse one.
+func OrderGroups(stack *types.Stack, action types.Action) []types.Group {
+	groups := make([]types.Group, 0, len(stack.Groups))
+	for i := range stack.Groups {
+		if action == types.ActionStartup {
+			groups = append(groups, stack.Groups[len(stack.Groups)-1-i])
+		} else {
+			groups = append(groups, stack.Groups[i])
+		}
+	}
+	return groups
+}
+
+// FindGroup returns the stack group with the given name.
+func FindGroup(stack *types.Stack, name string) (types.Group, bool) {
+	for _, g := range stack.Groups {
+		if *g.Name == name {
+			return g, true
+		}
+	}
+	return types.Group{}, false
+}
+
+// DefaultInstanceStates are the instance states considered by groups without an override
+var DefaultInstanceStates = []ec2Types.InstanceStateName{
+	ec2Types.InstanceStateNameRunning,
+	ec2Types.InstanceStateNameStopped,
+}
+
+// GroupInstanceStates returns the instance states considered by the group.
+func GroupInstanceStates(group *types.Group) []string {
+	states := group.InstanceStates
+	if len(states) == 0 {
+		states = DefaultInstanceStates
+	}
+
+	values := make([]string, 0, len(states))
+	for _, s := range states {
+		values = append(values, string(s))
+	}
+	return values
+}
+
+// ResolveGroupInstances appends the instances in the group instance states matching
+// both the stack and the group filters to the group instances.
+func ResolveGroupInstances(ctx context.Context, ec2Client EC2API, stack *types.Stack, group *types.Group) error {
+	return packageCurator().ResolveGroupInstances(ctx, ec2Client, stack, group)
+}
+
+// ResolveGroupInstances is ResolveGroupInstances with the events of the Curator
+func (c *Curator) ResolveGroupInstances(ctx context.Context, ec2Client EC2API, stack *types.Stack, group *types.Group) error {
+	// groups without filters consist of clusters only
+	if len(group.Filters) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseDescribe, time.Now())
+
+	filters := make([]ec2Types.Filter, 0, len(stack.Filters)+len(group.Filters)+1)
+	filters = append(filters, stack.Filters...)
+	filters = append(filters, group.Filters...)
+	filters = append(
+		filters,
+		ec2Types.Filter{
+			Name:   aws.String("instance-state-name"),
+			Values: GroupInstanceStates(group),
+		},
+	)
+
+	output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return &GroupResolutionError{Group: *group.Name, Err: err}
+	}
+
+	for _, r := range output.Reservations {
+		group.Instances = append(group.Instances, r.Instances...)
+	}
+	return nil
+}
+
+// FindOverlappingInstances resolves the instances of all the stack groups and returns the names
+// of the groups of every instance matched by more than one group, by the instance ID.
+func FindOverlappingInstances(ctx context.Context, ec2Client EC2API, stack *types.Stack) (map[string][]string, error) {
+	return packageCurator().FindOverlappingInstances(ctx, ec2Client, stack)
+}
+
+// FindOverlappingInstances is FindOverlappingInstances with the events of the Curator
+func (c *Curator) FindOverlappingInstances(ctx context.Context, ec2Client EC2API, stack *types.Stack) (map[string][]string, error) {
+	groupNames := make(map[string][]string)
+	for _, group := range stack.Groups {
+		group.Instances = nil
+		if err := c.ResolveGroupInstances(ctx, ec2Client, stack, &group); err != nil {
+			return nil, err
+		}
+		for _, id := range GroupInstanceIds(group) {
+			groupNames[id] = append(groupNames[id], *group.Name)
+		}
+	}
+
+	overlapping := make(map[string][]string)
+	for id, names := range groupNames {
+		if len(names) > 1 {
+			overlapping[id] = names
+		}
+	}
+	return overlapping, nil
+}
+
+// GroupBatches splits the resolved group instances into the batches of a rolling group,
+// or returns all of them as a single batch.
+func GroupBatches(group types.Group) [][]ec2Types.Instance {
+	if !group.Rolling || len(group.Instances) == 0 {
+		return [][]ec2Types.Instance{group.Instances}
+	}
+
+	size := 1
+	if group.BatchSize != nil {
+		size = *group.BatchSize
+	} else if group.BatchPercent != nil {
+		percent := *group.BatchPercent
+		size = max(1, (len(group.Instances)*percent+99)/100)
+	}
+
+	batches := make([][]ec2Types.Instance, 0, (len(group.Instances)+size-1)/size)
+	for start := 0; start < len(group.Instances); start += size {
+		batches = append(batches, group.Instances[start:min(start+size, len(group.Instances))])
+	}
+	return batches
+}
+
+// GroupInstanceIds returns the IDs of the resolved group instances.
+func GroupInstanceIds(group types.Group) []string {
+	instanceIds := make([]string, 0, len(group.Instances))
+	for _, i := range group.Instances {
+		instanceIds = append(instanceIds, *i.InstanceId)
+	}
+	return instanceIds
+}
+
+// maxEC2InstanceIds is the maximum number of instance IDs of a single EC2 request
+const maxEC2InstanceIds = 100
+
+// chunkInstanceIds splits the instance IDs into chunks of at most size IDs
+func chunkInstanceIds(instanceIds []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(instanceIds)+size-1)/size)
+	for start := 0; start < len(instanceIds); start += size {
+		chunks = append(chunks, instanceIds[start:min(start+size, len(instanceIds))])
+	}
+	return chunks
+}
+
+// StopInstanceGroup stops the group instances and waits until they are stopped.
+// Large groups are stopped and awaited in chunks within a single wait duration.
+func StopInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	return packageCurator().stopInstanceGroup(ctx, ec2Client, group, instanceIds)
+}
+
+// stopInstanceGroup is StopInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) stopInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseStop, time.Now())
+
+	// the chunks failing to stop do not stop the others, their errors are aggregated
+	chunks := make([][]string, 0)
+	stoppedIds := make([]string, 0, len(instanceIds))
+	chunkErrs := make([]error, 0)
+	stoppingInstances := make([]ec2Types.InstanceStateChange, 0, len(instanceIds))
+	for _, chunk := range chunkInstanceIds(instanceIds, maxEC2InstanceIds) {
+		output, err := ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
+			InstanceIds: chunk,
+		})
+		if err != nil {
+			chunkErrs = append(chunkErrs, &InstanceError{InstanceIds: chunk, Err: err})
+			continue
+		}
+		chunks = append(chunks, chunk)
+		stoppedIds = append(stoppedIds, chunk...)
+		stoppingInstances = append(stoppingInstances, output.StoppingInstances...)
+	}
+	if len(chunks) == 0 {
+		return errors.Join(chunkErrs...)
+	}
+	c.Printf("Instance state changes in instance group %v: %v\n", *group.Name, stoppingInstances)
+
+	// the instances stuck stopping past ForceStopAfter are forced to stop within the rest of the wait duration
+	waitDuration := groupWaitDuration(group, c.waitDuration)
+	deadline := time.Now().Add(waitDuration)
+	forceDeadline := deadline
+	force := group.ForceStopAfter != nil && *group.ForceStopAfter < waitDuration
+	if force {
+		forceDeadline = time.Now().Add(*group.ForceStopAfter)
+	}
+
+	stopped, err := c.waitForInstancesStopped(ctx, ec2Client, group, chunks, forceDeadline)
+	var timeoutErr *WaiterTimeoutError
+	if err != nil && force && errors.As(err, &timeoutErr) {
+		stuck, describeErr := stoppingInstanceIds(ctx, ec2Client, chunks)
+		if describeErr != nil {
+			return errors.Join(append(chunkErrs, err, describeErr)...)
+		}
+		if len(stuck) == 0 {
+			return errors.Join(append(chunkErrs, err)...)
+		}
+
+		c.Printf("Instance group %v: instances %v are stuck stopping, forcing them to stop\n", *group.Name, stuck)
+		for _, chunk := range chunkInstanceIds(stuck, maxEC2InstanceIds) {
+			if _, err := ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
+				InstanceIds: chunk,
+				Force:       aws.Bool(true),
+			}); err != nil {
+				return errors.Join(append(chunkErrs, &InstanceError{InstanceIds: chunk, Err: err})...)
+			}
+		}
+		stopped, err = c.waitForInstancesStopped(ctx, ec2Client, group, chunks, deadline)
+	}
+	if err != nil {
+		return errors.Join(append(chunkErrs, err)...)
+	}
+
+	pathValue, err := jmespath.Search(
+		fmt.Sprintf(
+			"Reservations[].Instances[].{%[1]v:%[1]v,%[2]v:%[2]v,%[3]v:%[3]v,%[4]v:%[4]v}",
+			"InstanceId",
+			"State",
+			"StateReason",
+			"StateTransitionReason",
+		),
+		stopped,
+	)
+	if err != nil {
+		return fmt.Errorf("error evaluating instance state: %w", err)
+	}
+
+	listOfValues, ok := pathValue.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected list got %T", pathValue)
+	}
+	c.Printf("Instance states in instance group %v: %v\n", *group.Name, listOfValues)
+	c.Emit(Event{Type: EventInstancesStopped, Group: *group.Name, InstanceIds: stoppedIds})
+
+	return errors.Join(chunkErrs...)
+}
+
+// waitForInstancesStopped waits until the instances of every chunk are stopped by the deadline
+func (c *Curator) waitForInstancesStopped(ctx context.Context, ec2Client EC2API, group types.Group, chunks [][]string, deadline time.Time) (*ec2.DescribeInstancesOutput, error) {
+	attempts := c.newWaiterAttempts(*group.Name)
+	waiter := ec2.NewInstanceStoppedWaiter(ec2Client, func(o *ec2.InstanceStoppedWaiterOptions) {
+		o.APIOptions = append(o.APIOptions, attempts.addCounter)
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.MaxDelay = time.Minute
+		setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+	})
+
+	waiterName := fmt.Sprintf("InstanceStopped waiter of instance group %v", *group.Name)
+	stopped := &ec2.DescribeInstancesOutput{}
+	for i, chunk := range chunks {
+		// the chunks left once the deadline has passed are not awaited at all
+		if time.Until(deadline) <= 0 {
+			return nil, instanceWaiterTimeoutError(ctx, ec2Client, waiterName, chunks[i:])
+		}
+		attempts.reset()
+		output, err := waiter.WaitForOutput(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: chunk,
+		}, time.Until(deadline))
+		attempts.emit()
+		if err != nil {
+			return nil, instanceWaiterError(ctx, ec2Client, waiterName, chunk, deadline, err)
+		}
+		stopped.Reservations = append(stopped.Reservations, output.Reservations...)
+	}
+	return stopped, nil
+}
+
+// stoppingInstanceIds returns the instances which are still stopping
+func stoppingInstanceIds(ctx context.Context, ec2Client EC2API, chunks [][]string) ([]string, error) {
+	stopping := make([]string, 0)
+	for _, chunk := range chunks {
+		paginator := ec2.NewDescribeInstancesPaginator(ec2Client, &ec2.DescribeInstancesInput{
+			InstanceIds: chunk,
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range output.Reservations {
+				for _, i := range r.Instances {
+					if i.State != nil && i.State.Name == ec2Types.InstanceStateNameStopping {
+						stopping = append(stopping, *i.InstanceId)
+					}
+				}
+			}
+		}
+	}
+	return stopping, nil
+}
+
+// StartInstanceGroup starts the group instances and waits until their status checks pass,
+// or until they are running if the group waiters await the running state only.
+// Large groups are started and awaited in chunks within a single wait duration.
+func StartInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	return packageCurator().startInstanceGroup(ctx, ec2Client, group, instanceIds)
+}
+
+// startInstanceGroup is StartInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) startInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseStart, time.Now())
+
+	// the chunks failing to start do not stop the others, their errors are aggregated
+	chunks := make([][]string, 0)
+	startedIds := make([]string, 0, len(instanceIds))
+	chunkErrs := make([]error, 0)
+	startingInstances := make([]ec2Types.InstanceStateChange, 0, len(instanceIds))
+	for _, chunk := range chunkInstanceIds(instanceIds, maxEC2InstanceIds) {
+		output, err := c.startInstances(ctx, ec2Client, group, chunk)
+		if err != nil && isCapacityError(err) && len(group.FallbackInstanceTypes) > 0 {
+			output, err = c.startInstancesWithFallback(ctx, ec2Client, group, chunk, err)
+		}
+		if err != nil {
+			chunkErrs = append(chunkErrs, &InstanceError{InstanceIds: chunk, Err: err})
+			continue
+		}
+		chunks = append(chunks, chunk)
+		startedIds = append(startedIds, chunk...)
+		startingInstances = append(startingInstances, output.StartingInstances...)
+	}
+	if len(chunks) == 0 {
+		return errors.Join(chunkErrs...)
+	}
+	c.Printf("Instance state changes in instance group %v: %v\n", *group.Name, startingInstances)
+
+	if err := c.waitForInstancesStarted(ctx, ec2Client, group, chunks); err != nil {
+		return errors.Join(append(chunkErrs, err)...)
+	}
+	c.Emit(Event{Type: EventInstancesStarted, Group: *group.Name, InstanceIds: startedIds})
+
+	return errors.Join(chunkErrs...)
+}
+
+// waitForInstancesStarted waits until the instances of every chunk pass their status checks, or are running
+// if the group waiters await the running state only, within a single wait duration
+func (c *Curator) waitForInstancesStarted(ctx context.Context, ec2Client EC2API, group types.Group, chunks [][]string) error {
+	deadline := time.Now().Add(groupWaitDuration(group, c.waitDuration))
+
+	if group.Waiters != nil && group.Waiters.StartedState != nil && *group.Waiters.StartedState == types.StartedStateRunning {
+		attempts := c.newWaiterAttempts(*group.Name)
+		waiter := ec2.NewInstanceRunningWaiter(ec2Client, func(o *ec2.InstanceRunningWaiterOptions) {
+			o.APIOptions = append(o.APIOptions, attempts.addCounter)
+			o.LogWaitAttempts = c.logWaitAttempts()
+			o.MaxDelay = time.Minute
+			setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+		})
+		waiterName := fmt.Sprintf("InstanceRunning waiter of instance group %v", *group.Name)
+		instances := make([]ec2Types.Instance, 0)
+		for i, chunk := range chunks {
+			if time.Until(deadline) <= 0 {
+				return instanceWaiterTimeoutError(ctx, ec2Client, waiterName, chunks[i:])
+			}
+			attempts.reset()
+			output, err := waiter.WaitForOutput(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: chunk,
+			}, time.Until(deadline))
+			attempts.emit()
+			if err != nil {
+				return instanceWaiterError(ctx, ec2Client, waiterName, chunk, deadline, err)
+			}
+			for _, r := range output.Reservations {
+				instances = append(instances, r.Instances...)
+			}
+		}
+		states := make([]string, 0, len(instances))
+		for _, i := range instances {
+			if i.State != nil {
+				states = append(states, fmt.Sprintf("%v:%v", aws.ToString(i.InstanceId), i.State.Name))
+			}
+		}
+		c.Printf("Instance states in instance group %v: %v\n", *group.Name, states)
+		return nil
+	}
+
+	attempts := c.newWaiterAttempts(*group.Name)
+	waiter := ec2.NewInstanceStatusOkWaiter(ec2Client, func(o *ec2.InstanceStatusOkWaiterOptions) {
+		o.APIOptions = append(o.APIOptions, attempts.addCounter)
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.MaxDelay = time.Minute
+		setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+	})
+	waiterName := fmt.Sprintf("InstanceStatusOk waiter of instance group %v", *group.Name)
+	instanceStatuses := make([]ec2Types.InstanceStatus, 0)
+	for i, chunk := range chunks {
+		if time.Until(deadline) <= 0 {
+			return instanceWaiterTimeoutError(ctx, ec2Client, waiterName, chunks[i:])
+		}
+		attempts.reset()
+		output, err := waiter.WaitForOutput(ctx, &ec2.DescribeInstanceStatusInput{
+			InstanceIds: chunk,
+		}, time.Until(deadline))
+		attempts.emit()
+		if err != nil {
+			return instanceWaiterError(ctx, ec2Client, waiterName, chunk, deadline, err)
+		}
+		instanceStatuses = append(instanceStatuses, output.InstanceStatuses...)
+	}
+	c.Printf("Instance statuses in instance group %v: %v\n", *group.Name, instanceStatuses)
+	return nil
+}
+
+// instanceWaiterError returns the error of the EC2 instance waiter timed out by the deadline with the states
+// the instances were last seen in, or the error itself if the waiter has not timed out
+func instanceWaiterError(ctx context.Context, ec2Client EC2API, waiter string, instanceIds []string, deadline time.Time, err error) error {
+	if !sdkWaiterTimedOut(ctx, deadline, err) {
+		return err
+	}
+	return instanceWaiterTimeoutError(ctx, ec2Client, waiter, [][]string{instanceIds})
+}
+
+// instanceWaiterTimeoutError returns the timeout of the EC2 instance waiter on the instances of the chunks
+// with the states they were last seen in, if they can be described
+func instanceWaiterTimeoutError(ctx context.Context, ec2Client EC2API, waiter string, chunks [][]string) error {
+	timeoutErr := &WaiterTimeoutError{Waiter: waiter}
+	for _, chunk := range chunks {
+		timeoutErr.InstanceIds = append(timeoutErr.InstanceIds, chunk...)
+	}
+
+	lastStates := make(map[string]string, len(timeoutErr.InstanceIds))
+	for _, chunk := range chunks {
+		output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: chunk,
+		})
+		if err != nil {
+			return timeoutErr
+		}
+		for _, r := range output.Reservations {
+			for _, i := range r.Instances {
+				if i.State != nil {
+					lastStates[aws.ToString(i.InstanceId)] = string(i.State.Name)
+				}
+			}
+		}
+	}
+	timeoutErr.LastStates = lastStates
+	return timeoutErr
+}
+
+// groupWaitDuration returns the maximum duration of the waits of the group, the default one unless overridden
+func groupWaitDuration(group types.Group, defaultDuration time.Duration) time.Duration {
+	if group.Waiters != nil && group.Waiters.MaxWait != nil {
+		return *group.Waiters.MaxWait
+	}
+	return defaultDuration
+}
+
+// setGroupWaiterDelays overrides the minimum and the maximum delays between the waiter attempts
+// by the group waiters, if any, so that the minimum delay does not exceed the maximum one
+func setGroupWaiterDelays(group types.Group, minDelay, maxDelay *time.Duration) {
+	if group.Waiters == nil {
+		return
+	}
+
+	if group.Waiters.MinDelay != nil {
+		*minDelay = *group.Waiters.MinDelay
+	}
+	if group.Waiters.MaxDelay != nil {
+		*maxDelay = *group.Waiters.MaxDelay
+	}
+	if *minDelay > *maxDelay {
+		if group.Waiters.MinDelay != nil {
+			*maxDelay = *minDelay
+		} else {
+			*minDelay = *maxDelay
+		}
+	}
+}
+
+// capacityErrorCodes are the error codes of the instance starts failing for insufficient capacity
+var capacityErrorCodes = map[string]bool{
+	"InsufficientInstanceCapacity":         true,
+	"InsufficientHostCapacity":             true,
+	"InsufficientReservedInstanceCapacity": true,
+	"InsufficientCapacity":                 true,
+}
+
+// isCapacityError reports whether the error is caused by insufficient capacity
+func isCapacityError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && capacityErrorCodes[apiErr.ErrorCode()]
+}
+
+// startInstances starts the instances, retrying the start failing for insufficient capacity
+// as configured for the group
+func (c *Curator) startInstances(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) (*ec2.StartInstancesOutput, error) {
+	input := &ec2.StartInstancesInput{
+		InstanceIds: instanceIds,
+	}
+	if group.CapacityRetry == nil {
+		return ec2Client.StartInstances(ctx, input)
+	}
+
+	interval := time.Minute
+	if group.CapacityRetry.Interval != nil {
+		interval = *group.CapacityRetry.Interval
+	}
+	maxDuration := 30 * time.Minute
+	if group.CapacityRetry.MaxDuration != nil {
+		maxDuration = *group.CapacityRetry.MaxDuration
+	}
+
+	var output *ec2.StartInstancesOutput
+	var lastErr error
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), maxDuration, interval, interval, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		var err error
+		output, err = ec2Client.StartInstances(ctx, input, func(o *ec2.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err == nil {
+			return false, nil
+		}
+		if !isCapacityError(err) {
+			return false, err
+		}
+
+		lastErr = err
+		c.Printf("Instance group %v: insufficient capacity to start instances %v, retrying in %v\n", *group.Name, instanceIds, interval.String())
+		c.Emit(Event{Type: EventCapacityRetry, Group: *group.Name, InstanceIds: instanceIds, Error: err.Error()})
+		return true, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			return nil, fmt.Errorf("exceeded max duration of capacity retries for instances %v of instance group %v: %w", instanceIds, *group.Name, lastErr)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// startInstancesWithFallback changes the type of the stopped instances to the fallback instance types
+// one after another until their start does not fail for insufficient capacity. The original type of every
+// changed instance is recorded with a tag, unless already recorded by a previous fallback.
+func (c *Curator) startInstancesWithFallback(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string, capacityErr error) (*ec2.StartInstancesOutput, error) {
+	output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stopped := make([]ec2Types.Instance, 0, len(instanceIds))
+	for _, r := range output.Reservations {
+		for _, i := range r.Instances {
+			if i.State != nil && i.State.Name == ec2Types.InstanceStateNameStopped {
+				stopped = append(stopped, i)
+			}
+		}
+	}
+	if len(stopped) == 0 {
+		return nil, capacityErr
+	}
+
+	stoppedIds := make([]string, 0, len(stopped))
+	for _, i := range stopped {
+		stoppedIds = append(stoppedIds, *i.InstanceId)
+		if hasTag(i.Tags, OriginalInstanceTypeTag) {
+			continue
+		}
+		if _, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{*i.InstanceId},
+			Tags:      []ec2Types.Tag{{Key: aws.String(OriginalInstanceTypeTag), Value: aws.String(string(i.InstanceType))}},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, instanceType := range group.FallbackInstanceTypes {
+		for _, i := range stopped {
+			if _, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+				InstanceId:   i.InstanceId,
+				InstanceType: &ec2Types.AttributeValue{Value: aws.String(string(instanceType))},
+			}); err != nil {
+				return nil, err
+			}
+		}
+		c.Printf("Instance group %v: instance type of instances %v has been changed to %v\n", *group.Name, stoppedIds, instanceType)
+		c.Emit(Event{Type: EventInstanceTypeChanged, Group: *group.Name, InstanceIds: stoppedIds, Message: string(instanceType)})
+
+		output, err := ec2Client.StartInstances(ctx, &ec2.StartInstancesInput{
+			InstanceIds: instanceIds,
+		})
+		if err == nil {
+			return output, nil
+		}
+		if !isCapacityError(err) {
+			return nil, err
+		}
+		capacityErr = err
+	}
+
+	return nil, fmt.Errorf("insufficient capacity for the fallback instance types %v of instance group %v: %w", group.FallbackInstanceTypes, *group.Name, capacityErr)
+}
+
+func hasTag(tags []ec2Types.Tag, key string) bool {
+	for _, t := range tags {
+		if aws.ToString(t.Key) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RebootGracePeriod is the delay between the reboot of the instances and the wait for their status checks,
+// as the status checks of rebooting instances may still pass
+const RebootGracePeriod = 30 * time.Second
+
+// RebootInstanceGroup reboots the group instances and waits until their status checks pass,
+// or until they are running if the group waiters await the running state only.
+func RebootInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	return packageCurator().RebootInstanceGroup(ctx, ec2Client, group, instanceIds)
+}
+
+// RebootInstanceGroup is RebootInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) RebootInstanceGroup(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseReboot, time.Now())
+
+	chunks := chunkInstanceIds(instanceIds, maxEC2InstanceIds)
+	for _, chunk := range chunks {
+		if _, err := ec2Client.RebootInstances(ctx, &ec2.RebootInstancesInput{
+			InstanceIds: chunk,
+		}); err != nil {
+			return err
+		}
+	}
+	c.Printf("Instance group %v: instances %v are rebooting\n", *group.Name, instanceIds)
+
+	if err := smithytime.SleepWithContext(ctx, RebootGracePeriod); err != nil {
+		return err
+	}
+
+	if err := c.waitForInstancesStarted(ctx, ec2Client, group, chunks); err != nil {
+		return err
+	}
+	c.Emit(Event{Type: EventInstancesRebooted, Group: *group.Name, InstanceIds: instanceIds})
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package curator_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// newInstances returns the instances i-1 to i-n
+func newInstances(n int) []ec2Types.Instance {
+	instances := make([]ec2Types.Instance, 0, n)
+	for i := 1; i <= n; i++ {
+		instances = append(instances, ec2Types.Instance{InstanceId: aws.String(fmt.Sprintf("i-%v", i))})
+	}
+	return instances
+}
+
+func TestGroupBatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		instances    int
+		rolling      bool
+		batchSize    *int
+		batchPercent *int
+		sizes        []int
+	}{
+		{name: "not rolling", instances: 5, batchSize: aws.Int(2), sizes: []int{5}},
+		{name: "rolling one at a time", instances: 3, rolling: true, sizes: []int{1, 1, 1}},
+		{name: "batch size", instances: 4, rolling: true, batchSize: aws.Int(2), sizes: []int{2, 2}},
+		{name: "batch size not dividing the group", instances: 5, rolling: true, batchSize: aws.Int(2), sizes: []int{2, 2, 1}},
+		{name: "batch size of the group", instances: 5, rolling: true, batchSize: aws.Int(5), sizes: []int{5}},
+		{name: "batch size above the group size", instances: 5, rolling: true, batchSize: aws.Int(10), sizes: []int{5}},
+		{name: "0 percent", instances: 3, rolling: true, batchPercent: aws.Int(0), sizes: []int{1, 1, 1}},
+		{name: "100 percent", instances: 5, rolling: true, batchPercent: aws.Int(100), sizes: []int{5}},
+		{name: "percent rounded up", instances: 5, rolling: true, batchPercent: aws.Int(30), sizes: []int{2, 2, 1}},
+		{name: "percent not dividing the group", instances: 3, rolling: true, batchPercent: aws.Int(50), sizes: []int{2, 1}},
+		{name: "percent below a single instance", instances: 3, rolling: true, batchPercent: aws.Int(10), sizes: []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := newInstances(tt.instances)
+			group := types.Group{
+				Name:         aws.String("app"),
+				Instances:    instances,
+				Rolling:      tt.rolling,
+				BatchSize:    tt.batchSize,
+				BatchPercent: tt.batchPercent,
+			}
+
+			batches := curator.GroupBatches(group)
+			sizes := make([]int, 0, len(batches))
+			ids := make([]string, 0, len(instances))
+			for _, batch := range batches {
+				sizes = append(sizes, len(batch))
+				ids = append(ids, curator.GroupInstanceIds(types.Group{Instances: batch})...)
+			}
+			if !slices.Equal(sizes, tt.sizes) {
+				t.Errorf("batch sizes are %v, expected %v", sizes, tt.sizes)
+			}
+			if expected := curator.GroupInstanceIds(group); !slices.Equal(ids, expected) {
+				t.Errorf("batched instances are %v, expected %v", ids, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package curator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/jmespath/go-jmespath"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// CheckInstanceGroupGuards evaluates the group guards one by one, waiting for the guards
+// configured to wait until they pass, and fails on the first guard that does not pass.
+func CheckInstanceGroupGuards(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string) error {
+	return packageCurator().CheckInstanceGroupGuards(ctx, ec2Client, autoscalingClient, group, instanceIds)
+}
+
+// CheckInstanceGroupGuards is CheckInstanceGroupGuards with the output and the wait duration of the Curator
+func (c *Curator) CheckInstanceGroupGuards(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string) error {
+	for _, g := range group.Guards {
+		name := aws.ToString(g.Name)
+		if name == "" {
+			name = *g.Expression
+		}
+
+		expression, err := jmespath.Compile(*g.Expression)
+		if err != nil {
+			return fmt.Errorf("error compiling guard %v of instance group %v: %w", name, *group.Name, err)
+		}
+
+		if g.OnFailure == nil || *g.OnFailure == types.GuardFailureAbort {
+			passed, err := evaluateGuard(ctx, ec2Client, autoscalingClient, group, instanceIds, g, expression, nil)
+			if err != nil {
+				return err
+			}
+			if !passed {
+				return fmt.Errorf("guard %v of instance group %v has not passed", name, *group.Name)
+			}
+			c.Printf("Instance group %v: guard %v has passed\n", *group.Name, name)
+			continue
+		}
+
+		timeout := c.waitDuration
+		if g.Timeout != nil {
+			timeout = *g.Timeout
+		}
+
+		c.Printf("Instance group %v: waiting for guard %v to pass\n", *group.Name, name)
+		err = waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, 10*time.Second, time.Minute, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+			passed, err := evaluateGuard(ctx, ec2Client, autoscalingClient, group, instanceIds, g, expression, apiOptions)
+			return !passed, err
+		})
+		if err != nil {
+			if errors.Is(err, ErrWaitTimeout) {
+				return &WaiterTimeoutError{Waiter: fmt.Sprintf("guard %v of instance group %v to pass", name, *group.Name)}
+			}
+			return err
+		}
+		c.Printf("Instance group %v: guard %v has passed\n", *group.Name, name)
+	}
+
+	return nil
+}
+
+func evaluateGuard(ctx context.Context, ec2Client EC2API, autoscalingClient AutoScalingAPI, group types.Group, instanceIds []string, g types.Guard, expression *jmespath.JMESPath, apiOptions []func(*middleware.Stack) error) (bool, error) {
+	var data interface{}
+	var err error
+	if g.Source != nil && *g.Source == types.GuardSourceAutoScalingGroups {
+		data, err = describeGuardAutoScalingGroups(ctx, autoscalingClient, instanceIds, g, apiOptions)
+	} else {
+		data, err = describeGuardInstances(ctx, ec2Client, instanceIds, g, apiOptions)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// normalize the output as the AWS CLI does, so that the expression compares values rather than pointers
+	b, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(b, &document); err != nil {
+		return false, err
+	}
+
+	value, err := expression.Search(document)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating guard of instance group %v: %w", *group.Name, err)
+	}
+	return isTruthy(value), nil
+}
+
+func describeGuardInstances(ctx context.Context, ec2Client EC2API, instanceIds []string, g types.Guard, apiOptions []func(*middleware.Stack) error) (*ec2.DescribeInstancesOutput, error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: g.Filters,
+	}
+	if len(g.Filters) == 0 {
+		input.InstanceIds = instanceIds
+		if len(instanceIds) == 0 {
+			return &ec2.DescribeInstancesOutput{}, nil
+		}
+	}
+
+	// merge the pages so that the expression sees every reservation
+	output := &ec2.DescribeInstancesOutput{}
+	paginator := ec2.NewDescribeInstancesPaginator(ec2Client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, func(o *ec2.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		output.Reservations = append(output.Reservations, page.Reservations...)
+	}
+	return output, nil
+}
+
+func describeGuardAutoScalingGroups(ctx context.Context, autoscalingClient AutoScalingAPI, instanceIds []string, g types.Guard, apiOptions []func(*middleware.Stack) error) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	withAPIOptions := func(o *autoscaling.Options) {
+		o.APIOptions = append(o.APIOptions, apiOptions...)
+	}
+
+	names := g.AutoScalingGroupNames
+	if len(names) == 0 {
+		if len(instanceIds) == 0 {
+			return &autoscaling.DescribeAutoScalingGroupsOutput{}, nil
+		}
+
+		seen := make(map[string]bool)
+		for _, chunk := range chunkInstanceIds(instanceIds, maxAutoScalingInstanceIds) {
+			output, err := autoscalingClient.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+				InstanceIds: chunk,
+			}, withAPIOptions)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, i := range output.AutoScalingInstances {
+				if !seen[*i.AutoScalingGroupName] {
+					seen[*i.AutoScalingGroupName] = true
+					names = append(names, *i.AutoScalingGroupName)
+				}
+			}
+		}
+		if len(names) == 0 {
+			return &autoscaling.DescribeAutoScalingGroupsOutput{}, nil
+		}
+	}
+
+	return autoscalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: names,
+	}, withAPIOptions)
+}
+
+// isTruthy follows the JMESPath truthiness rules: false, null and empty values are false
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package curator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Health check defaults
+const (
+	DefaultHealthCheckExpectedStatus int           = http.StatusOK
+	DefaultHealthCheckRetries        int           = 30
+	DefaultHealthCheckInterval       time.Duration = 10 * time.Second
+	DefaultHealthCheckTimeout        time.Duration = 5 * time.Second
+)
+
+// HealthCheckTarget is the data available to health check URL templates
+type HealthCheckTarget struct {
+	InstanceId       string
+	Name             string
+	PrivateIpAddress string
+	PrivateDnsName   string
+}
+
+func newHealthCheckTarget(instance ec2Types.Instance) HealthCheckTarget {
+	target := HealthCheckTarget{
+		InstanceId:       aws.ToString(instance.InstanceId),
+		PrivateIpAddress: aws.ToString(instance.PrivateIpAddress),
+		PrivateDnsName:   aws.ToString(instance.PrivateDnsName),
+	}
+	for _, t := range instance.Tags {
+		if aws.ToString(t.Key) == "Name" {
+			target.Name = aws.ToString(t.Value)
+			break
+		}
+	}
+	return target
+}
+
+// CheckInstanceGroupHealth probes the group health checks against the private IP
+// of every instance until they pass or their retries are exhausted.
+func CheckInstanceGroupHealth(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	return packageCurator().CheckInstanceGroupHealth(ctx, ec2Client, group, instanceIds)
+}
+
+// CheckInstanceGroupHealth is CheckInstanceGroupHealth with the output and the wait duration of the Curator
+func (c *Curator) CheckInstanceGroupHealth(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) error {
+	if len(group.HealthChecks) == 0 || len(instanceIds) == 0 {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseHealth, time.Now())
+
+	output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIds,
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for _, r := range output.Reservations {
+		for _, i := range r.Instances {
+			target := newHealthCheckTarget(i)
+			for _, hc := range group.HealthChecks {
+				wg.Add(1)
+				go func(hc types.HealthCheck) {
+					defer wg.Done()
+					if err := probeHealthCheck(ctx, hc, target); err != nil {
+						mu.Lock()
+						errs = append(errs, fmt.Errorf("instance %v: %w", target.InstanceId, err))
+						mu.Unlock()
+					}
+				}(hc)
+			}
+		}
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("health checks failed in instance group %v: %w", *group.Name, err)
+	}
+
+	c.Printf("Instance group %v: health checks have passed\n", *group.Name)
+	return nil
+}
+
+func probeHealthCheck(ctx context.Context, hc types.HealthCheck, target HealthCheckTarget) error {
+	retries := DefaultHealthCheckRetries
+	if hc.Retries != nil {
+		retries = *hc.Retries
+	}
+	interval := DefaultHealthCheckInterval
+	if hc.Interval != nil {
+		interval = *hc.Interval
+	}
+	timeout := DefaultHealthCheckTimeout
+	if hc.Timeout != nil {
+		timeout = *hc.Timeout
+	}
+
+	var probe func(context.Context) error
+	if hc.URL != nil {
+		url, err := renderHealthCheckURL(*hc.URL, target)
+		if err != nil {
+			return err
+		}
+		expectedStatus := DefaultHealthCheckExpectedStatus
+		if hc.ExpectedStatus != nil {
+			expectedStatus = *hc.ExpectedStatus
+		}
+		probe = func(ctx context.Context) error {
+			return probeHTTP(ctx, url, expectedStatus)
+		}
+	} else {
+		address := net.JoinHostPort(target.PrivateIpAddress, strconv.Itoa(int(*hc.Port)))
+		probe = func(ctx context.Context) error {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		probeCtx, cancelFn := context.WithTimeout(ctx, timeout)
+		err = probe(probeCtx)
+		cancelFn()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("health check failed after %v attempts: %w", retries+1, err)
+}
+
+func renderHealthCheckURL(urlTemplate string, target HealthCheckTarget) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing health check URL template: %w", err)
+	}
+
+	var url bytes.Buffer
+	if err := tmpl.Execute(&url, target); err != nil {
+		return "", fmt.Errorf("error rendering health check URL template: %w", err)
+	}
+	return url.String(), nil
+}
+
+func probeHTTP(ctx context.Context, url string, expectedStatus int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("GET %v: expected status %v, got %v", url, expectedStatus, resp.StatusCode)
+	}
+	return nil
+}
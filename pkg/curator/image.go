@@ -0,0 +1,78 @@
+package curator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// EC2ImagesAPI is the subset of the EC2 client image operations used by the curator.
+type EC2ImagesAPI interface {
+	ec2.DescribeImagesAPIClient
+
+	CreateImage(context.Context, *ec2.CreateImageInput, ...func(*ec2.Options)) (*ec2.CreateImageOutput, error)
+}
+
+// CreateInstanceGroupImages creates the images of the group instances, or of one representative
+// instance, tagged with the run ID, and waits until they are available.
+func CreateInstanceGroupImages(ctx context.Context, ec2Client EC2ImagesAPI, group types.Group, instanceIds []string, prefix string, run RunMetadata) ([]string, error) {
+	return packageCurator().CreateInstanceGroupImages(ctx, ec2Client, group, instanceIds, prefix, run)
+}
+
+// CreateInstanceGroupImages is CreateInstanceGroupImages with the output and the wait duration of the Curator
+func (c *Curator) CreateInstanceGroupImages(ctx context.Context, ec2Client EC2ImagesAPI, group types.Group, instanceIds []string, prefix string, run RunMetadata) ([]string, error) {
+	if group.Images == nil || len(instanceIds) == 0 {
+		return nil, nil
+	}
+
+	if group.Images.Representative {
+		instanceIds = instanceIds[:1]
+	}
+
+	tags := make([]ec2Types.Tag, 0, 3)
+	for k, v := range runTags(prefix, run) {
+		tags = append(tags, ec2Types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	imageIds := make([]string, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		output, err := ec2Client.CreateImage(ctx, &ec2.CreateImageInput{
+			InstanceId:  aws.String(id),
+			Name:        aws.String(fmt.Sprintf("%v-%v-%v", *group.Name, id, run.Timestamp.UTC().Format("20060102T150405Z"))),
+			Description: aws.String(fmt.Sprintf("Instance %v of instance group %v before %v run %v", id, *group.Name, run.Action, run.RunId)),
+			NoReboot:    aws.Bool(group.Images.NoReboot),
+			TagSpecifications: []ec2Types.TagSpecification{
+				{ResourceType: ec2Types.ResourceTypeImage, Tags: tags},
+				{ResourceType: ec2Types.ResourceTypeSnapshot, Tags: tags},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		imageIds = append(imageIds, *output.ImageId)
+	}
+
+	attempts := c.newWaiterAttempts(*group.Name)
+	waiter := ec2.NewImageAvailableWaiter(ec2Client, func(o *ec2.ImageAvailableWaiterOptions) {
+		o.APIOptions = append(o.APIOptions, attempts.addCounter)
+		o.LogWaitAttempts = c.logWaitAttempts()
+		o.MaxDelay = time.Minute
+	})
+	attempts.reset()
+	err := waiter.Wait(ctx, &ec2.DescribeImagesInput{
+		ImageIds: imageIds,
+	}, c.waitDuration)
+	attempts.emit()
+	if err != nil {
+		return nil, err
+	}
+
+	c.Printf("Instance group %v: images %v have been created\n", *group.Name, imageIds)
+	return imageIds, nil
+}
@@ -0,0 +1,150 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// CloudWatchAPI is the subset of the CloudWatch client operations used by the curator.
+type CloudWatchAPI interface {
+	GetMetricStatistics(context.Context, *cloudwatch.GetMetricStatisticsInput, ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricStatisticsOutput, error)
+	DescribeAlarms(context.Context, *cloudwatch.DescribeAlarmsInput, ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error)
+}
+
+// WaitForInstanceGroupMetricGuards waits until every datapoint of the group metric guards
+// within their lookback windows satisfies the condition. A guard not satisfied within its timeout
+// fails the shutdown, unless it is configured to force the shutdown.
+func WaitForInstanceGroupMetricGuards(ctx context.Context, cloudwatchClient CloudWatchAPI, group types.Group) error {
+	return packageCurator().waitForInstanceGroupMetricGuards(ctx, cloudwatchClient, group)
+}
+
+// waitForInstanceGroupMetricGuards is WaitForInstanceGroupMetricGuards with the output and the wait duration of the Curator
+func (c *Curator) waitForInstanceGroupMetricGuards(ctx context.Context, cloudwatchClient CloudWatchAPI, group types.Group) error {
+	for _, g := range group.MetricGuards {
+		name := aws.ToString(g.Name)
+		if name == "" {
+			name = *g.Metric
+		}
+
+		period := time.Minute
+		if g.Period != nil {
+			period = *g.Period
+		}
+		lookback := 5 * time.Minute
+		if g.Lookback != nil {
+			lookback = *g.Lookback
+		}
+		timeout := c.waitDuration
+		if g.Timeout != nil {
+			timeout = *g.Timeout
+		}
+
+		var last []float64
+		err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), timeout, period, max(period, time.Minute), c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+			values, err := getMetricValues(ctx, cloudwatchClient, g, period, lookback, apiOptions)
+			if err != nil {
+				return false, err
+			}
+			last = values
+
+			if len(values) == 0 {
+				return true, nil
+			}
+			for _, v := range values {
+				if !compareMetric(v, *g.Comparison, *g.Threshold) {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			if !errors.Is(err, ErrWaitTimeout) {
+				return err
+			}
+			if g.OnTimeout != nil && *g.OnTimeout == types.MetricGuardTimeoutForce {
+				c.Printf("Instance group %v: metric guard %v has not been satisfied within %v, forcing the shutdown: %v\n", *group.Name, name, timeout.String(), last)
+				continue
+			}
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("metric guard %v of instance group %v, last datapoints %v", name, *group.Name, last)}
+		}
+
+		c.Printf("Instance group %v: metric guard %v has been satisfied: %v\n", *group.Name, name, last)
+	}
+
+	return nil
+}
+
+// getMetricValues returns the statistic values of the datapoints within the lookback window
+func getMetricValues(ctx context.Context, cloudwatchClient CloudWatchAPI, g types.MetricGuard, period, lookback time.Duration, apiOptions []func(*middleware.Stack) error) ([]float64, error) {
+	statistic := cwTypes.StatisticAverage
+	if g.Statistic != nil {
+		statistic = cwTypes.Statistic(*g.Statistic)
+	}
+
+	dimensions := make([]cwTypes.Dimension, 0, len(g.Dimensions))
+	for k, v := range g.Dimensions {
+		dimensions = append(dimensions, cwTypes.Dimension{Name: aws.String(k), Value: aws.String(v)})
+	}
+
+	now := time.Now()
+	output, err := cloudwatchClient.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  g.Namespace,
+		MetricName: g.Metric,
+		Dimensions: dimensions,
+		StartTime:  aws.Time(now.Add(-lookback)),
+		EndTime:    aws.Time(now),
+		Period:     aws.Int32(int32(period.Seconds())),
+		Statistics: []cwTypes.Statistic{statistic},
+	}, func(o *cloudwatch.Options) {
+		o.APIOptions = append(o.APIOptions, apiOptions...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, 0, len(output.Datapoints))
+	for _, d := range output.Datapoints {
+		var v *float64
+		switch statistic {
+		case cwTypes.StatisticSum:
+			v = d.Sum
+		case cwTypes.StatisticMinimum:
+			v = d.Minimum
+		case cwTypes.StatisticMaximum:
+			v = d.Maximum
+		case cwTypes.StatisticSampleCount:
+			v = d.SampleCount
+		default:
+			v = d.Average
+		}
+		if v != nil {
+			values = append(values, *v)
+		}
+	}
+	return values, nil
+}
+
+func compareMetric(value float64, comparison string, threshold float64) bool {
+	switch comparison {
+	case "lt":
+		return value < threshold
+	case "lte":
+		return value <= threshold
+	case "eq":
+		return value == threshold
+	case "gte":
+		return value >= threshold
+	case "gt":
+		return value > threshold
+	}
+	return false
+}
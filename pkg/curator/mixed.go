@@ -0,0 +1,61 @@
+package curator
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+)
+
+// instanceCapacity returns the capacity units of the Auto Scaling instances counted towards the sizes
+// of the Auto Scaling Group. Groups with a mixed instances policy may weight the instances by their
+// instance type, or count the vCPUs or the memory of the instances with the attribute-based selection,
+// while every instance counts as a single unit otherwise.
+func instanceCapacity(g asTypes.AutoScalingGroup, instanceIds []string) int32 {
+	requested := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		requested[id] = true
+	}
+
+	var capacity int32
+	for _, i := range g.Instances {
+		if !requested[aws.ToString(i.InstanceId)] {
+			continue
+		}
+
+		weight, err := strconv.Atoi(aws.ToString(i.WeightedCapacity))
+		if err != nil || weight <= 0 {
+			weight = 1
+		}
+		capacity += int32(weight)
+	}
+	return capacity
+}
+
+// groupCapacity returns the capacity units of all the instances of the Auto Scaling Group
+func groupCapacity(g asTypes.AutoScalingGroup) int32 {
+	instanceIds := make([]string, 0, len(g.Instances))
+	for _, i := range g.Instances {
+		instanceIds = append(instanceIds, aws.ToString(i.InstanceId))
+	}
+	return instanceCapacity(g, instanceIds)
+}
+
+// warnMixedInstancesPolicy warns that the sizes of the Auto Scaling Group with a mixed instances policy
+// are adjusted by the capacity units of the instances, and that the group may replace the instances
+// returned to service to restore its On-Demand and Spot distribution or its Spot capacity.
+func (c *Curator) warnMixedInstancesPolicy(g asTypes.AutoScalingGroup, instanceIds []string) {
+	if g.MixedInstancesPolicy == nil {
+		return
+	}
+
+	units := "capacity units"
+	if g.DesiredCapacityType != nil {
+		units = aws.ToString(g.DesiredCapacityType)
+	}
+	c.Printf("Warning: ASG %v has a mixed instances policy, its sizes are adjusted by %v %v of the instances %v\n",
+		*g.AutoScalingGroupName, instanceCapacity(g, instanceIds), units, instanceIds)
+	if aws.ToBool(g.CapacityRebalance) {
+		c.Printf("Warning: ASG %v has capacity rebalancing enabled, it may replace the Spot instances returned to service\n", *g.AutoScalingGroupName)
+	}
+}
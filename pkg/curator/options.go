@@ -0,0 +1,251 @@
+package curator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// DefaultWaiterMaxDelay is the maximum delay between the attempts of the Auto Scaling instance waiters
+const DefaultWaiterMaxDelay time.Duration = time.Minute
+
+// HookFunc is called with the group and its instance IDs at a step of the group orchestration.
+// An error returned by the hook fails the orchestration.
+type HookFunc func(ctx context.Context, group types.Group, instanceIds []string) error
+
+// Hooks are the functions called at the steps of the group orchestration
+type Hooks struct {
+	// BeforeShutdown is called once the group is allowed to be shut down,
+	// before its instances are deregistered from the target groups.
+	BeforeShutdown HookFunc
+
+	// AfterShutdown is called once the group is shut down.
+	AfterShutdown HookFunc
+
+	// BeforeStartup is called before the group instances are started.
+	BeforeStartup HookFunc
+
+	// AfterStartup is called once the group is started and ready.
+	AfterStartup HookFunc
+}
+
+// Curator orchestrates the groups of a stack with the configured clients and settings.
+// A Curator is constructed with New and the functional options.
+type Curator struct {
+	ec2         EC2API
+	addresses   EC2AddressesAPI
+	autoscaling AutoScalingAPI
+	policies    AutoScalingPoliciesAPI
+	elbv2       ELBv2API
+	route53     Route53API
+	rds         RDSAPI
+	ssm         SSMAPI
+	cloudwatch  CloudWatchAPI
+	ecs         ECSAPI
+
+	logger         *slog.Logger
+	reporter       Reporter
+	verbosity      Verbosity
+	events         func(Event)
+	waitDuration   time.Duration
+	waiterMaxDelay time.Duration
+	dryRun         bool
+	adjustSizes    bool
+	hooks          Hooks
+	sizes          SizeRecorder
+}
+
+// Option configures a Curator
+type Option func(*Curator)
+
+// New constructs a Curator. Unless configured, the waits last up to DefaultWaitDuration,
+// the Auto Scaling Group sizes are adjusted when required, the progress is printed to stdout
+// and neither the messages are logged nor the events delivered.
+func New(optFns ...Option) *Curator {
+	c := &Curator{
+		reporter:       stdoutReporter,
+		verbosity:      VerbosityNormal,
+		waitDuration:   DefaultWaitDuration,
+		waiterMaxDelay: DefaultWaiterMaxDelay,
+		adjustSizes:    true,
+	}
+	for _, fn := range optFns {
+		fn(c)
+	}
+	return c
+}
+
+// WithEC2 sets the EC2 client.
+func WithEC2(client EC2API) Option {
+	return func(c *Curator) {
+		c.ec2 = client
+	}
+}
+
+// WithEC2Addresses sets the EC2 client of the Elastic IPs, required by the groups re-associating their Elastic IPs.
+func WithEC2Addresses(client EC2AddressesAPI) Option {
+	return func(c *Curator) {
+		c.addresses = client
+	}
+}
+
+// WithAutoScaling sets the Auto Scaling client.
+func WithAutoScaling(client AutoScalingAPI) Option {
+	return func(c *Curator) {
+		c.autoscaling = client
+	}
+}
+
+// WithAutoScalingPolicies sets the Auto Scaling client of the predictive scaling policies.
+func WithAutoScalingPolicies(client AutoScalingPoliciesAPI) Option {
+	return func(c *Curator) {
+		c.policies = client
+	}
+}
+
+// WithELBv2 sets the Elastic Load Balancing client of the target groups.
+func WithELBv2(client ELBv2API) Option {
+	return func(c *Curator) {
+		c.elbv2 = client
+	}
+}
+
+// WithRoute53 sets the Route53 client of the health checks.
+func WithRoute53(client Route53API) Option {
+	return func(c *Curator) {
+		c.route53 = client
+	}
+}
+
+// WithRDS sets the RDS client of the DocumentDB and Neptune clusters.
+func WithRDS(client RDSAPI) Option {
+	return func(c *Curator) {
+		c.rds = client
+	}
+}
+
+// WithSSM sets the SSM client of the commands and automations.
+func WithSSM(client SSMAPI) Option {
+	return func(c *Curator) {
+		c.ssm = client
+	}
+}
+
+// WithCloudWatch sets the CloudWatch client of the metric guards.
+func WithCloudWatch(client CloudWatchAPI) Option {
+	return func(c *Curator) {
+		c.cloudwatch = client
+	}
+}
+
+// WithECS sets the ECS client of the services awaited after the startup.
+func WithECS(client ECSAPI) Option {
+	return func(c *Curator) {
+		c.ecs = client
+	}
+}
+
+// WithLogger sets the structured logger receiving every message and waiter attempt of the Curator
+// regardless of its verbosity. A nil logger disables structured logging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Curator) {
+		c.logger = logger
+	}
+}
+
+// WithReporter sets the reporter receiving the messages and the tables of the Curator.
+// A nil reporter keeps the default pretty output to stdout.
+func WithReporter(r Reporter) Option {
+	return func(c *Curator) {
+		if r != nil {
+			c.reporter = r
+		}
+	}
+}
+
+// WithVerbosity sets the verbosity of the output of the Curator.
+func WithVerbosity(v Verbosity) Option {
+	return func(c *Curator) {
+		c.verbosity = v
+	}
+}
+
+// WithEventHandler sets a function receiving the orchestration events of the Curator as they happen.
+// A nil handler disables the events.
+func WithEventHandler(h func(Event)) Option {
+	return func(c *Curator) {
+		c.events = h
+	}
+}
+
+// WithWaitDuration sets the maximum duration of the waits of the Curator, e.g. of the instances
+// to stop and start, unless overridden by the group waiters.
+func WithWaitDuration(d time.Duration) Option {
+	return func(c *Curator) {
+		c.waitDuration = d
+	}
+}
+
+// WithWaiterMaxDelay sets the maximum delay between the attempts of the Auto Scaling instance waiters.
+func WithWaiterMaxDelay(d time.Duration) Option {
+	return func(c *Curator) {
+		c.waiterMaxDelay = d
+	}
+}
+
+// WithDryRun makes the Curator describe the groups and plan the changes without applying them.
+func WithDryRun(dryRun bool) Option {
+	return func(c *Curator) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithSizeAdjustment sets whether the MinSize and MaxSize of the Auto Scaling Groups are adjusted
+// to put the instances into Standby and return them to service. Without the adjustment, the Auto Scaling Groups
+// must already allow the changes of their desired capacity.
+func WithSizeAdjustment(adjustSizes bool) Option {
+	return func(c *Curator) {
+		c.adjustSizes = adjustSizes
+	}
+}
+
+// WithSizeRecorder sets the recorder of the Auto Scaling Group sizes lowered by the shutdown,
+// restored by the startup of a later run even if the shutdown run has not been resumed.
+func WithSizeRecorder(recorder SizeRecorder) Option {
+	return func(c *Curator) {
+		c.sizes = recorder
+	}
+}
+
+// WithHooks sets the functions called at the steps of the group orchestration.
+func WithHooks(hooks Hooks) Option {
+	return func(c *Curator) {
+		c.hooks = hooks
+	}
+}
+
+// runHook calls the hook, if set
+func runHook(ctx context.Context, hook HookFunc, group types.Group, instanceIds []string) error {
+	if hook == nil {
+		return nil
+	}
+	return hook(ctx, group, instanceIds)
+}
+
+// packageCurator returns the Curator of the package-level functions, reporting, logging and delivering
+// the events as configured by SetReporter, SetVerbosity, SetLogger and SetEventHandler
+func packageCurator(optFns ...Option) *Curator {
+	return New(append([]Option{
+		WithReporter(reporter),
+		WithVerbosity(verbosity),
+		WithLogger(logger),
+		WithEventHandler(eventHandler),
+	}, optFns...)...)
+}
+
+// defaultCurator returns the Curator of the deprecated package-level functions of the Auto Scaling client
+func defaultCurator(autoscalingClient AutoScalingAPI, optFns ...Option) *Curator {
+	return packageCurator(append([]Option{WithAutoScaling(autoscalingClient)}, optFns...)...)
+}
@@ -0,0 +1,362 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// CleanupTimeout is the maximum duration of the best-effort cleanup of a cancelled orchestration
+const CleanupTimeout time.Duration = 5 * time.Minute
+
+// ShutdownGroup shuts the resolved group instances down: checks the group guards, runs the steps preceding
+// the shutdown, puts the Auto Scaling instances into Standby, stops the instances and runs the gates
+// of the stopped group. Once the context is cancelled before the instances are stopped, the group is returned
+// to service: the instances are started again, returned to service and registered with the target groups.
+func (c *Curator) ShutdownGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.CheckInstanceGroupGuards(ctx, c.ec2, c.autoscaling, group, instanceIds); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		c.Printf("Dry run: instance group %v would be shut down\n", *group.Name)
+		return nil
+	}
+
+	if err := c.BeginGroupShutdown(ctx, group, instanceIds); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, false, err)
+	}
+
+	if err := c.PrepareGroupForShutdown(ctx, group); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, false, err)
+	}
+
+	if err := c.StopGroup(ctx, group, instanceIds); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, true, err)
+	}
+
+	return c.CompleteGroupShutdown(ctx, group, instanceIds)
+}
+
+// StandbyGroup runs the first phase of the two-phase shutdown of the resolved group instances:
+// checks the group guards, runs the steps preceding the shutdown and puts the Auto Scaling instances
+// into Standby. StopStandbyGroup runs the second phase. Once the context is cancelled, the group is returned
+// to service as by ShutdownGroup.
+func (c *Curator) StandbyGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.CheckInstanceGroupGuards(ctx, c.ec2, c.autoscaling, group, instanceIds); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		c.Printf("Dry run: instances of instance group %v would be put into Standby\n", *group.Name)
+		return nil
+	}
+
+	if err := c.BeginGroupShutdown(ctx, group, instanceIds); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, false, err)
+	}
+
+	if err := c.PrepareGroupForShutdown(ctx, group); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, false, err)
+	}
+	return nil
+}
+
+// StopStandbyGroup runs the second phase of the two-phase shutdown of the group put into Standby
+// by StandbyGroup: stops the instances and completes the group shutdown. Once the context is cancelled
+// before the instances are stopped, the group is returned to service as by ShutdownGroup.
+func (c *Curator) StopStandbyGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if c.dryRun {
+		c.Printf("Dry run: instances of instance group %v would be stopped\n", *group.Name)
+		return nil
+	}
+
+	if err := c.StopGroup(ctx, group, instanceIds); err != nil {
+		return c.undoCancelledShutdown(ctx, group, instanceIds, true, err)
+	}
+
+	return c.CompleteGroupShutdown(ctx, group, instanceIds)
+}
+
+// undoCancelledShutdown returns the group whose shutdown has been cancelled to service: starts the instances
+// stopped meanwhile, if any, returns the Auto Scaling instances to service restoring the sizes of their
+// Auto Scaling Groups, registers the instances with the target groups and points the DNS records at them.
+// The steps are idempotent, so that the group is returned to service whichever step has been cancelled.
+// The compensation is best-effort and lasts up to CleanupTimeout; the failures other than the cancellation
+// are returned as they are.
+func (c *Curator) undoCancelledShutdown(ctx context.Context, group types.Group, instanceIds []string, stopping bool, shutdownErr error) error {
+	if ctx.Err() == nil {
+		return shutdownErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CleanupTimeout)
+	defer cancel()
+
+	c.Printf("Instance group %v: shutdown has been cancelled, returning the group to service: %v\n", *group.Name, shutdownErr)
+	var errs []error
+
+	if stopping && !group.SkipEC2 {
+		if err := c.restartStoppingInstances(ctx, group, instanceIds); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := c.PrepareGroupForStartup(ctx, group); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.registerInstanceGroupTargets(ctx, c.elbv2, group, instanceIds); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.upsertInstanceGroupRecords(ctx, c.route53, c.ec2, group, instanceIds); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(shutdownErr, fmt.Errorf("unable to return instance group %v to service: %w", *group.Name, errors.Join(errs...)))
+	}
+	c.Printf("Instance group %v: has been returned to service\n", *group.Name)
+	return shutdownErr
+}
+
+// restartStoppingInstances waits for the group instances still stopping to stop, as they cannot be started
+// meanwhile, and starts the group instances, re-associating their Elastic IPs
+func (c *Curator) restartStoppingInstances(ctx context.Context, group types.Group, instanceIds []string) error {
+	chunks := chunkInstanceIds(instanceIds, maxEC2InstanceIds)
+	stopping, err := stoppingInstanceIds(ctx, c.ec2, chunks)
+	if err != nil {
+		return err
+	}
+	if len(stopping) > 0 {
+		deadline := time.Now().Add(groupWaitDuration(group, c.waitDuration))
+		if _, err := c.waitForInstancesStopped(ctx, c.ec2, group, chunkInstanceIds(stopping, maxEC2InstanceIds), deadline); err != nil {
+			return err
+		}
+	}
+
+	if err := c.startInstanceGroup(ctx, c.ec2, group, instanceIds); err != nil {
+		return err
+	}
+	return c.reassociateInstanceGroupElasticIPs(ctx, c.addresses, group, instanceIds)
+}
+
+// FreezeGroup puts the InService Auto Scaling instances of the resolved group into Standby without
+// stopping them: checks the group guards and lowers the MinSize of their Auto Scaling Groups when required.
+// ThawGroup returns the frozen instances to service.
+func (c *Curator) FreezeGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.CheckInstanceGroupGuards(ctx, c.ec2, c.autoscaling, group, instanceIds); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		c.Printf("Dry run: instances of instance group %v would be frozen\n", *group.Name)
+		return nil
+	}
+
+	return c.PrepareGroupForShutdown(ctx, group)
+}
+
+// ThawGroup returns the Standby Auto Scaling instances of the resolved group to service following
+// the startup failure policy of the group, without starting them.
+func (c *Curator) ThawGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.CheckInstanceGroupGuards(ctx, c.ec2, c.autoscaling, group, instanceIds); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		c.Printf("Dry run: instances of instance group %v would be thawed\n", *group.Name)
+		return nil
+	}
+
+	return c.returnGroupToService(ctx, group)
+}
+
+// StartupGroup starts the resolved group instances up: checks the group guards, runs the steps preceding
+// the startup, starts the instances, returns the Auto Scaling instances to service following the startup
+// failure policy of the group and runs the readiness gates of the started group.
+func (c *Curator) StartupGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.CheckInstanceGroupGuards(ctx, c.ec2, c.autoscaling, group, instanceIds); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		c.Printf("Dry run: instance group %v would be started up\n", *group.Name)
+		return nil
+	}
+
+	if err := c.BeginGroupStartup(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.StartGroup(ctx, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.returnGroupToService(ctx, group); err != nil {
+		return err
+	}
+
+	return c.CompleteGroupStartup(ctx, group, instanceIds)
+}
+
+// BeginGroupShutdown runs the steps preceding the shutdown of a group: waits for the metric guards,
+// runs the before-shutdown automations and the BeforeShutdown hook, points the DNS records at their
+// maintenance targets and deregisters the instances from the target groups.
+func (c *Curator) BeginGroupShutdown(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.waitForInstanceGroupMetricGuards(ctx, c.cloudwatch, group); err != nil {
+		return err
+	}
+
+	if err := c.runInstanceGroupAutomations(ctx, c.ssm, group, instanceIds, types.AutomationPhaseBeforeShutdown); err != nil {
+		return err
+	}
+
+	if err := runHook(ctx, c.hooks.BeforeShutdown, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.downgradeInstanceGroupRecords(ctx, c.route53, group); err != nil {
+		return err
+	}
+
+	return c.deregisterInstanceGroupTargets(ctx, c.elbv2, group, instanceIds)
+}
+
+// StopGroup records the Elastic IP associations and shuts the group instances down gracefully, if configured,
+// and stops them, unless the group skips the EC2 phase.
+func (c *Curator) StopGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if group.SkipEC2 {
+		c.Printf("Instance group %v: stopping of the instances is skipped\n", *group.Name)
+		return nil
+	}
+
+	if err := c.recordInstanceGroupElasticIPs(ctx, c.addresses, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.shutdownInstanceGroupServices(ctx, c.ssm, group, instanceIds); err != nil {
+		return err
+	}
+
+	return c.stopInstanceGroup(ctx, c.ec2, group, instanceIds)
+}
+
+// CompleteGroupShutdown runs the gates of a stopped group: stops the group clusters, waits for
+// the Route53 health checks to turn unhealthy, if configured, waits for the after-shutdown conditions
+// and runs the after-shutdown automations and the AfterShutdown hook.
+func (c *Curator) CompleteGroupShutdown(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.stopGroupClusters(ctx, c.rds, group); err != nil {
+		return err
+	}
+
+	if group.Route53HealthChecks != nil && group.Route53HealthChecks.WaitUnhealthyOnShutdown {
+		if err := c.waitForRoute53HealthChecks(ctx, c.route53, group, false); err != nil {
+			return err
+		}
+	}
+
+	if err := c.waitForInstanceGroupConditions(ctx, c.ec2, c.autoscaling, group, instanceIds, types.WaitConditionPhaseAfterShutdown); err != nil {
+		return err
+	}
+
+	if err := c.runInstanceGroupAutomations(ctx, c.ssm, group, instanceIds, types.AutomationPhaseAfterShutdown); err != nil {
+		return err
+	}
+
+	return runHook(ctx, c.hooks.AfterShutdown, group, instanceIds)
+}
+
+// BeginGroupStartup runs the steps preceding the startup of a group: runs the before-startup automations,
+// starts the group clusters and runs the BeforeStartup hook.
+func (c *Curator) BeginGroupStartup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.runInstanceGroupAutomations(ctx, c.ssm, group, instanceIds, types.AutomationPhaseBeforeStartup); err != nil {
+		return err
+	}
+
+	if err := c.startGroupClusters(ctx, c.rds, group); err != nil {
+		return err
+	}
+
+	return runHook(ctx, c.hooks.BeforeStartup, group, instanceIds)
+}
+
+// StartGroup starts the group instances within the capacity reserved for them, if configured,
+// and waits for their boot-time provisioning, if configured, unless the group skips the EC2 phase.
+func (c *Curator) StartGroup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if group.SkipEC2 {
+		c.Printf("Instance group %v: starting of the instances is skipped\n", *group.Name)
+		return nil
+	}
+
+	reservationIds, err := c.reserveInstanceGroupCapacity(ctx, c.ec2, group, instanceIds)
+	if err != nil {
+		return err
+	}
+
+	err = c.startInstanceGroup(ctx, c.ec2, group, instanceIds)
+	if releaseErr := c.releaseInstanceGroupCapacity(ctx, c.ec2, group, reservationIds); releaseErr != nil {
+		err = errors.Join(err, releaseErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.WaitForInstanceGroupBoot(ctx, c.ssm, group, instanceIds)
+}
+
+// CompleteGroupStartup runs the readiness gates of a started group: re-associates the Elastic IPs,
+// if configured, checks the instance health, verifies the group, waits for the after-startup conditions,
+// points the DNS records at the instances, registers the instances with the target groups,
+// waits for the Route53 health checks, the ECS services and the CloudWatch alarms and runs
+// the after-startup automations and the AfterStartup hook, and then waits for the group to warm up.
+func (c *Curator) CompleteGroupStartup(ctx context.Context, group types.Group, instanceIds []string) error {
+	if err := c.reassociateInstanceGroupElasticIPs(ctx, c.addresses, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.CheckInstanceGroupHealth(ctx, c.ec2, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.VerifyInstanceGroup(ctx, c.ssm, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.waitForInstanceGroupConditions(ctx, c.ec2, c.autoscaling, group, instanceIds, types.WaitConditionPhaseAfterStartup); err != nil {
+		return err
+	}
+
+	if err := c.upsertInstanceGroupRecords(ctx, c.route53, c.ec2, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.registerInstanceGroupTargets(ctx, c.elbv2, group, instanceIds); err != nil {
+		return err
+	}
+
+	if err := c.waitForRoute53HealthChecks(ctx, c.route53, group, true); err != nil {
+		return err
+	}
+
+	if err := c.waitForGroupECSServices(ctx, c.ecs, group); err != nil {
+		return err
+	}
+
+	if err := c.waitForInstanceGroupAlarms(ctx, c.cloudwatch, group); err != nil {
+		return err
+	}
+
+	if err := c.runInstanceGroupAutomations(ctx, c.ssm, group, instanceIds, types.AutomationPhaseAfterStartup); err != nil {
+		return err
+	}
+
+	if err := runHook(ctx, c.hooks.AfterStartup, group, instanceIds); err != nil {
+		return err
+	}
+
+	return c.waitForGroupWarmup(ctx, group)
+}
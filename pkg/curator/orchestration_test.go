@@ -0,0 +1,208 @@
+package curator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator/fake"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// addAutoScalingGroup adds the running InService instances and their Auto Scaling Group to the fake
+// and returns the instances
+func addAutoScalingGroup(cloud *fake.Cloud, name string, minSize, maxSize int32, instanceIds ...string) []ec2Types.Instance {
+	instances := make([]ec2Types.Instance, 0, len(instanceIds))
+	members := make([]asTypes.Instance, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		instance := ec2Types.Instance{InstanceId: aws.String(id)}
+		cloud.AddInstance(instance)
+		instances = append(instances, instance)
+		members = append(members, asTypes.Instance{
+			InstanceId:     aws.String(id),
+			LifecycleState: asTypes.LifecycleStateInService,
+		})
+	}
+	cloud.AddAutoScalingGroup(asTypes.AutoScalingGroup{
+		AutoScalingGroupName: aws.String(name),
+		MinSize:              aws.Int32(minSize),
+		MaxSize:              aws.Int32(maxSize),
+		DesiredCapacity:      aws.Int32(int32(len(instanceIds))),
+		Instances:            members,
+	})
+	return instances
+}
+
+// newCurator constructs a Curator over the fake, discarding its messages
+func newCurator(ec2Client curator.EC2API, autoscalingClient curator.AutoScalingAPI, optFns ...curator.Option) *curator.Curator {
+	optFns = append([]curator.Option{
+		curator.WithEC2(ec2Client),
+		curator.WithAutoScaling(autoscalingClient),
+		curator.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}, optFns...)
+	return curator.New(optFns...)
+}
+
+func groupInstanceIds(instances []ec2Types.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, i := range instances {
+		ids = append(ids, *i.InstanceId)
+	}
+	return ids
+}
+
+func checkInstances(t *testing.T, cloud *fake.Cloud, asgName string, lifecycleState asTypes.LifecycleState, state ec2Types.InstanceStateName, instanceIds ...string) {
+	t.Helper()
+
+	g, ok := cloud.AutoScalingGroup(asgName)
+	if !ok {
+		t.Fatalf("ASG %v does not exist", asgName)
+	}
+	for _, id := range instanceIds {
+		for _, i := range g.Instances {
+			if *i.InstanceId == id && i.LifecycleState != lifecycleState {
+				t.Errorf("instance %v of ASG %v is %v, expected %v", id, asgName, i.LifecycleState, lifecycleState)
+			}
+		}
+
+		i, ok := cloud.Instance(id)
+		if !ok {
+			t.Fatalf("instance %v does not exist", id)
+		}
+		if i.State.Name != state {
+			t.Errorf("instance %v is %v, expected %v", id, i.State.Name, state)
+		}
+	}
+}
+
+func checkSizes(t *testing.T, cloud *fake.Cloud, asgName string, minSize, desiredCapacity int32) {
+	t.Helper()
+
+	g, ok := cloud.AutoScalingGroup(asgName)
+	if !ok {
+		t.Fatalf("ASG %v does not exist", asgName)
+	}
+	if *g.MinSize != minSize {
+		t.Errorf("MinSize of ASG %v is %v, expected %v", asgName, *g.MinSize, minSize)
+	}
+	if *g.DesiredCapacity != desiredCapacity {
+		t.Errorf("DesiredCapacity of ASG %v is %v, expected %v", asgName, *g.DesiredCapacity, desiredCapacity)
+	}
+}
+
+func TestShutdownAndStartupGroup(t *testing.T) {
+	ctx := context.Background()
+	cloud := fake.New()
+	instances := addAutoScalingGroup(cloud, "app", 2, 2, "i-1", "i-2")
+	group := types.Group{Name: aws.String("app"), Instances: instances}
+	instanceIds := groupInstanceIds(instances)
+	c := newCurator(cloud, cloud)
+
+	if err := c.ShutdownGroup(ctx, group, instanceIds); err != nil {
+		t.Fatalf("shutdown has failed: %v", err)
+	}
+	checkInstances(t, cloud, "app", asTypes.LifecycleStateStandby, ec2Types.InstanceStateNameStopped, instanceIds...)
+	checkSizes(t, cloud, "app", 0, 0)
+
+	if err := c.StartupGroup(ctx, group, instanceIds); err != nil {
+		t.Fatalf("startup has failed: %v", err)
+	}
+	checkInstances(t, cloud, "app", asTypes.LifecycleStateInService, ec2Types.InstanceStateNameRunning, instanceIds...)
+	checkSizes(t, cloud, "app", 2, 2)
+}
+
+// sizeRecorder keeps the recorded Auto Scaling Group sizes in memory
+type sizeRecorder map[string][]types.AutoScalingGroupChange
+
+func (r sizeRecorder) RecordShutdownChanges(ctx context.Context, group string, changes []types.AutoScalingGroupChange) error {
+	r[group] = changes
+	return nil
+}
+
+func (r sizeRecorder) ShutdownChanges(ctx context.Context, group string) ([]types.AutoScalingGroupChange, error) {
+	return r[group], nil
+}
+
+func (r sizeRecorder) ForgetShutdownChanges(ctx context.Context, group string) error {
+	delete(r, group)
+	return nil
+}
+
+func TestStartupGroupRestoresMinSize(t *testing.T) {
+	ctx := context.Background()
+	cloud := fake.New()
+	instances := addAutoScalingGroup(cloud, "app", 3, 4, "i-1", "i-2", "i-3")
+	// the group covers a part of the ASG only, so that the startup alone raises the MinSize to 2
+	group := types.Group{Name: aws.String("app"), Instances: instances[:2]}
+	instanceIds := groupInstanceIds(group.Instances)
+	sizes := sizeRecorder{}
+	c := newCurator(cloud, cloud, curator.WithSizeRecorder(sizes))
+
+	if err := c.ShutdownGroup(ctx, group, instanceIds); err != nil {
+		t.Fatalf("shutdown has failed: %v", err)
+	}
+	checkInstances(t, cloud, "app", asTypes.LifecycleStateStandby, ec2Types.InstanceStateNameStopped, instanceIds...)
+	checkInstances(t, cloud, "app", asTypes.LifecycleStateInService, ec2Types.InstanceStateNameRunning, "i-3")
+	checkSizes(t, cloud, "app", 1, 1)
+	if len(sizes["app"]) != 1 {
+		t.Fatalf("recorded shutdown changes are %v, expected the change of ASG app", sizes["app"])
+	}
+
+	if err := c.StartupGroup(ctx, group, instanceIds); err != nil {
+		t.Fatalf("startup has failed: %v", err)
+	}
+	checkInstances(t, cloud, "app", asTypes.LifecycleStateInService, ec2Types.InstanceStateNameRunning, instanceIds...)
+	checkSizes(t, cloud, "app", 3, 3)
+	if _, ok := sizes["app"]; ok {
+		t.Errorf("recorded shutdown changes %v are kept after the startup", sizes["app"])
+	}
+}
+
+// cancellingCloud cancels the run once the first instances have entered Standby
+type cancellingCloud struct {
+	*fake.Cloud
+	cancel context.CancelFunc
+}
+
+func (c cancellingCloud) EnterStandby(ctx context.Context, params *autoscaling.EnterStandbyInput, optFns ...func(*autoscaling.Options)) (*autoscaling.EnterStandbyOutput, error) {
+	output, err := c.Cloud.EnterStandby(ctx, params, optFns...)
+	c.cancel()
+	return output, err
+}
+
+func TestShutdownGroupUndoesCancelledStandby(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cloud := fake.New()
+	instances := append(addAutoScalingGroup(cloud, "app-a", 1, 1, "i-1"), addAutoScalingGroup(cloud, "app-b", 1, 1, "i-2")...)
+	group := types.Group{Name: aws.String("app"), Instances: instances}
+	instanceIds := groupInstanceIds(instances)
+	c := newCurator(cloud, cancellingCloud{Cloud: cloud, cancel: cancel})
+
+	err := c.ShutdownGroup(ctx, group, instanceIds)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("shutdown error is %v, expected the cancellation", err)
+	}
+
+	standby := 0
+	for _, call := range cloud.Calls() {
+		if call.Operation == "EnterStandby" {
+			standby++
+		}
+	}
+	if standby != 1 {
+		t.Errorf("EnterStandby has been called %v times, expected once before the cancellation", standby)
+	}
+	checkInstances(t, cloud, "app-a", asTypes.LifecycleStateInService, ec2Types.InstanceStateNameRunning, "i-1")
+	checkInstances(t, cloud, "app-b", asTypes.LifecycleStateInService, ec2Types.InstanceStateNameRunning, "i-2")
+	checkSizes(t, cloud, "app-a", 1, 1)
+	checkSizes(t, cloud, "app-b", 1, 1)
+}
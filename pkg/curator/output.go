@@ -0,0 +1,97 @@
+package curator
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+// Verbosity controls how much the curator prints
+type Verbosity int
+
+const (
+	// VerbosityQuiet prints only summaries
+	VerbosityQuiet Verbosity = iota - 1
+	// VerbosityNormal prints the progress of the orchestration
+	VerbosityNormal
+	// VerbosityVerbose additionally logs waiter attempts
+	VerbosityVerbose
+	// VerbosityDebug additionally logs AWS requests and responses
+	VerbosityDebug
+)
+
+// verbosity is the verbosity of the package-level functions
+var verbosity = VerbosityNormal
+
+// logger receives every message of the package-level functions regardless of the verbosity, if set
+var logger *slog.Logger
+
+// plainPrinter formats messages for the logger without colors
+var plainPrinter = newPlainPrinter()
+
+func newPlainPrinter() *pp.PrettyPrinter {
+	printer := pp.New()
+	printer.SetColoringEnabled(false)
+	printer.SetExportedOnly(true)
+	return printer
+}
+
+// SetVerbosity sets the verbosity of the output of the package-level functions.
+// A Curator is configured with WithVerbosity instead.
+func SetVerbosity(v Verbosity) {
+	verbosity = v
+}
+
+// GetVerbosity returns the verbosity of the output of the package-level functions.
+func GetVerbosity() Verbosity {
+	return verbosity
+}
+
+// SetLogger sets a structured logger receiving every message and waiter attempt of the package-level functions
+// regardless of the verbosity. A nil logger disables structured logging. A Curator is configured with WithLogger instead.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// GetLogger returns the structured logger of the package-level functions, if any.
+func GetLogger() *slog.Logger {
+	return logger
+}
+
+// Printf prints a progress message of the package-level functions unless the output is quiet.
+func Printf(format string, a ...interface{}) {
+	packageCurator().Printf(format, a...)
+}
+
+// Summaryf prints a summary message of the package-level functions regardless of the verbosity.
+func Summaryf(format string, a ...interface{}) {
+	packageCurator().Summaryf(format, a...)
+}
+
+// Printf reports a progress message unless the output of the Curator is quiet.
+func (c *Curator) Printf(format string, a ...interface{}) {
+	c.log(slog.LevelInfo, format, a...)
+	if c.verbosity >= VerbosityNormal {
+		c.reporter.Report(MessageProgress, format, a...)
+	}
+}
+
+// Summaryf reports a summary message regardless of the verbosity of the Curator.
+func (c *Curator) Summaryf(format string, a ...interface{}) {
+	c.log(slog.LevelInfo, format, a...)
+	c.reporter.Report(MessageSummary, format, a...)
+}
+
+func (c *Curator) log(level slog.Level, format string, a ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.Log(context.Background(), level, strings.TrimSuffix(plainPrinter.Sprintf(format, a...), "\n"))
+}
+
+// logWaitAttempts reports whether waiter attempts should be logged.
+func (c *Curator) logWaitAttempts() bool {
+	return c.verbosity >= VerbosityVerbose || c.logger != nil
+}
@@ -0,0 +1,169 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ForecastOnlyPoliciesTag records the predictive scaling policies of an Auto Scaling Group switched
+// to forecast only by the shutdown, to be switched back to forecast and scale by the startup
+const ForecastOnlyPoliciesTag string = DefaultRunTagsPrefix + "forecast-only-policies"
+
+// predictiveScalingPolicyType is the type of the predictive scaling policies
+const predictiveScalingPolicyType = "PredictiveScaling"
+
+// AutoScalingPoliciesAPI is the subset of the Auto Scaling client operations pausing the predictive scaling policies.
+// Like AutoScalingTagsAPI, it is kept apart from AutoScalingAPI for its DeleteTags operation.
+type AutoScalingPoliciesAPI interface {
+	autoscaling.DescribePoliciesAPIClient
+
+	PutScalingPolicy(context.Context, *autoscaling.PutScalingPolicyInput, ...func(*autoscaling.Options)) (*autoscaling.PutScalingPolicyOutput, error)
+	CreateOrUpdateTags(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error)
+	DeleteTags(context.Context, *autoscaling.DeleteTagsInput, ...func(*autoscaling.Options)) (*autoscaling.DeleteTagsOutput, error)
+}
+
+// describePredictivePolicies returns the predictive scaling policies of the Auto Scaling Group
+func describePredictivePolicies(ctx context.Context, client AutoScalingPoliciesAPI, asgName *string) ([]asTypes.ScalingPolicy, error) {
+	policies := make([]asTypes.ScalingPolicy, 0)
+	paginator := autoscaling.NewDescribePoliciesPaginator(client, &autoscaling.DescribePoliciesInput{
+		AutoScalingGroupName: asgName,
+		PolicyTypes:          []string{predictiveScalingPolicyType},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, output.ScalingPolicies...)
+	}
+	return policies, nil
+}
+
+// setPredictiveScalingMode updates the mode of the predictive scaling policy, keeping its configuration
+func setPredictiveScalingMode(ctx context.Context, client AutoScalingPoliciesAPI, policy asTypes.ScalingPolicy, mode asTypes.PredictiveScalingMode) error {
+	configuration := *policy.PredictiveScalingConfiguration
+	configuration.Mode = mode
+	_, err := client.PutScalingPolicy(ctx, &autoscaling.PutScalingPolicyInput{
+		AutoScalingGroupName:           policy.AutoScalingGroupName,
+		PolicyName:                     policy.PolicyName,
+		PolicyType:                     policy.PolicyType,
+		PredictiveScalingConfiguration: &configuration,
+	})
+	return err
+}
+
+// pausePredictiveScaling switches the predictive scaling policies of the Auto Scaling Group scaling it
+// to forecast only before the shutdown, so that the forecast capacity does not start the stopped instances
+// again, and records the switched policies in the ForecastOnlyPoliciesTag of the Auto Scaling Group.
+// It returns the names of the switched policies.
+func (c *Curator) pausePredictiveScaling(ctx context.Context, group types.Group, asgName *string) ([]string, error) {
+	if !group.PausePredictiveScaling {
+		return nil, nil
+	}
+	if c.policies == nil {
+		return nil, errors.New("an Auto Scaling policies client is required to pause the predictive scaling")
+	}
+
+	policies, err := describePredictivePolicies(ctx, c.policies, asgName)
+	if err != nil {
+		return nil, err
+	}
+
+	paused := make([]string, 0, len(policies))
+	for _, p := range policies {
+		if p.PredictiveScalingConfiguration == nil || p.PredictiveScalingConfiguration.Mode == asTypes.PredictiveScalingModeForecastOnly {
+			continue
+		}
+		paused = append(paused, *p.PolicyName)
+	}
+	if len(paused) == 0 {
+		return nil, nil
+	}
+
+	// the tag is written first, so that the policies switched before a failure are still restored
+	if _, err := c.policies.CreateOrUpdateTags(ctx, &autoscaling.CreateOrUpdateTagsInput{
+		Tags: []asTypes.Tag{{
+			ResourceId:        asgName,
+			ResourceType:      aws.String("auto-scaling-group"),
+			Key:               aws.String(ForecastOnlyPoliciesTag),
+			Value:             aws.String(strings.Join(paused, ",")),
+			PropagateAtLaunch: aws.Bool(false),
+		}},
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, p := range policies {
+		if !slices.Contains(paused, aws.ToString(p.PolicyName)) {
+			continue
+		}
+		if err := setPredictiveScalingMode(ctx, c.policies, p, asTypes.PredictiveScalingModeForecastOnly); err != nil {
+			return paused, err
+		}
+	}
+	c.Printf("Predictive scaling policies %v of ASG %v have been switched to forecast only\n", paused, *asgName)
+	return paused, nil
+}
+
+// resumePredictiveScaling switches the predictive scaling policies of the Auto Scaling Group recorded in its
+// ForecastOnlyPoliciesTag back to forecast and scale after the startup, and removes the tag
+func (c *Curator) resumePredictiveScaling(ctx context.Context, group types.Group, asgName *string) error {
+	if !group.PausePredictiveScaling {
+		return nil
+	}
+	if c.policies == nil {
+		return errors.New("an Auto Scaling policies client is required to resume the predictive scaling")
+	}
+
+	output, err := c.autoscaling.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{*asgName},
+	})
+	if err != nil {
+		return err
+	}
+
+	var paused []string
+	for _, g := range output.AutoScalingGroups {
+		for _, t := range g.Tags {
+			if aws.ToString(t.Key) == ForecastOnlyPoliciesTag && aws.ToString(t.Value) != "" {
+				paused = strings.Split(aws.ToString(t.Value), ",")
+			}
+		}
+	}
+	if len(paused) == 0 {
+		return nil
+	}
+
+	policies, err := describePredictivePolicies(ctx, c.policies, asgName)
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		if !slices.Contains(paused, aws.ToString(p.PolicyName)) || p.PredictiveScalingConfiguration == nil {
+			continue
+		}
+		if err := setPredictiveScalingMode(ctx, c.policies, p, asTypes.PredictiveScalingModeForecastAndScale); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.policies.DeleteTags(ctx, &autoscaling.DeleteTagsInput{
+		Tags: []asTypes.Tag{{
+			ResourceId:   asgName,
+			ResourceType: aws.String("auto-scaling-group"),
+			Key:          aws.String(ForecastOnlyPoliciesTag),
+		}},
+	}); err != nil {
+		return err
+	}
+	c.Printf("Predictive scaling policies %v of ASG %v have been switched to forecast and scale\n", paused, *asgName)
+	return nil
+}
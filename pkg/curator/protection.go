@@ -0,0 +1,79 @@
+package curator
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// protectedInstanceIds returns the instances of the Auto Scaling Group protected from scale in
+func protectedInstanceIds(g asTypes.AutoScalingGroup, instanceIds []string) []string {
+	requested := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		requested[id] = true
+	}
+
+	protected := make([]string, 0)
+	for _, i := range g.Instances {
+		if requested[*i.InstanceId] && aws.ToBool(i.ProtectedFromScaleIn) {
+			protected = append(protected, *i.InstanceId)
+		}
+	}
+	return protected
+}
+
+func setInstanceProtection(ctx context.Context, autoscalingClient AutoScalingAPI, changes []types.AutoScalingGroupChange, protected bool) error {
+	for _, c := range changes {
+		if len(c.ProtectedInstanceIds) == 0 {
+			continue
+		}
+
+		if _, err := autoscalingClient.SetInstanceProtection(ctx, &autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: c.AutoScalingGroupName,
+			InstanceIds:          c.ProtectedInstanceIds,
+			ProtectedFromScaleIn: aws.Bool(protected),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disableScaleInProtection removes the scale-in protection of the planned protected instances,
+// if enabled for the group, and returns a function restoring the original protection within CleanupTimeout.
+func (c *Curator) disableScaleInProtection(ctx context.Context, autoscalingClient AutoScalingAPI, group types.Group, changes []types.AutoScalingGroupChange) (func() error, error) {
+	protected := make([]string, 0)
+	for _, change := range changes {
+		protected = append(protected, change.ProtectedInstanceIds...)
+	}
+
+	if len(protected) == 0 {
+		return func() error { return nil }, nil
+	}
+
+	if !group.DisableScaleInProtection {
+		c.Printf("Instance group %v: instances %v are protected from scale in, keeping the protection\n", *group.Name, protected)
+		return func() error { return nil }, nil
+	}
+
+	if err := setInstanceProtection(ctx, autoscalingClient, changes, false); err != nil {
+		return nil, err
+	}
+	c.Printf("Instance group %v: scale-in protection of instances %v has been disabled\n", *group.Name, protected)
+
+	return func() error {
+		// the protection is restored even if the run is cancelled, so that the instances are not left unprotected
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CleanupTimeout)
+		defer cancel()
+
+		if err := setInstanceProtection(ctx, autoscalingClient, changes, true); err != nil {
+			return err
+		}
+		c.Printf("Instance group %v: scale-in protection of instances %v has been restored\n", *group.Name, protected)
+		return nil
+	}, nil
+}
@@ -0,0 +1,218 @@
+package curator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+	"github.com/olekukonko/tablewriter"
+)
+
+// MessageKind is the kind of a reported message
+type MessageKind string
+
+// Reported message kinds
+const (
+	// MessageProgress is a progress message of the orchestration
+	MessageProgress MessageKind = "progress"
+	// MessageSummary is a summary message, reported regardless of the verbosity
+	MessageSummary MessageKind = "summary"
+	// MessageTable is a table, as encoded by the JSON reporter
+	MessageTable MessageKind = "table"
+)
+
+// Table is a table reported to the user, e.g. of the group instances or the run timings
+type Table struct {
+	// Name identifying the table, e.g. instances or timings
+	Name string `json:"name"`
+
+	// Column names
+	Header []string `json:"header"`
+
+	// Cell values by row
+	Rows [][]string `json:"rows"`
+
+	// Align the cells to the right rather than to the left
+	AlignRight bool `json:"-"`
+
+	// Merge the equal cells of the first column
+	MergeFirstColumn bool `json:"-"`
+
+	// Color the columns, if the reporter supports colors
+	Colored bool `json:"-"`
+}
+
+// Reporter receives every human-facing message and table of the curator.
+type Reporter interface {
+	// Report reports a message formatted with the arguments
+	Report(kind MessageKind, format string, a ...interface{})
+
+	// ReportTable reports a table
+	ReportTable(t Table)
+}
+
+// stdoutReporter is the default pretty output to stdout
+var stdoutReporter = NewPrettyReporter(os.Stdout, false)
+
+// reporter receives the messages and the tables of the package-level functions
+var reporter = stdoutReporter
+
+// SetReporter sets the reporter receiving the messages and the tables of the package-level functions.
+// A nil reporter restores the default pretty output to stdout. A Curator is configured with WithReporter instead.
+func SetReporter(r Reporter) {
+	if r == nil {
+		r = stdoutReporter
+	}
+	reporter = r
+}
+
+// GetReporter returns the reporter receiving the messages and the tables of the package-level functions.
+func GetReporter() Reporter {
+	return reporter
+}
+
+// PrintTable reports a table of the package-level functions unless the output is quiet.
+func PrintTable(t Table) {
+	packageCurator().PrintTable(t)
+}
+
+// PrintTable reports a table unless the output of the Curator is quiet.
+func (c *Curator) PrintTable(t Table) {
+	if c.verbosity >= VerbosityNormal && len(t.Rows) > 0 {
+		c.reporter.ReportTable(t)
+	}
+}
+
+// prettyReporter prints the messages with pp and renders the tables with tablewriter
+type prettyReporter struct {
+	w       io.Writer
+	printer *pp.PrettyPrinter
+	colors  bool
+}
+
+// NewPrettyReporter returns a Reporter printing to w the messages pretty-printed with pp and the tables
+// rendered as text, colored if enabled.
+func NewPrettyReporter(w io.Writer, colors bool) Reporter {
+	printer := pp.New()
+	printer.SetOutput(w)
+	printer.SetColoringEnabled(colors)
+	printer.SetExportedOnly(true)
+	return &prettyReporter{w: w, printer: printer, colors: colors}
+}
+
+// NewPlainReporter returns a Reporter printing to w the messages and the tables as plain text without colors.
+func NewPlainReporter(w io.Writer) Reporter {
+	return NewPrettyReporter(w, false)
+}
+
+func (r *prettyReporter) Report(kind MessageKind, format string, a ...interface{}) {
+	r.printer.Printf(format, a...)
+}
+
+func (r *prettyReporter) ReportTable(t Table) {
+	table := tablewriter.NewWriter(r.w)
+	table.SetHeader(t.Header)
+	if t.AlignRight {
+		table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	} else {
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+	}
+	if t.MergeFirstColumn {
+		table.SetAutoMergeCellsByColumnIndex([]int{0})
+	}
+	if t.Colored && r.colors {
+		colors := make([]tablewriter.Colors, len(t.Header))
+		for i := range colors {
+			switch i {
+			case 0:
+				colors[i] = tablewriter.Colors{tablewriter.Normal, tablewriter.FgRedColor}
+			case 1:
+				colors[i] = tablewriter.Colors{tablewriter.Normal, tablewriter.FgYellowColor}
+			default:
+				colors[i] = tablewriter.Colors{tablewriter.Normal, tablewriter.FgGreenColor}
+			}
+		}
+		table.SetColumnColor(colors...)
+	}
+	table.AppendBulk(t.Rows)
+	table.Render()
+}
+
+// jsonReporter encodes the messages and the tables as JSON lines
+type jsonReporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// jsonRecord is a JSON line of the JSON reporter
+type jsonRecord struct {
+	Time    time.Time   `json:"time"`
+	Kind    MessageKind `json:"kind"`
+	Message string      `json:"message,omitempty"`
+	Table   *Table      `json:"table,omitempty"`
+}
+
+// NewJSONReporter returns a Reporter writing to w every message and table as a JSON line.
+func NewJSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{encoder: json.NewEncoder(w)}
+}
+
+func (r *jsonReporter) Report(kind MessageKind, format string, a ...interface{}) {
+	r.encode(jsonRecord{Kind: kind, Message: strings.TrimSuffix(plainPrinter.Sprintf(format, a...), "\n")})
+}
+
+func (r *jsonReporter) ReportTable(t Table) {
+	r.encode(jsonRecord{Kind: MessageTable, Table: &t})
+}
+
+func (r *jsonReporter) encode(record jsonRecord) {
+	record.Time = time.Now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.encoder.Encode(record); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to report %v: %v\n", record.Kind, err)
+	}
+}
+
+// csvReporter writes the tables as CSV, separated by blank lines, and the messages as plain text to another writer
+type csvReporter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	messages io.Writer
+	tables   int
+}
+
+// NewCSVReporter returns a Reporter writing to w every table as CSV with a header row, e.g. to be pasted
+// into spreadsheets, and to messages every message as plain text, so that they do not interleave with the tables.
+func NewCSVReporter(w, messages io.Writer) Reporter {
+	return &csvReporter{w: w, messages: messages}
+}
+
+func (r *csvReporter) Report(kind MessageKind, format string, a ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprint(r.messages, plainPrinter.Sprintf(format, a...))
+}
+
+func (r *csvReporter) ReportTable(t Table) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tables > 0 {
+		fmt.Fprintln(r.w)
+	}
+	r.tables++
+
+	writer := csv.NewWriter(r.w)
+	writer.Write(t.Header)
+	writer.WriteAll(t.Rows)
+	if err := writer.Error(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to report %v table: %v\n", t.Name, err)
+	}
+}
@@ -0,0 +1,205 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// capacityReservationExpiry is the lifetime of the created capacity reservations, which expire
+// even if the run fails to cancel them, e.g. when it is killed
+const capacityReservationExpiry = 3 * time.Hour
+
+// defaultInstancePlatform is the platform of instances not reporting the platform details
+const defaultInstancePlatform = ec2Types.CapacityReservationInstancePlatformLinuxUnix
+
+// capacityKey identifies the instances a single capacity reservation may cover
+type capacityKey struct {
+	InstanceType     ec2Types.InstanceType
+	AvailabilityZone string
+	Platform         ec2Types.CapacityReservationInstancePlatform
+	Tenancy          ec2Types.Tenancy
+}
+
+func (k capacityKey) String() string {
+	return fmt.Sprintf("%v %v in %v", k.InstanceType, k.Platform, k.AvailabilityZone)
+}
+
+// requiredCapacity returns the number of stopped instances of every instance type, Availability Zone,
+// platform and tenancy
+func requiredCapacity(ctx context.Context, ec2Client EC2API, instanceIds []string) (map[capacityKey]int32, error) {
+	required := make(map[capacityKey]int32)
+	for _, chunk := range chunkInstanceIds(instanceIds, maxEC2InstanceIds) {
+		output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range output.Reservations {
+			for _, i := range r.Instances {
+				if i.State == nil || i.State.Name != ec2Types.InstanceStateNameStopped {
+					continue
+				}
+
+				key := capacityKey{
+					InstanceType: i.InstanceType,
+					Platform:     ec2Types.CapacityReservationInstancePlatform(aws.ToString(i.PlatformDetails)),
+					Tenancy:      ec2Types.TenancyDefault,
+				}
+				if key.Platform == "" {
+					key.Platform = defaultInstancePlatform
+				}
+				if i.Placement != nil {
+					key.AvailabilityZone = aws.ToString(i.Placement.AvailabilityZone)
+					if i.Placement.Tenancy != "" {
+						key.Tenancy = i.Placement.Tenancy
+					}
+				}
+				required[key]++
+			}
+		}
+	}
+	return required, nil
+}
+
+// sortedCapacityKeys returns the keys in a stable order
+func sortedCapacityKeys(required map[capacityKey]int32) []capacityKey {
+	keys := make([]capacityKey, 0, len(required))
+	for k := range required {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// availableCapacity returns the available instance count of the active open capacity reservations
+// matching the key
+func availableCapacity(ctx context.Context, ec2Client EC2API, key capacityKey) (int32, error) {
+	input := &ec2.DescribeCapacityReservationsInput{
+		Filters: []ec2Types.Filter{
+			{Name: aws.String("state"), Values: []string{string(ec2Types.CapacityReservationStateActive)}},
+			{Name: aws.String("instance-type"), Values: []string{string(key.InstanceType)}},
+			{Name: aws.String("availability-zone"), Values: []string{key.AvailabilityZone}},
+			{Name: aws.String("instance-platform"), Values: []string{string(key.Platform)}},
+			{Name: aws.String("instance-match-criteria"), Values: []string{string(ec2Types.InstanceMatchCriteriaOpen)}},
+		},
+	}
+
+	var available int32
+	for {
+		output, err := ec2Client.DescribeCapacityReservations(ctx, input)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range output.CapacityReservations {
+			if ec2Types.Tenancy(r.Tenancy) == key.Tenancy {
+				available += aws.ToInt32(r.AvailableInstanceCount)
+			}
+		}
+		if aws.ToString(output.NextToken) == "" {
+			return available, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// ReserveInstanceGroupCapacity ensures the On-Demand capacity of the stopped instances of the group
+// ahead of their startup. It returns the IDs of the created capacity reservations, which are
+// to be released with ReleaseInstanceGroupCapacity once the instances are started.
+func ReserveInstanceGroupCapacity(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) ([]string, error) {
+	return packageCurator().reserveInstanceGroupCapacity(ctx, ec2Client, group, instanceIds)
+}
+
+// reserveInstanceGroupCapacity is ReserveInstanceGroupCapacity with the output and the wait duration of the Curator
+func (c *Curator) reserveInstanceGroupCapacity(ctx context.Context, ec2Client EC2API, group types.Group, instanceIds []string) ([]string, error) {
+	if group.CapacityReservation == nil || len(instanceIds) == 0 {
+		return nil, nil
+	}
+
+	required, err := requiredCapacity(ctx, ec2Client, instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if group.CapacityReservation.Mode == types.CapacityReservationModeVerify {
+		for _, key := range sortedCapacityKeys(required) {
+			available, err := availableCapacity(ctx, ec2Client, key)
+			if err != nil {
+				return nil, err
+			}
+			if available < required[key] {
+				return nil, fmt.Errorf("insufficient reserved capacity of %v for instance group %v: %v available, %v required", key, *group.Name, available, required[key])
+			}
+		}
+		c.Printf("Instance group %v: reserved capacity has been verified\n", *group.Name)
+		return nil, nil
+	}
+
+	reservationIds := make([]string, 0, len(required))
+	for _, key := range sortedCapacityKeys(required) {
+		output, err := ec2Client.CreateCapacityReservation(ctx, &ec2.CreateCapacityReservationInput{
+			AvailabilityZone:      aws.String(key.AvailabilityZone),
+			InstanceCount:         aws.Int32(required[key]),
+			InstancePlatform:      key.Platform,
+			InstanceType:          aws.String(string(key.InstanceType)),
+			EndDateType:           ec2Types.EndDateTypeLimited,
+			EndDate:               aws.Time(time.Now().Add(capacityReservationExpiry)),
+			InstanceMatchCriteria: ec2Types.InstanceMatchCriteriaOpen,
+			Tenancy:               ec2Types.CapacityReservationTenancy(key.Tenancy),
+		})
+		if err != nil {
+			if releaseErr := c.releaseInstanceGroupCapacity(ctx, ec2Client, group, reservationIds); releaseErr != nil {
+				c.Printf("Instance group %v: unable to release capacity reservations %v: %v\n", *group.Name, reservationIds, releaseErr)
+			}
+			return nil, fmt.Errorf("unable to reserve capacity of %v for instance group %v: %w", key, *group.Name, err)
+		}
+		reservationIds = append(reservationIds, *output.CapacityReservation.CapacityReservationId)
+	}
+
+	c.Printf("Instance group %v: capacity reservations %v have been created\n", *group.Name, reservationIds)
+	return reservationIds, nil
+}
+
+// ReleaseInstanceGroupCapacity cancels the capacity reservations created by ReserveInstanceGroupCapacity.
+// The reservations are cancelled even if the run is cancelled, within CleanupTimeout,
+// and every reservation is tried, so that none of them is billed past the startup.
+func ReleaseInstanceGroupCapacity(ctx context.Context, ec2Client EC2API, group types.Group, reservationIds []string) error {
+	return packageCurator().releaseInstanceGroupCapacity(ctx, ec2Client, group, reservationIds)
+}
+
+// releaseInstanceGroupCapacity is ReleaseInstanceGroupCapacity with the output and the wait duration of the Curator
+func (c *Curator) releaseInstanceGroupCapacity(ctx context.Context, ec2Client EC2API, group types.Group, reservationIds []string) error {
+	if len(reservationIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CleanupTimeout)
+	defer cancel()
+
+	errs := make([]error, 0)
+	for _, id := range reservationIds {
+		if _, err := ec2Client.CancelCapacityReservation(ctx, &ec2.CancelCapacityReservationInput{
+			CapacityReservationId: aws.String(id),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("unable to cancel capacity reservation %v of instance group %v: %w", id, *group.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	c.Printf("Instance group %v: capacity reservations %v have been released\n", *group.Name, reservationIds)
+	return nil
+}
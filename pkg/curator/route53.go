@@ -0,0 +1,89 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Route53HealthyCheckerRatio is the share of Route53 health checkers that must report
+// success for a health check to be considered healthy, as documented by Route53
+const Route53HealthyCheckerRatio float64 = 0.18
+
+// Route53API is the subset of the Route53 client operations used by the curator.
+type Route53API interface {
+	route53.GetChangeAPIClient
+
+	GetHealthCheckStatus(context.Context, *route53.GetHealthCheckStatusInput, ...func(*route53.Options)) (*route53.GetHealthCheckStatusOutput, error)
+	ChangeResourceRecordSets(context.Context, *route53.ChangeResourceRecordSetsInput, ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error)
+}
+
+// WaitForRoute53HealthChecks waits until all Route53 health checks of the group
+// report the expected health.
+func WaitForRoute53HealthChecks(ctx context.Context, route53Client Route53API, group types.Group, healthy bool) error {
+	return packageCurator().waitForRoute53HealthChecks(ctx, route53Client, group, healthy)
+}
+
+// waitForRoute53HealthChecks is WaitForRoute53HealthChecks with the output and the wait duration of the Curator
+func (c *Curator) waitForRoute53HealthChecks(ctx context.Context, route53Client Route53API, group types.Group, healthy bool) error {
+	if group.Route53HealthChecks == nil {
+		return nil
+	}
+
+	expected := "unhealthy"
+	if healthy {
+		expected = "healthy"
+	}
+
+	pending := append([]string{}, group.Route53HealthChecks.IDs...)
+	err := waitLoop(ctx, c.newWaiterAttempts(*group.Name), c.waitDuration, 10*time.Second, time.Minute, c.logWaitAttempts(), nil, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		stillPending := make([]string, 0, len(pending))
+		for _, id := range pending {
+			output, err := route53Client.GetHealthCheckStatus(ctx, &route53.GetHealthCheckStatusInput{
+				HealthCheckId: aws.String(id),
+			}, func(o *route53.Options) {
+				o.APIOptions = append(o.APIOptions, apiOptions...)
+			})
+			if err != nil {
+				return false, err
+			}
+
+			if isRoute53HealthCheckHealthy(output) != healthy {
+				stillPending = append(stillPending, id)
+			}
+		}
+		pending = stillPending
+		return len(pending) > 0, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			return &WaiterTimeoutError{Waiter: fmt.Sprintf("Route53 health checks of instance group %v to become %v: %v", *group.Name, expected, pending)}
+		}
+		return err
+	}
+
+	c.Printf("Instance group %v: Route53 health checks are %v\n", *group.Name, expected)
+	return nil
+}
+
+func isRoute53HealthCheckHealthy(output *route53.GetHealthCheckStatusOutput) bool {
+	if len(output.HealthCheckObservations) == 0 {
+		return false
+	}
+
+	var succeeded int
+	for _, o := range output.HealthCheckObservations {
+		if o.StatusReport != nil && strings.HasPrefix(aws.ToString(o.StatusReport.Status), "Success") {
+			succeeded++
+		}
+	}
+	return float64(succeeded)/float64(len(output.HealthCheckObservations)) > Route53HealthyCheckerRatio
+}
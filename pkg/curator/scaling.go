@@ -0,0 +1,62 @@
+package curator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ScalingProcesses are the Auto Scaling processes suspended by the groups suspending the scaling: the processes
+// of the scaling policies and of the scheduled actions, which would scale the ASGs while their instances are stopped
+var ScalingProcesses = []string{"AlarmNotification", "ScheduledActions"}
+
+// scalingProcesses returns the scaling processes of the Auto Scaling Group which are suspended, if suspended,
+// or which are not suspended otherwise
+func scalingProcesses(g asTypes.AutoScalingGroup, suspended bool) []string {
+	processes := make([]string, 0, len(ScalingProcesses))
+	for _, p := range ScalingProcesses {
+		isSuspended := slices.ContainsFunc(g.SuspendedProcesses, func(s asTypes.SuspendedProcess) bool {
+			return s.ProcessName != nil && *s.ProcessName == p
+		})
+		if isSuspended == suspended {
+			processes = append(processes, p)
+		}
+	}
+	return processes
+}
+
+// suspendProcesses suspends the planned scaling processes of the Auto Scaling Group before the shutdown
+func (c *Curator) suspendProcesses(ctx context.Context, change types.AutoScalingGroupChange) error {
+	if len(change.SuspendProcesses) == 0 {
+		return nil
+	}
+
+	if _, err := c.autoscaling.SuspendProcesses(ctx, &autoscaling.SuspendProcessesInput{
+		AutoScalingGroupName: change.AutoScalingGroupName,
+		ScalingProcesses:     change.SuspendProcesses,
+	}); err != nil {
+		return asgOperationError(change.AutoScalingGroupName, "SuspendProcesses", err)
+	}
+	c.Printf("Scaling processes %v of ASG %v have been suspended\n", change.SuspendProcesses, *change.AutoScalingGroupName)
+	return nil
+}
+
+// resumeProcesses resumes the given scaling processes of the Auto Scaling Group
+func (c *Curator) resumeProcesses(ctx context.Context, asgName *string, processes []string) error {
+	if len(processes) == 0 {
+		return nil
+	}
+
+	if _, err := c.autoscaling.ResumeProcesses(ctx, &autoscaling.ResumeProcessesInput{
+		AutoScalingGroupName: asgName,
+		ScalingProcesses:     processes,
+	}); err != nil {
+		return asgOperationError(asgName, "ResumeProcesses", err)
+	}
+	c.Printf("Scaling processes %v of ASG %v have been resumed\n", processes, *asgName)
+	return nil
+}
@@ -0,0 +1,80 @@
+package curator
+
+import (
+	"context"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// SizeRecorder persists the Auto Scaling Group changes of the shutdown of a group, so that the startup
+// of a later run restores the sizes the shutdown has lowered
+type SizeRecorder interface {
+	// RecordShutdownChanges stores the applied shutdown changes of the group.
+	RecordShutdownChanges(ctx context.Context, group string, changes []types.AutoScalingGroupChange) error
+
+	// ShutdownChanges returns the recorded shutdown changes of the group, or nil if none are recorded.
+	ShutdownChanges(ctx context.Context, group string) ([]types.AutoScalingGroupChange, error)
+
+	// ForgetShutdownChanges removes the recorded shutdown changes of the group.
+	ForgetShutdownChanges(ctx context.Context, group string) error
+}
+
+// recordShutdownChanges records the applied shutdown changes lowering the MinSize of the Auto Scaling Groups.
+// The changes recorded by an earlier shutdown are kept for the same Auto Scaling Groups, as they hold
+// the sizes prior to the lowering.
+func (c *Curator) recordShutdownChanges(ctx context.Context, group types.Group, lowered []types.AutoScalingGroupChange) {
+	if c.sizes == nil || len(lowered) == 0 {
+		return
+	}
+
+	recorded, err := c.sizes.ShutdownChanges(ctx, *group.Name)
+	if err != nil {
+		c.Printf("Warning: unable to read recorded ASG sizes of instance group %v: %v\n", *group.Name, err)
+		return
+	}
+	changes := append([]types.AutoScalingGroupChange{}, recorded...)
+	for _, change := range lowered {
+		if !containsAutoScalingGroupChange(recorded, *change.AutoScalingGroupName) {
+			changes = append(changes, change)
+		}
+	}
+
+	if err := c.sizes.RecordShutdownChanges(ctx, *group.Name, changes); err != nil {
+		c.Printf("Warning: unable to record ASG sizes of instance group %v: %v\n", *group.Name, err)
+	}
+}
+
+// restoreRecordedMinSizes updates the startup changes to restore the MinSize lowered by the recorded shutdown changes
+func (c *Curator) restoreRecordedMinSizes(ctx context.Context, group types.Group, changes []types.AutoScalingGroupChange) {
+	if c.sizes == nil {
+		return
+	}
+
+	recorded, err := c.sizes.ShutdownChanges(ctx, *group.Name)
+	if err != nil {
+		c.Printf("Warning: unable to read recorded ASG sizes of instance group %v: %v\n", *group.Name, err)
+		return
+	}
+	RestoreMinSizes(changes, recorded)
+}
+
+// forgetShutdownChanges removes the recorded shutdown changes of the group once the sizes are restored
+func (c *Curator) forgetShutdownChanges(ctx context.Context, group types.Group) {
+	if c.sizes == nil {
+		return
+	}
+
+	if err := c.sizes.ForgetShutdownChanges(ctx, *group.Name); err != nil {
+		c.Printf("Warning: unable to remove recorded ASG sizes of instance group %v: %v\n", *group.Name, err)
+	}
+}
+
+// containsAutoScalingGroupChange reports whether the changes include a change of the named Auto Scaling Group
+func containsAutoScalingGroupChange(changes []types.AutoScalingGroupChange, name string) bool {
+	for _, change := range changes {
+		if *change.AutoScalingGroupName == name {
+			return true
+		}
+	}
+	return false
+}
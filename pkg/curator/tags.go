@@ -0,0 +1,190 @@
+package curator
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// Run metadata tag names, prefixed with the run tags prefix of the stack
+const (
+	RunTagLastAction string = "last-action"
+	RunTagRunId      string = "run-id"
+	RunTagTimestamp  string = "timestamp"
+)
+
+// DefaultRunTagsPrefix is the prefix of the run metadata tag keys of stacks without an override
+const DefaultRunTagsPrefix string = "curator:"
+
+// OriginalInstanceTypeTag records the type of an instance before it was changed to a fallback instance type
+const OriginalInstanceTypeTag string = DefaultRunTagsPrefix + "original-instance-type"
+
+// EC2TagsAPI is the subset of the EC2 client tagging operations used by the curator.
+type EC2TagsAPI interface {
+	CreateTags(context.Context, *ec2.CreateTagsInput, ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error)
+	DeleteTags(context.Context, *ec2.DeleteTagsInput, ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error)
+}
+
+// AutoScalingTagsAPI is the subset of the Auto Scaling client tagging operations used by the curator.
+// It is kept apart from AutoScalingAPI as the EC2 and Auto Scaling DeleteTags operations cannot
+// be implemented by a single type, e.g. the in-memory fake of the fake package.
+type AutoScalingTagsAPI interface {
+	autoscaling.DescribeAutoScalingInstancesAPIClient
+
+	CreateOrUpdateTags(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error)
+	DeleteTags(context.Context, *autoscaling.DeleteTagsInput, ...func(*autoscaling.Options)) (*autoscaling.DeleteTagsOutput, error)
+}
+
+// RunMetadata describes the curator run recorded in the run metadata tags
+type RunMetadata struct {
+	Action    types.Action
+	RunId     string
+	Timestamp time.Time
+}
+
+// RunTagsPrefix returns the prefix of the run metadata tag keys of the stack.
+func RunTagsPrefix(stack *types.Stack) string {
+	if stack.RunTags != nil && stack.RunTags.Prefix != nil {
+		return *stack.RunTags.Prefix
+	}
+	return DefaultRunTagsPrefix
+}
+
+func runTags(prefix string, run RunMetadata) map[string]string {
+	return map[string]string{
+		prefix + RunTagLastAction: string(run.Action),
+		prefix + RunTagRunId:      run.RunId,
+		prefix + RunTagTimestamp:  run.Timestamp.UTC().Format(time.RFC3339),
+	}
+}
+
+func runTagKeys(prefix string) []string {
+	return []string{prefix + RunTagLastAction, prefix + RunTagRunId, prefix + RunTagTimestamp}
+}
+
+// groupAutoScalingGroupNames returns the names of the Auto Scaling Groups the instances belong to
+func groupAutoScalingGroupNames(ctx context.Context, autoscalingClient AutoScalingTagsAPI, instanceIds []string) ([]string, error) {
+	output, err := autoscalingClient.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: instanceIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, i := range output.AutoScalingInstances {
+		if !seen[*i.AutoScalingGroupName] {
+			seen[*i.AutoScalingGroupName] = true
+			names = append(names, *i.AutoScalingGroupName)
+		}
+	}
+	return names, nil
+}
+
+// TagInstanceGroup writes the run metadata tags to the group instances and their Auto Scaling Groups.
+func TagInstanceGroup(ctx context.Context, ec2Client EC2TagsAPI, autoscalingClient AutoScalingTagsAPI, group types.Group, instanceIds []string, prefix string, run RunMetadata) error {
+	return packageCurator().TagInstanceGroup(ctx, ec2Client, autoscalingClient, group, instanceIds, prefix, run)
+}
+
+// TagInstanceGroup is TagInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) TagInstanceGroup(ctx context.Context, ec2Client EC2TagsAPI, autoscalingClient AutoScalingTagsAPI, group types.Group, instanceIds []string, prefix string, run RunMetadata) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	tags := runTags(prefix, run)
+	ec2Tags := make([]ec2Types.Tag, 0, len(tags))
+	for k, v := range tags {
+		ec2Tags = append(ec2Tags, ec2Types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	if _, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: instanceIds,
+		Tags:      ec2Tags,
+	}); err != nil {
+		return err
+	}
+
+	asgNames, err := groupAutoScalingGroupNames(ctx, autoscalingClient, instanceIds)
+	if err != nil {
+		return err
+	}
+	if len(asgNames) > 0 {
+		asTags := make([]asTypes.Tag, 0, len(asgNames)*len(tags))
+		for _, name := range asgNames {
+			for k, v := range tags {
+				asTags = append(asTags, asTypes.Tag{
+					Key:               aws.String(k),
+					Value:             aws.String(v),
+					ResourceId:        aws.String(name),
+					ResourceType:      aws.String("auto-scaling-group"),
+					PropagateAtLaunch: aws.Bool(false),
+				})
+			}
+		}
+		if _, err := autoscalingClient.CreateOrUpdateTags(ctx, &autoscaling.CreateOrUpdateTagsInput{
+			Tags: asTags,
+		}); err != nil {
+			return err
+		}
+	}
+
+	c.Printf("Instance group %v: run metadata tags have been written to instances %v and Auto Scaling Groups %v\n", *group.Name, instanceIds, asgNames)
+	return nil
+}
+
+// UntagInstanceGroup removes the run metadata tags from the group instances and their Auto Scaling Groups.
+func UntagInstanceGroup(ctx context.Context, ec2Client EC2TagsAPI, autoscalingClient AutoScalingTagsAPI, group types.Group, instanceIds []string, prefix string) error {
+	return packageCurator().UntagInstanceGroup(ctx, ec2Client, autoscalingClient, group, instanceIds, prefix)
+}
+
+// UntagInstanceGroup is UntagInstanceGroup with the output and the wait duration of the Curator
+func (c *Curator) UntagInstanceGroup(ctx context.Context, ec2Client EC2TagsAPI, autoscalingClient AutoScalingTagsAPI, group types.Group, instanceIds []string, prefix string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	keys := runTagKeys(prefix)
+	ec2Tags := make([]ec2Types.Tag, 0, len(keys))
+	for _, k := range keys {
+		ec2Tags = append(ec2Tags, ec2Types.Tag{Key: aws.String(k)})
+	}
+	if _, err := ec2Client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: instanceIds,
+		Tags:      ec2Tags,
+	}); err != nil {
+		return err
+	}
+
+	asgNames, err := groupAutoScalingGroupNames(ctx, autoscalingClient, instanceIds)
+	if err != nil {
+		return err
+	}
+	if len(asgNames) > 0 {
+		asTags := make([]asTypes.Tag, 0, len(asgNames)*len(keys))
+		for _, name := range asgNames {
+			for _, k := range keys {
+				asTags = append(asTags, asTypes.Tag{
+					Key:          aws.String(k),
+					ResourceId:   aws.String(name),
+					ResourceType: aws.String("auto-scaling-group"),
+				})
+			}
+		}
+		if _, err := autoscalingClient.DeleteTags(ctx, &autoscaling.DeleteTagsInput{
+			Tags: asTags,
+		}); err != nil {
+			return err
+		}
+	}
+
+	c.Printf("Instance group %v: run metadata tags have been removed from instances %v and Auto Scaling Groups %v\n", *group.Name, instanceIds, asgNames)
+	return nil
+}
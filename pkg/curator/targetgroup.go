@@ -0,0 +1,156 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2Types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// ELBv2API is the subset of the Elastic Load Balancing v2 client operations used by the curator.
+type ELBv2API interface {
+	elbv2.DescribeTargetHealthAPIClient
+
+	RegisterTargets(context.Context, *elbv2.RegisterTargetsInput, ...func(*elbv2.Options)) (*elbv2.RegisterTargetsOutput, error)
+	DeregisterTargets(context.Context, *elbv2.DeregisterTargetsInput, ...func(*elbv2.Options)) (*elbv2.DeregisterTargetsOutput, error)
+}
+
+func targetDescriptions(tg types.TargetGroup, instanceIds []string) []elbv2Types.TargetDescription {
+	targets := make([]elbv2Types.TargetDescription, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		targets = append(targets, elbv2Types.TargetDescription{
+			Id:   aws.String(id),
+			Port: tg.Port,
+		})
+	}
+	return targets
+}
+
+// DeregisterInstanceGroupTargets deregisters the group instances from the group
+// target groups and waits until they are deregistered.
+func DeregisterInstanceGroupTargets(ctx context.Context, elbv2Client ELBv2API, group types.Group, instanceIds []string) error {
+	return packageCurator().deregisterInstanceGroupTargets(ctx, elbv2Client, group, instanceIds)
+}
+
+// deregisterInstanceGroupTargets is DeregisterInstanceGroupTargets with the output and the wait duration of the Curator
+func (c *Curator) deregisterInstanceGroupTargets(ctx context.Context, elbv2Client ELBv2API, group types.Group, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	for _, tg := range group.TargetGroups {
+		targets := targetDescriptions(tg, instanceIds)
+		if _, err := elbv2Client.DeregisterTargets(ctx, &elbv2.DeregisterTargetsInput{
+			TargetGroupArn: tg.ARN,
+			Targets:        targets,
+		}); err != nil {
+			return err
+		}
+
+		attempts := c.newWaiterAttempts(*group.Name)
+		waiter := elbv2.NewTargetDeregisteredWaiter(elbv2Client, func(o *elbv2.TargetDeregisteredWaiterOptions) {
+			o.APIOptions = append(o.APIOptions, attempts.addCounter)
+			o.LogWaitAttempts = c.logWaitAttempts()
+			o.MaxDelay = time.Minute
+		})
+		attempts.reset()
+		err := waiter.Wait(ctx, &elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: tg.ARN,
+			Targets:        targets,
+		}, c.waitDuration)
+		attempts.emit()
+		if err != nil {
+			return err
+		}
+
+		c.Printf("Instance group %v: targets have been deregistered from %v\n", *group.Name, *tg.ARN)
+	}
+
+	return nil
+}
+
+// RegisterInstanceGroupTargets registers the group instances with the group
+// target groups and waits until they are healthy.
+func RegisterInstanceGroupTargets(ctx context.Context, elbv2Client ELBv2API, group types.Group, instanceIds []string) error {
+	return packageCurator().registerInstanceGroupTargets(ctx, elbv2Client, group, instanceIds)
+}
+
+// registerInstanceGroupTargets is RegisterInstanceGroupTargets with the output and the wait duration of the Curator
+func (c *Curator) registerInstanceGroupTargets(ctx context.Context, elbv2Client ELBv2API, group types.Group, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	for _, tg := range group.TargetGroups {
+		targets := targetDescriptions(tg, instanceIds)
+		if _, err := elbv2Client.RegisterTargets(ctx, &elbv2.RegisterTargetsInput{
+			TargetGroupArn: tg.ARN,
+			Targets:        targets,
+		}); err != nil {
+			return err
+		}
+
+		attempts := c.newWaiterAttempts(*group.Name)
+		waiter := elbv2.NewTargetInServiceWaiter(elbv2Client, func(o *elbv2.TargetInServiceWaiterOptions) {
+			o.APIOptions = append(o.APIOptions, attempts.addCounter)
+			o.LogWaitAttempts = c.logWaitAttempts()
+			o.MaxDelay = time.Minute
+		})
+		attempts.reset()
+		output, err := waiter.WaitForOutput(ctx, &elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: tg.ARN,
+			Targets:        targets,
+		}, c.waitDuration)
+		attempts.emit()
+		if err != nil {
+			return err
+		}
+		c.Printf("Target health in %v: %v\n", *tg.ARN, output.TargetHealthDescriptions)
+
+		c.Printf("Instance group %v: targets have been registered with %v\n", *group.Name, *tg.ARN)
+	}
+
+	return nil
+}
+
+// waitForAutoScalingTargetHealth waits until the instances returned to service are healthy
+// in the target groups attached to their Auto Scaling Groups
+func (c *Curator) waitForAutoScalingTargetHealth(ctx context.Context, group types.Group, changes []types.AutoScalingGroupChange) error {
+	for _, change := range changes {
+		if len(change.TargetGroupARNs) == 0 || len(change.InstanceIds) == 0 {
+			continue
+		}
+		if c.elbv2 == nil {
+			return errors.New("an Elastic Load Balancing client is required to wait for the target health")
+		}
+
+		for _, arn := range change.TargetGroupARNs {
+			targets := targetDescriptions(types.TargetGroup{ARN: aws.String(arn)}, change.InstanceIds)
+			attempts := c.newWaiterAttempts(*group.Name)
+			waiter := elbv2.NewTargetInServiceWaiter(c.elbv2, func(o *elbv2.TargetInServiceWaiterOptions) {
+				o.APIOptions = append(o.APIOptions, attempts.addCounter)
+				o.LogWaitAttempts = c.logWaitAttempts()
+				o.MaxDelay = c.waiterMaxDelay
+				setGroupWaiterDelays(group, &o.MinDelay, &o.MaxDelay)
+			})
+			attempts.reset()
+			err := waiter.Wait(ctx, &elbv2.DescribeTargetHealthInput{
+				TargetGroupArn: aws.String(arn),
+				Targets:        targets,
+			}, groupWaitDuration(group, c.waitDuration))
+			attempts.emit()
+			if err != nil {
+				return err
+			}
+
+			c.Printf("Instance group %v: targets of ASG %v are healthy in %v\n", *group.Name, *change.AutoScalingGroupName, arn)
+		}
+	}
+
+	return nil
+}
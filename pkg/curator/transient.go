@@ -0,0 +1,67 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithytime "github.com/aws/smithy-go/time"
+)
+
+// TransientRetryAttempts is the maximum number of attempts of an Auto Scaling call failing transiently
+const TransientRetryAttempts = 5
+
+// transientAutoScalingErrorCodes are the error codes of the Auto Scaling calls failing transiently,
+// e.g. right after a previous scaling activity of the Auto Scaling Group
+var transientAutoScalingErrorCodes = map[string]bool{
+	"ScalingActivityInProgress": true,
+	"ResourceContention":        true,
+}
+
+// transientValidationMessages are the messages of the validation errors racing a previous scaling activity
+var transientValidationMessages = []string{
+	"scaling activity in progress",
+	"scaling activity is in progress",
+}
+
+// isTransientAutoScalingError reports whether the Auto Scaling call may succeed once retried
+func isTransientAutoScalingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if transientAutoScalingErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	if apiErr.ErrorCode() != "ValidationError" {
+		return false
+	}
+
+	message := strings.ToLower(apiErr.ErrorMessage())
+	for _, m := range transientValidationMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransient calls fn until it succeeds, fails with an error which is not transient
+// or TransientRetryAttempts are made, backing off exponentially between the attempts
+func (c *Curator) retryTransient(ctx context.Context, call string, fn func() error) error {
+	delay := 5 * time.Second
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= TransientRetryAttempts || !isTransientAutoScalingError(err) {
+			return err
+		}
+
+		c.Printf("Warning: %v has failed transiently, retrying in %v: %v\n", call, delay.String(), err)
+		if sleepErr := smithytime.SleepWithContext(ctx, delay); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+		delay = min(2*delay, time.Minute)
+	}
+}
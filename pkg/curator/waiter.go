@@ -0,0 +1,343 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/smithy-go/middleware"
+	smithytime "github.com/aws/smithy-go/time"
+	smithywaiter "github.com/aws/smithy-go/waiter"
+	"github.com/jmespath/go-jmespath"
+)
+
+// DefaultLifecycleStatePathExpression is the JMESPath expression evaluated by
+// AutoScalingInstanceLifecycleStateWaiter unless overridden
+const DefaultLifecycleStatePathExpression string = "AutoScalingInstances[].LifecycleState"
+
+// sdkWaiterTimedOut reports whether the SDK waiter has failed by exceeding its deadline, i.e. the deadline
+// has passed while the context has not been cancelled. The SDK waiters give up before the deadline
+// once it would pass during their next delay, so their untyped timeout error is matched as a fallback.
+func sdkWaiterTimedOut(ctx context.Context, deadline time.Time, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !time.Now().Before(deadline) || strings.HasPrefix(err.Error(), ErrWaitTimeout.Error())
+}
+
+// Comparator reports whether the values selected by a waiter path expression match the expected value
+type Comparator func(values []interface{}, expected string) (bool, error)
+
+// AllStringEquals matches when the list is not empty and every value equals the expected one
+func AllStringEquals(values []interface{}, expected string) (bool, error) {
+	if len(values) == 0 {
+		return false, nil
+	}
+	for _, v := range values {
+		value, err := comparatorString(v)
+		if err != nil {
+			return false, err
+		}
+		if value != expected {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AnyStringEquals matches when at least one value equals the expected one
+func AnyStringEquals(values []interface{}, expected string) (bool, error) {
+	for _, v := range values {
+		value, err := comparatorString(v)
+		if err != nil {
+			return false, err
+		}
+		if value == expected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AllStringEqualsOrEmpty matches when every value equals the expected one, including an empty list.
+// It is useful for transient states, e.g. Detached instances are no longer reported by the Auto Scaling Group.
+func AllStringEqualsOrEmpty(values []interface{}, expected string) (bool, error) {
+	if len(values) == 0 {
+		return true, nil
+	}
+	return AllStringEquals(values, expected)
+}
+
+func comparatorString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case *string:
+		if value == nil {
+			return "", nil
+		}
+		return *value, nil
+	}
+	// string based enums, e.g. ec2Types.InstanceStateName
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	return "", fmt.Errorf("waiter comparator expected string value, got %T", v)
+}
+
+// AutoScalingInstanceLifecycleStateWaiterOptions are waiter options for AutoScalingInstanceLifecycleStateWaiter
+type AutoScalingInstanceLifecycleStateWaiterOptions struct {
+
+	// Set of options to modify how an operation is invoked. These apply to all
+	// operations invoked for this client. Use functional options on operation call to
+	// modify this list for per operation behavior.
+	APIOptions []func(*middleware.Stack) error
+
+	// MinDelay is the minimum amount of time to delay between retries. If unset,
+	// AutoScalingInstanceLifecycleStateWaiter will use default minimum delay of 15 seconds. Note that
+	// MinDelay must resolve to a value lesser than or equal to the MaxDelay.
+	MinDelay time.Duration
+
+	// MaxDelay is the maximum amount of time to delay between retries. If unset or set
+	// to zero, AutoScalingInstanceLifecycleStateWaiter will use default max delay of 120 seconds. Note
+	// that MaxDelay must resolve to value greater than or equal to the MinDelay.
+	MaxDelay time.Duration
+
+	// LogWaitAttempts is used to enable logging for waiter retry attempts
+	LogWaitAttempts bool
+
+	// PathExpression is the JMESPath expression evaluated against the
+	// DescribeAutoScalingInstances output. If unset, DefaultLifecycleStatePathExpression is used.
+	PathExpression string
+
+	// Comparator is used to match the values selected by PathExpression against
+	// the target lifecycle state. If unset, AllStringEquals is used.
+	Comparator Comparator
+
+	// Retryable is function that can be used to override the default waiter-behavior
+	// based on operation output, or returned error. This function is used by the waiter
+	// to decide if a state is retryable or a terminal state. If unset, the waiter
+	// evaluates PathExpression with Comparator against the target lifecycle state.
+	// The function returns an error in case of a failure state. In case of retry state,
+	// this function returns a bool value of true and nil error, while in case of success
+	// it returns a bool value of false and nil error.
+	Retryable func(context.Context, *autoscaling.DescribeAutoScalingInstancesInput, *autoscaling.DescribeAutoScalingInstancesOutput, error) (bool, error)
+
+	// attempts emits the attempts of the waiter of a Curator with their group
+	attempts *waiterAttempts
+}
+
+// AutoScalingInstanceLifecycleStateWaiter defines the waiters for Auto Scaling instance lifecycle states
+type AutoScalingInstanceLifecycleStateWaiter struct {
+	client autoscaling.DescribeAutoScalingInstancesAPIClient
+
+	lifecycleState string
+
+	options AutoScalingInstanceLifecycleStateWaiterOptions
+}
+
+// NewAutoScalingInstanceLifecycleStateWaiter constructs a AutoScalingInstanceLifecycleStateWaiter
+// waiting for the instances to reach the given lifecycle state.
+func NewAutoScalingInstanceLifecycleStateWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, lifecycleState string, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	options := AutoScalingInstanceLifecycleStateWaiterOptions{}
+	options.MinDelay = 15 * time.Second
+	options.MaxDelay = 120 * time.Second
+	options.PathExpression = DefaultLifecycleStatePathExpression
+	options.Comparator = AllStringEquals
+
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &AutoScalingInstanceLifecycleStateWaiter{
+		client:         client,
+		lifecycleState: lifecycleState,
+		options:        options,
+	}
+}
+
+// NewAutoScalingInstanceStandbyWaiter constructs a AutoScalingInstanceLifecycleStateWaiter for the Standby state.
+func NewAutoScalingInstanceStandbyWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	return NewAutoScalingInstanceLifecycleStateWaiter(client, LifecycleStateNameStandby, optFns...)
+}
+
+// NewAutoScalingInstanceInServiceWaiter constructs a AutoScalingInstanceLifecycleStateWaiter for the InService state.
+func NewAutoScalingInstanceInServiceWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	return NewAutoScalingInstanceLifecycleStateWaiter(client, LifecycleStateNameInService, optFns...)
+}
+
+// NewAutoScalingInstancePendingWaiter constructs a AutoScalingInstanceLifecycleStateWaiter for the Pending state.
+func NewAutoScalingInstancePendingWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	return NewAutoScalingInstanceLifecycleStateWaiter(client, LifecycleStateNamePending, optFns...)
+}
+
+// NewAutoScalingInstanceTerminatingWaitWaiter constructs a AutoScalingInstanceLifecycleStateWaiter for the Terminating:Wait state
+// entered by instances paused by a termination lifecycle hook.
+func NewAutoScalingInstanceTerminatingWaitWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	return NewAutoScalingInstanceLifecycleStateWaiter(client, LifecycleStateNameTerminatingWait, optFns...)
+}
+
+// NewAutoScalingInstanceDetachedWaiter constructs a AutoScalingInstanceLifecycleStateWaiter for the Detached state.
+// Instances no longer reported by any Auto Scaling Group are considered detached.
+func NewAutoScalingInstanceDetachedWaiter(client autoscaling.DescribeAutoScalingInstancesAPIClient, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) *AutoScalingInstanceLifecycleStateWaiter {
+	optFns = append([]func(*AutoScalingInstanceLifecycleStateWaiterOptions){func(o *AutoScalingInstanceLifecycleStateWaiterOptions) {
+		o.Comparator = AllStringEqualsOrEmpty
+	}}, optFns...)
+	return NewAutoScalingInstanceLifecycleStateWaiter(client, LifecycleStateNameDetached, optFns...)
+}
+
+// Wait calls the waiter function for the lifecycle state waiter. The maxWaitDur is the
+// maximum wait duration the waiter will wait. The maxWaitDur is required and must
+// be greater than zero.
+func (w *AutoScalingInstanceLifecycleStateWaiter) Wait(ctx context.Context, params *autoscaling.DescribeAutoScalingInstancesInput, maxWaitDur time.Duration, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) error {
+	_, err := w.WaitForOutput(ctx, params, maxWaitDur, optFns...)
+	return err
+}
+
+// WaitForOutput calls the waiter function for the lifecycle state waiter and returns
+// the output of the successful operation. The maxWaitDur is the maximum wait
+// duration the waiter will wait. The maxWaitDur is required and must be greater
+// than zero.
+func (w *AutoScalingInstanceLifecycleStateWaiter) WaitForOutput(ctx context.Context, params *autoscaling.DescribeAutoScalingInstancesInput, maxWaitDur time.Duration, optFns ...func(*AutoScalingInstanceLifecycleStateWaiterOptions)) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+	if maxWaitDur <= 0 {
+		return nil, fmt.Errorf("maximum wait time for waiter must be greater than zero")
+	}
+
+	options := w.options
+	for _, fn := range optFns {
+		fn(&options)
+	}
+
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = 120 * time.Second
+	}
+
+	if options.MinDelay > options.MaxDelay {
+		return nil, fmt.Errorf("minimum waiter delay %v must be lesser than or equal to maximum waiter delay of %v.", options.MinDelay, options.MaxDelay)
+	}
+
+	retryable := options.Retryable
+	if retryable == nil {
+		retryable = lifecycleStateRetryable(w.lifecycleState, options.PathExpression, options.Comparator)
+	}
+
+	var out *autoscaling.DescribeAutoScalingInstancesOutput
+	err := waitLoop(ctx, options.attempts, maxWaitDur, options.MinDelay, options.MaxDelay, options.LogWaitAttempts, options.APIOptions, func(ctx context.Context, apiOptions []func(*middleware.Stack) error) (bool, error) {
+		var err error
+		out, err = w.client.DescribeAutoScalingInstances(ctx, params, func(o *autoscaling.Options) {
+			o.APIOptions = append(o.APIOptions, apiOptions...)
+		})
+		return retryable(ctx, params, out, err)
+	})
+	if err != nil {
+		if errors.Is(err, ErrWaitTimeout) {
+			timeoutErr := &WaiterTimeoutError{
+				Waiter:      fmt.Sprintf("AutoScalingInstance %v lifecycle state waiter", w.lifecycleState),
+				InstanceIds: params.InstanceIds,
+			}
+			if out != nil && len(out.AutoScalingInstances) > 0 {
+				timeoutErr.LastStates = make(map[string]string, len(out.AutoScalingInstances))
+				for _, i := range out.AutoScalingInstances {
+					timeoutErr.LastStates[aws.ToString(i.InstanceId)] = aws.ToString(i.LifecycleState)
+				}
+			}
+			return nil, timeoutErr
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// waitLoop invokes the attempt function with an exponential backoff between attempts
+// until it reports a terminal state, fails, or the maximum wait duration is exceeded.
+// Every attempt and the completion of the wait are emitted with the group of the attempts, if any.
+func waitLoop(ctx context.Context, attempts *waiterAttempts, maxWaitDur, minDelay, maxDelay time.Duration, logWaitAttempts bool, apiOptions []func(*middleware.Stack) error, attemptFn func(context.Context, []func(*middleware.Stack) error) (bool, error)) error {
+	ctx, cancelFn := context.WithTimeout(ctx, maxWaitDur)
+	defer cancelFn()
+
+	// the waiters constructed outside of a Curator emit no events
+	if attempts == nil {
+		attempts = New().newWaiterAttempts("")
+	}
+	attempts.reset()
+	defer attempts.emit()
+
+	logger := smithywaiter.Logger{}
+	remainingTime := maxWaitDur
+
+	for {
+		attempt := attempts.count + 1
+		attemptAPIOptions := apiOptions
+		start := time.Now()
+
+		if logWaitAttempts {
+			logger.Attempt = attempt
+			attemptAPIOptions = append([]func(*middleware.Stack) error{}, apiOptions...)
+			attemptAPIOptions = append(attemptAPIOptions, logger.AddLogger)
+		}
+
+		retryable, err := attemptFn(ctx, attemptAPIOptions)
+		if err != nil || !retryable {
+			attempts.attempt("")
+			return err
+		}
+
+		remainingTime -= time.Since(start)
+		if remainingTime < minDelay || remainingTime <= 0 {
+			attempts.attempt("")
+			break
+		}
+
+		// compute exponential backoff between waiter retries
+		delay, err := smithywaiter.ComputeDelay(
+			attempt, minDelay, maxDelay, remainingTime,
+		)
+		if err != nil {
+			attempts.attempt("")
+			return fmt.Errorf("error computing waiter delay, %w", err)
+		}
+		attempts.attempt(fmt.Sprintf("retrying in %v", delay))
+
+		remainingTime -= delay
+		// sleep for the delay amount before invoking a request
+		if err := smithytime.SleepWithContext(ctx, delay); err != nil {
+			return fmt.Errorf("request cancelled while waiting, %w", err)
+		}
+	}
+	return ErrWaitTimeout
+}
+
+func lifecycleStateRetryable(lifecycleState, pathExpression string, comparator Comparator) func(context.Context, *autoscaling.DescribeAutoScalingInstancesInput, *autoscaling.DescribeAutoScalingInstancesOutput, error) (bool, error) {
+	if pathExpression == "" {
+		pathExpression = DefaultLifecycleStatePathExpression
+	}
+	if comparator == nil {
+		comparator = AllStringEquals
+	}
+
+	return func(ctx context.Context, input *autoscaling.DescribeAutoScalingInstancesInput, output *autoscaling.DescribeAutoScalingInstancesOutput, err error) (bool, error) {
+		if err != nil {
+			return true, nil
+		}
+
+		pathValue, err := jmespath.Search(pathExpression, output)
+		if err != nil {
+			return false, fmt.Errorf("error evaluating waiter state: %w", err)
+		}
+
+		listOfValues, ok := pathValue.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("waiter comparator expected list got %T", pathValue)
+		}
+
+		match, err := comparator(listOfValues, lifecycleState)
+		if err != nil {
+			return false, err
+		}
+		return !match, nil
+	}
+}
@@ -0,0 +1,34 @@
+package curator
+
+import (
+	"context"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// WaitForGroupWarmup waits for the configured warm-up delay of a started group which has passed its health gates,
+// so that the services have warmed up, e.g. filled their caches, before the run moves on to the dependent groups.
+func WaitForGroupWarmup(ctx context.Context, group types.Group) error {
+	return packageCurator().waitForGroupWarmup(ctx, group)
+}
+
+// waitForGroupWarmup is WaitForGroupWarmup with the output and the wait duration of the Curator
+func (c *Curator) waitForGroupWarmup(ctx context.Context, group types.Group) error {
+	if group.WarmupAfterStartup == nil {
+		return nil
+	}
+	defer c.emitPhase(*group.Name, PhaseWarmup, time.Now())
+
+	c.Printf("Instance group %v: warming up for %v\n", *group.Name, group.WarmupAfterStartup.String())
+	timer := time.NewTimer(*group.WarmupAfterStartup)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.Printf("Instance group %v: warm-up has been completed\n", *group.Name)
+	return nil
+}
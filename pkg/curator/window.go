@@ -0,0 +1,65 @@
+package curator
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+// OperationWindowsOpen reports whether the action may run at the time: whether no operation window of the stack
+// applies to the action, or any window applying to it is open at the time.
+func OperationWindowsOpen(stack *types.Stack, action types.Action, now time.Time) (bool, error) {
+	applied := false
+	for _, w := range stack.OperationWindows {
+		if len(w.Actions) > 0 && !slices.Contains(w.Actions, action) {
+			continue
+		}
+		applied = true
+
+		open, err := operationWindowOpen(w, now)
+		if err != nil || open {
+			return open, err
+		}
+	}
+	return !applied, nil
+}
+
+// operationWindowOpen reports whether the operation window is open at the time. A window ending before its start
+// spans midnight and is open until its end on the day after it starts.
+func operationWindowOpen(w types.OperationWindow, now time.Time) (bool, error) {
+	location := time.UTC
+	if w.TimeZone != nil {
+		var err error
+		if location, err = time.LoadLocation(*w.TimeZone); err != nil {
+			return false, err
+		}
+	}
+
+	start, err := time.Parse("15:04", *w.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := time.Parse("15:04", *w.End)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(location)
+	startsOn := func(day time.Time) bool {
+		return len(w.Days) == 0 || slices.Contains(w.Days, day.Weekday().String()[:3])
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	switch {
+	case startMinutes == endMinutes:
+		// the window lasts the whole day
+		return startsOn(now), nil
+	case startMinutes < endMinutes:
+		return startsOn(now) && minutes >= startMinutes && minutes < endMinutes, nil
+	default:
+		return (startsOn(now) && minutes >= startMinutes) || (startsOn(now.AddDate(0, 0, -1)) && minutes < endMinutes), nil
+	}
+}
@@ -0,0 +1,150 @@
+package curator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/curator"
+	"github.com/ikorchynskyi/instance-stack-curator/pkg/types"
+)
+
+func TestOperationWindowsOpen(t *testing.T) {
+	// 2024-01-05 is a Friday
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 5, hour, minute, 0, 0, time.UTC)
+	}
+	saturday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 6, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window types.OperationWindow
+		now    time.Time
+		open   bool
+	}{
+		{
+			name:   "overnight window before its start",
+			window: types.OperationWindow{Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    friday(21, 59),
+			open:   false,
+		},
+		{
+			name:   "overnight window at its start",
+			window: types.OperationWindow{Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    friday(22, 0),
+			open:   true,
+		},
+		{
+			name:   "overnight window after midnight",
+			window: types.OperationWindow{Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    saturday(5, 59),
+			open:   true,
+		},
+		{
+			name:   "overnight window at its end",
+			window: types.OperationWindow{Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    saturday(6, 0),
+			open:   false,
+		},
+		{
+			name:   "whole day window",
+			window: types.OperationWindow{Start: aws.String("08:00"), End: aws.String("08:00")},
+			now:    friday(7, 59),
+			open:   true,
+		},
+		{
+			name:   "whole day window on another day",
+			window: types.OperationWindow{Days: []string{"Sat"}, Start: aws.String("08:00"), End: aws.String("08:00")},
+			now:    friday(12, 0),
+			open:   false,
+		},
+		{
+			name:   "whole day window on its day",
+			window: types.OperationWindow{Days: []string{"Sat"}, Start: aws.String("08:00"), End: aws.String("08:00")},
+			now:    saturday(0, 0),
+			open:   true,
+		},
+		{
+			name:   "overnight window on the day after its start day",
+			window: types.OperationWindow{Days: []string{"Fri"}, Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    saturday(3, 0),
+			open:   true,
+		},
+		{
+			name:   "overnight window started on the day before its start day",
+			window: types.OperationWindow{Days: []string{"Fri"}, Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    friday(3, 0),
+			open:   false,
+		},
+		{
+			name:   "overnight window started on the day after its start day",
+			window: types.OperationWindow{Days: []string{"Fri"}, Start: aws.String("22:00"), End: aws.String("06:00")},
+			now:    saturday(23, 0),
+			open:   false,
+		},
+		{
+			name: "window of a time zone before its start",
+			window: types.OperationWindow{
+				Days:     []string{"Fri"},
+				Start:    aws.String("19:00"),
+				End:      aws.String("07:00"),
+				TimeZone: aws.String("Europe/Kyiv"),
+			},
+			// 18:59 in Kyiv
+			now:  friday(16, 59),
+			open: false,
+		},
+		{
+			name: "window of a time zone at its start",
+			window: types.OperationWindow{
+				Days:     []string{"Fri"},
+				Start:    aws.String("19:00"),
+				End:      aws.String("07:00"),
+				TimeZone: aws.String("Europe/Kyiv"),
+			},
+			// 19:00 in Kyiv
+			now:  friday(17, 0),
+			open: true,
+		},
+		{
+			name: "window of a time zone at its end",
+			window: types.OperationWindow{
+				Days:     []string{"Fri"},
+				Start:    aws.String("19:00"),
+				End:      aws.String("07:00"),
+				TimeZone: aws.String("Europe/Kyiv"),
+			},
+			// 07:00 of Saturday in Kyiv
+			now:  saturday(5, 0),
+			open: false,
+		},
+		{
+			name: "window of a time zone on its day only there",
+			window: types.OperationWindow{
+				Days:     []string{"Sat"},
+				Start:    aws.String("00:00"),
+				End:      aws.String("02:00"),
+				TimeZone: aws.String("Europe/Kyiv"),
+			},
+			// 00:30 of Saturday in Kyiv
+			now:  friday(22, 30),
+			open: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := &types.Stack{OperationWindows: []types.OperationWindow{tt.window}}
+			open, err := curator.OperationWindowsOpen(stack, types.ActionShutdown, tt.now)
+			if err != nil {
+				t.Fatalf("operation window check has failed: %v", err)
+			}
+			if open != tt.open {
+				t.Errorf("operation window is open %v at %v, expected %v", open, tt.now, tt.open)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package types defines the instance stack configuration and plan types used by the curator.
+package types
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Filters are DescribeInstances filters, each either in the verbose form of a name and values,
+// e.g. {name: tag:Tier, values: [web, api]}, or in the shorthand form of a single filter name
+// with a value or a list of values, e.g. {tag:Tier: [web, api]}
+type Filters []ec2Types.Filter
+
+// UnmarshalYAML decodes both the verbose and the shorthand filters
+func (f *Filters) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items []map[string]TagValues
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+
+	filters := make(Filters, 0, len(items))
+	for i, item := range items {
+		name, hasName := item["name"]
+		values, hasValues := item["values"]
+		if !hasName && !hasValues {
+			if len(item) != 1 {
+				return fmt.Errorf("shorthand filter %v must have a single name, got %v", i, len(item))
+			}
+			for name, values := range item {
+				filters = append(filters, ec2Types.Filter{Name: aws.String(name), Values: values})
+			}
+			continue
+		}
+
+		for key := range item {
+			if key != "name" && key != "values" {
+				return fmt.Errorf("filter %v: field %v not found in type types.Filter", i, key)
+			}
+		}
+		if len(name) > 1 {
+			return fmt.Errorf("filter %v: name must be a single value", i)
+		}
+
+		filter := ec2Types.Filter{Values: values}
+		if len(name) == 1 {
+			filter.Name = aws.String(name[0])
+		}
+		filters = append(filters, filter)
+	}
+
+	*f = filters
+	return nil
+}
+
+// TagValues are the values of a tag filter, given as a single value or a list of values
+type TagValues []string
+
+// UnmarshalYAML decodes a single value or a list of values
+func (v *TagValues) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*v = TagValues{value}
+		return nil
+	}
+
+	var values []string
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// TagFilters converts the tag values by the tag key into the tag filters, ordered by the tag key
+func TagFilters(tags map[string]TagValues) Filters {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	filters := make(Filters, 0, len(tags))
+	for _, k := range keys {
+		filters = append(filters, ec2Types.Filter{Name: aws.String("tag:" + k), Values: tags[k]})
+	}
+	return filters
+}
+
+// ExpandTagFilters appends the tag filters of the stack and of the groups to their filters
+func (s *Stack) ExpandTagFilters() {
+	s.Filters = append(s.Filters, TagFilters(s.Tags)...)
+	for i := range s.Groups {
+		s.Groups[i].Filters = append(s.Groups[i].Filters, TagFilters(s.Groups[i].Tags)...)
+	}
+}
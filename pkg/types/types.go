@@ -0,0 +1,897 @@
+package types
+
+import (
+	"time"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Instance health check configuration
+type HealthCheck struct {
+	// URL template of an HTTP(S) health check, e.g. http://{{.PrivateIpAddress}}:8080/health
+	URL *string `yaml:"url" validate:"required_without=Port,excluded_with=Port,omitempty,gt=0"`
+
+	// Port of a TCP health check.
+	Port *int32 `validate:"required_without=URL,omitempty,gt=0,lte=65535"`
+
+	// Expected HTTP status code. Defaults to 200
+	ExpectedStatus *int `yaml:"expected-status" validate:"omitempty,gte=100,lt=600"`
+
+	// Number of retries before the check fails. Defaults to 30
+	Retries *int `validate:"omitempty,gte=0"`
+
+	// Interval between retries. Defaults to 10s
+	Interval *time.Duration `validate:"omitempty,gt=0"`
+
+	// Timeout of a single probe. Defaults to 5s
+	Timeout *time.Duration `validate:"omitempty,gt=0"`
+}
+
+// Route53 health check gating configuration
+type Route53HealthChecks struct {
+	// Route53 health check IDs. Required
+	IDs []string `yaml:"ids" validate:"required,gt=0,dive,required"`
+
+	// Wait for the health checks to report unhealthy before declaring the shutdown done.
+	WaitUnhealthyOnShutdown bool `yaml:"wait-unhealthy-on-shutdown"`
+}
+
+// CloudWatch alarm gating configuration
+type Alarms struct {
+	// Names of the metric or composite alarms which must be in OK state after startup. Required
+	Names []string `validate:"required,gt=0,lte=100,dive,required"`
+
+	// Duration the alarms must stay in OK state without a state change. Defaults to 0, the OK state once
+	Sustain *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Maximum duration of the wait, including the sustain duration. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// ECS services of a cluster the group provides the capacity of
+type ECSServices struct {
+	// Name or ARN of the ECS cluster. Required
+	Cluster *string `validate:"required,gt=0"`
+
+	// Names or ARNs of the services which must reach a steady state after startup. Required
+	Services []string `validate:"required,gt=0,dive,required"`
+
+	// Maximum duration of the wait. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// Load balancer target group registration
+type TargetGroup struct {
+	// Target group ARN. Required
+	ARN *string `yaml:"arn" validate:"required,arn=elasticloadbalancing:targetgroup"`
+
+	// Port the instances are registered on. Defaults to the target group port
+	Port *int32 `validate:"omitempty,gt=0,lte=65535"`
+}
+
+// Route53 record set pointing at the private IPs of the group instances
+type DNSRecord struct {
+	// ID of the hosted zone of the record. Required
+	HostedZoneId *string `yaml:"hosted-zone-id" validate:"required,gt=0"`
+
+	// Name of the A record, e.g. app.internal.example.com. Required
+	Name *string `validate:"required,gt=0"`
+
+	// TTL of the record in seconds. Defaults to 60
+	TTL *int64 `yaml:"ttl" validate:"omitempty,gt=0"`
+
+	// IPs of the maintenance target the record points at from the shutdown until the startup, if any
+	MaintenanceValues []string `yaml:"maintenance-values" validate:"omitempty,dive,ip"`
+}
+
+// Role assumed with a web identity token
+type WebIdentity struct {
+	// IAM Role ARN to be assumed. Required
+	RoleARN *string `yaml:"role-arn" validate:"required,arn=iam:role"`
+
+	// Path of the file the web identity token is read from whenever the credentials are renewed. Required
+	TokenFile *string `yaml:"token-file" validate:"required,gt=0"`
+
+	// Name of the role session. Defaults to the run ID based session name
+	SessionName *string `yaml:"session-name" validate:"omitempty,min=2,max=64"`
+}
+
+// Role assumed in a role chain
+type AssumeRole struct {
+	// IAM Role ARN to be assumed. Required
+	RoleARN *string `yaml:"role-arn" validate:"required,arn=iam:role"`
+
+	// External ID required by the trust policy of the role.
+	ExternalID *string `yaml:"external-id" validate:"omitempty,min=2,max=1224"`
+
+	// Source identity set on the role session.
+	SourceIdentity *string `yaml:"source-identity" validate:"omitempty,min=2,max=64"`
+
+	// Tags of the role session.
+	SessionTags map[string]string `yaml:"session-tags" validate:"omitempty,max=50,dive,keys,min=1,max=128,endkeys,max=256"`
+}
+
+// Instance Group configuration
+type Group struct {
+	// The name of the group. Required
+	Name *string `validate:"required,gt=0"`
+
+	// Group filters. Required unless the group consists of clusters only
+	Filters Filters `validate:"required_without=Clusters,dive,required"`
+
+	// Shorthand tag filters: the tag values by the tag key, appended to the filters.
+	Tags map[string]TagValues
+
+	// DocumentDB and Neptune clusters stopped after and started before the group instances.
+	Clusters []Cluster `validate:"omitempty,dive"`
+
+	// Instance states considered by the group. Defaults to running and stopped
+	InstanceStates []ec2Types.InstanceStateName `yaml:"instance-states" validate:"omitempty,dive,oneof=pending running shutting-down terminated stopping stopped"`
+
+	// Restart the group instances in batches, one instance at a time by default, rather than all at once.
+	Rolling bool
+
+	// Number of instances in a rolling batch.
+	BatchSize *int `yaml:"batch-size" validate:"omitempty,gt=0,excluded_with=BatchPercent"`
+
+	// Percentage of the group instances in a rolling batch, rounded up.
+	BatchPercent *int `yaml:"batch-percent" validate:"omitempty,gt=0,lte=100"`
+
+	// Retry of the instance starts failing for insufficient capacity. Disabled by default
+	CapacityRetry *CapacityRetry `yaml:"capacity-retry" validate:"omitempty"`
+
+	// Action taken when putting the group instances into Standby fails for an ASG on shutdown:
+	// compensate or continue. Defaults to compensate
+	OnShutdownFailure *ShutdownFailureAction `yaml:"on-shutdown-failure" validate:"omitempty,oneof=compensate continue"`
+
+	// Failure policy of returning the group instances to service on startup. Fails the run by default
+	OnStartupFailure *StartupFailurePolicy `yaml:"on-startup-failure" validate:"omitempty"`
+
+	// On-Demand Capacity Reservations ensured before the startup
+	CapacityReservation *CapacityReservation `yaml:"capacity-reservation"`
+
+	// Instance types the stopped instances are changed to, one after another, when their start keeps failing
+	// for insufficient capacity beyond the capacity retries.
+	FallbackInstanceTypes []ec2Types.InstanceType `yaml:"fallback-instance-types" validate:"omitempty,dive,required"`
+
+	// Duration after which instances stuck stopping are stopped forcibly. Disabled by default
+	ForceStopAfter *time.Duration `yaml:"force-stop-after" validate:"omitempty,gt=0"`
+
+	// Guard conditions which must pass before the group is acted upon.
+	Guards []Guard `validate:"omitempty,dive"`
+
+	// CloudWatch metric conditions which must be satisfied before the group shutdown.
+	MetricGuards []MetricGuard `yaml:"metric-guards" validate:"omitempty,dive"`
+
+	// Conditions awaited after the group shutdown or startup.
+	WaitConditions []WaitCondition `yaml:"wait-conditions" validate:"omitempty,dive"`
+
+	// Waiter settings of the group overriding the defaults, e.g. for instances with long status checks.
+	Waiters *Waiters `validate:"omitempty"`
+
+	// Canary verification pause after the group startup.
+	Canary *Canary `validate:"omitempty"`
+
+	// Warm-up delay after the group startup passed its health gates, before the run moves on to the next group,
+	// e.g. for the services filling their caches before the dependents reconnect.
+	WarmupAfterStartup *time.Duration `yaml:"warmup-after-startup" validate:"omitempty,gt=0"`
+
+	// Disable the scale-in protection of the group instances for the operation, restoring it afterwards.
+	DisableScaleInProtection bool `yaml:"disable-scale-in-protection"`
+
+	// Suspend the scaling policies and the scheduled actions of the ASGs from the shutdown until the startup.
+	SuspendScaling bool `yaml:"suspend-scaling"`
+
+	// Switch the predictive scaling policies of the ASGs to forecast only from the shutdown until the startup.
+	PausePredictiveScaling bool `yaml:"pause-predictive-scaling"`
+
+	// Skip the Auto Scaling phase: the instances are not put into Standby nor returned to service,
+	// e.g. for instances outside of ASGs or whose Standby is handled elsewhere.
+	SkipAutoScaling bool `yaml:"skip-asg"`
+
+	// Skip the EC2 phase: the instances are not stopped nor started, only put into Standby and returned to service.
+	SkipEC2 bool `yaml:"skip-ec2" validate:"excluded_with=SkipAutoScaling"`
+
+	// Images created of the group instances before shutdown.
+	Images *Images `validate:"omitempty"`
+
+	// SSM Automation documents executed as group steps.
+	Automations []Automation `validate:"omitempty,dive"`
+
+	// Health checks probed against every group instance after startup.
+	HealthChecks []HealthCheck `yaml:"health-checks" validate:"omitempty,dive"`
+
+	// Route53 health checks gating the group startup and shutdown.
+	Route53HealthChecks *Route53HealthChecks `yaml:"route53-health-checks" validate:"omitempty"`
+
+	// Route53 records upserted with the private IPs of the group instances after the startup.
+	DNSRecords []DNSRecord `yaml:"dns-records" validate:"omitempty,dive"`
+
+	// CloudWatch alarms which must be in OK state after the group startup.
+	Alarms *Alarms `validate:"omitempty"`
+
+	// ECS services which must reach a steady state after the group startup.
+	ECSServices *ECSServices `yaml:"ecs-services" validate:"omitempty"`
+
+	// Boot-time provisioning awaited on every group instance after startup.
+	BootCompletion *BootCompletion `yaml:"boot-completion" validate:"omitempty"`
+
+	// In-OS shutdown run on every group instance before the instances are stopped.
+	GracefulShutdown *GracefulShutdown `yaml:"graceful-shutdown" validate:"omitempty"`
+
+	// Application verification run on every group instance after startup.
+	Verification *Verification `validate:"omitempty"`
+
+	// Target groups the instances are registered with directly rather than through their ASG.
+	TargetGroups []TargetGroup `yaml:"target-groups" validate:"omitempty,dive"`
+
+	// Wait until the instances returned to service are healthy in the target groups attached to their ASG.
+	WaitTargetHealth bool `yaml:"wait-target-health"`
+
+	// Record the Elastic IP associations of the instances at the shutdown and re-associate them after the startup,
+	// failing the health phase if an association cannot be restored.
+	ElasticIPs bool `yaml:"elastic-ips"`
+
+	// Group instance IDs.
+	Instances []ec2Types.Instance `yaml:"-"`
+
+	// All the resolved group instances while Instances holds a subset of them, e.g. a batch of a rolling group,
+	// whose other members keep serving, nil otherwise.
+	AllInstances []ec2Types.Instance `yaml:"-"`
+}
+
+// The step of a group an automation is executed at
+type AutomationPhase string
+
+// Automation phases
+const (
+	AutomationPhaseBeforeShutdown AutomationPhase = "before-shutdown"
+	AutomationPhaseAfterShutdown  AutomationPhase = "after-shutdown"
+	AutomationPhaseBeforeStartup  AutomationPhase = "before-startup"
+	AutomationPhaseAfterStartup   AutomationPhase = "after-startup"
+)
+
+// SSM Automation document executed as a group step
+type Automation struct {
+	// The name or ARN of the Automation document. Required
+	Document *string `validate:"required,gt=0"`
+
+	// The document version. Defaults to the default version
+	DocumentVersion *string `yaml:"document-version" validate:"omitempty,gt=0"`
+
+	// The step of the group the automation is executed at. Required
+	Phase AutomationPhase `validate:"required,oneof=before-shutdown after-shutdown before-startup after-startup"`
+
+	// Document parameter templates, e.g. InstanceId: ["{{range .InstanceIds}}{{.}}\n{{end}}"]
+	Parameters map[string][]string
+
+	// Maximum duration of the execution. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gt=0"`
+}
+
+// Application verification run on every started instance via SSM Run Command
+type Verification struct {
+	// Shell, or PowerShell, commands whose exit code determines the instance health. Required
+	Commands []string `validate:"required,gt=0,dive,required"`
+
+	// Run the commands with PowerShell rather than a shell.
+	PowerShell bool `yaml:"powershell"`
+
+	// Maximum duration of the commands. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Maximum number of instances the commands run on concurrently. Defaults to all instances
+	Parallelism *int32 `validate:"omitempty,gt=0"`
+}
+
+// Source of the data evaluated by a guard
+type GuardSource string
+
+// Guard sources
+const (
+	GuardSourceInstances         GuardSource = "instances"
+	GuardSourceAutoScalingGroups GuardSource = "auto-scaling-groups"
+)
+
+// Action taken when a guard does not pass
+type GuardFailureAction string
+
+// Guard failure actions
+const (
+	GuardFailureAbort GuardFailureAction = "abort"
+	GuardFailureWait  GuardFailureAction = "wait"
+)
+
+// Guard condition which must pass before the group is acted upon
+type Guard struct {
+	// Name of the guard shown in messages. Defaults to the expression
+	Name *string `validate:"omitempty,gt=0"`
+
+	// Data the expression is evaluated over: DescribeInstances or DescribeAutoScalingGroups output. Defaults to instances
+	Source *GuardSource `validate:"omitempty,oneof=instances auto-scaling-groups"`
+
+	// Filters of a custom DescribeInstances query. Defaults to the group instances
+	Filters Filters `validate:"omitempty,dive,required"`
+
+	// Names of the Auto Scaling Groups described. Defaults to the Auto Scaling Groups of the group instances
+	AutoScalingGroupNames []string `yaml:"auto-scaling-group-names" validate:"omitempty,dive,required"`
+
+	// JMESPath expression which must evaluate to a truthy value. Required
+	Expression *string `validate:"required,gt=0"`
+
+	// Action taken when the guard does not pass: abort or wait. Defaults to abort
+	OnFailure *GuardFailureAction `yaml:"on-failure" validate:"omitempty,oneof=abort wait"`
+
+	// Maximum duration of the wait. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// Instance state awaited after the instances are started
+type StartedState string
+
+// Started states
+const (
+	StartedStateStatusOk StartedState = "status-ok"
+	StartedStateRunning  StartedState = "running"
+)
+
+// Waiter settings of a group
+type Waiters struct {
+	// Maximum duration of the instance and Auto Scaling waits. Defaults to 10m
+	MaxWait *time.Duration `yaml:"max-wait" validate:"omitempty,gte=1s"`
+
+	// Minimum delay between the waiter attempts. Defaults to the waiter default, 15s for most waiters
+	MinDelay *time.Duration `yaml:"min-delay" validate:"omitempty,gte=1s"`
+
+	// Maximum delay between the waiter attempts, raised to the minimum delay if lower. Defaults to 1m
+	// for the instance waiters
+	MaxDelay *time.Duration `yaml:"max-delay" validate:"omitempty,gte=1s"`
+
+	// Instance state awaited after the start and the reboot: status-ok, once the status checks pass,
+	// or running. Defaults to status-ok
+	StartedState *StartedState `yaml:"started-state" validate:"omitempty,oneof=status-ok running"`
+}
+
+// Phase a wait condition is awaited in
+type WaitConditionPhase string
+
+// Wait condition phases
+const (
+	WaitConditionPhaseAfterShutdown WaitConditionPhase = "after-shutdown"
+	WaitConditionPhaseAfterStartup  WaitConditionPhase = "after-startup"
+)
+
+// Data a wait condition expression is evaluated over
+type WaitConditionSource string
+
+// Wait condition sources
+const (
+	WaitConditionSourceInstances            WaitConditionSource = "instances"
+	WaitConditionSourceAutoScalingInstances WaitConditionSource = "auto-scaling-instances"
+)
+
+// Comparator of the values selected by a wait condition expression with the expected value
+type WaitConditionComparator string
+
+// Wait condition comparators
+const (
+	WaitConditionAllStringEquals        WaitConditionComparator = "all-string-equals"
+	WaitConditionAnyStringEquals        WaitConditionComparator = "any-string-equals"
+	WaitConditionAllStringEqualsOrEmpty WaitConditionComparator = "all-string-equals-or-empty"
+)
+
+// Condition over the group instances awaited after the group shutdown or startup
+type WaitCondition struct {
+	// Name of the condition shown in messages. Defaults to the expression
+	Name *string `validate:"omitempty,gt=0"`
+
+	// Phase the condition is awaited in: after-shutdown or after-startup. Required
+	Phase WaitConditionPhase `validate:"required,oneof=after-shutdown after-startup"`
+
+	// Data the expression is evaluated over: DescribeInstances or DescribeAutoScalingInstances output
+	// of the group instances. Defaults to instances
+	Source *WaitConditionSource `validate:"omitempty,oneof=instances auto-scaling-instances"`
+
+	// JMESPath expression selecting the compared values, e.g. Reservations[].Instances[].State.Name. Required
+	Expression *string `validate:"required,gt=0"`
+
+	// Value the selected values are compared with. Required
+	Expected *string `validate:"required"`
+
+	// Comparator: all-string-equals, any-string-equals or all-string-equals-or-empty. Defaults to all-string-equals
+	Comparator *WaitConditionComparator `validate:"omitempty,oneof=all-string-equals any-string-equals all-string-equals-or-empty"`
+
+	// Maximum duration of the wait. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// Action taken when a metric guard is not satisfied within its timeout
+type MetricGuardTimeoutAction string
+
+// Metric guard timeout actions
+const (
+	MetricGuardTimeoutFail  MetricGuardTimeoutAction = "fail"
+	MetricGuardTimeoutForce MetricGuardTimeoutAction = "force"
+)
+
+// CloudWatch metric condition which must be satisfied before the group shutdown
+type MetricGuard struct {
+	// Name of the guard shown in messages. Defaults to the metric name
+	Name *string `validate:"omitempty,gt=0"`
+
+	// Metric namespace, e.g. AWS/SQS. Required
+	Namespace *string `validate:"required,gt=0"`
+
+	// Metric name, e.g. ApproximateNumberOfMessagesVisible. Required
+	Metric *string `validate:"required,gt=0"`
+
+	// Metric dimensions.
+	Dimensions map[string]string `validate:"omitempty,dive,keys,required,endkeys,required"`
+
+	// Statistic: Average, Sum, Minimum, Maximum or SampleCount. Defaults to Average
+	Statistic *string `validate:"omitempty,oneof=Average Sum Minimum Maximum SampleCount"`
+
+	// Comparison of the statistic with the threshold: lt, lte, eq, gte or gt. Required
+	Comparison *string `validate:"required,oneof=lt lte eq gte gt"`
+
+	// Threshold the statistic is compared with. Required
+	Threshold *float64 `validate:"required"`
+
+	// Period of the datapoints. Defaults to 1m
+	Period *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Window every datapoint of which must satisfy the condition. Defaults to 5m
+	Lookback *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Maximum duration of the wait for the condition. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Action taken when the condition is not satisfied within the timeout: fail or force. Defaults to fail
+	OnTimeout *MetricGuardTimeoutAction `yaml:"on-timeout" validate:"omitempty,oneof=fail force"`
+}
+
+// Retry of instance starts failing for insufficient capacity
+type CapacityRetry struct {
+	// Interval between the retries. Defaults to 1m
+	Interval *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Maximum duration of the retries. Defaults to 30m
+	MaxDuration *time.Duration `yaml:"max-duration" validate:"omitempty,gte=1s"`
+
+	// Notify the run notifiers of every retry.
+	Notify bool
+}
+
+// Execution strategy of the stack shutdown
+type ShutdownStrategy string
+
+// Shutdown strategies
+const (
+	// Shut every group down, from Standby to stopped instances, before the next one
+	ShutdownStrategySequential ShutdownStrategy = "sequential"
+
+	// Put the instances of every group into Standby in order, then stop the instances of every group in order
+	ShutdownStrategyTwoPhase ShutdownStrategy = "two-phase"
+)
+
+// Action taken when putting the group instances into Standby fails for an ASG on shutdown
+type ShutdownFailureAction string
+
+// Shutdown failure actions
+const (
+	// Undo the changes applied to the other ASGs of the group
+	ShutdownFailureCompensate ShutdownFailureAction = "compensate"
+
+	// Change the remaining ASGs of the group, keeping the applied changes
+	ShutdownFailureContinue ShutdownFailureAction = "continue"
+)
+
+// Action taken when returning the group instances to service fails on startup
+type StartupFailureAction string
+
+// Startup failure actions
+const (
+	StartupFailureFail     StartupFailureAction = "fail"
+	StartupFailureRetry    StartupFailureAction = "retry"
+	StartupFailureContinue StartupFailureAction = "continue"
+)
+
+// Failure policy of returning the group instances to service on startup
+type StartupFailurePolicy struct {
+	// Action taken on failure: fail, retry or continue with a warning. Defaults to fail
+	Action *StartupFailureAction `validate:"omitempty,oneof=fail retry continue"`
+
+	// Number of retries before the startup fails. Defaults to 3
+	Retries *int `validate:"omitempty,gt=0"`
+
+	// Interval between the retries. Defaults to 30s
+	Interval *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// CapacityReservationMode defines how the capacity is ensured before the startup
+type CapacityReservationMode string
+
+const (
+	// Create reservations matching the stopped instances and cancel them once the instances are started
+	CapacityReservationModeCreate CapacityReservationMode = "create"
+
+	// Verify that active reservations with enough available capacity exist
+	CapacityReservationModeVerify CapacityReservationMode = "verify"
+)
+
+// On-Demand Capacity Reservations matching the instance types and Availability Zones of the group
+type CapacityReservation struct {
+	Mode CapacityReservationMode `validate:"required,oneof=create verify"`
+}
+
+// Canary verification pause after the group startup
+type Canary struct {
+	// Duration of the pause before the run continues.
+	Pause *time.Duration `validate:"required_without=Confirm,omitempty,gt=0"`
+
+	// Require a confirmation before the run continues.
+	Confirm bool
+
+	// Pause after the first batch of a rolling group rather than after the whole group.
+	FirstBatch bool `yaml:"first-batch"`
+}
+
+// Boot-time provisioning awaited on every group instance via SSM Run Command after startup
+type BootCompletion struct {
+	// Wait for cloud-init to finish.
+	CloudInit bool `yaml:"cloud-init"`
+
+	// Path of a marker file created once the provisioning has finished.
+	SentinelFile *string `yaml:"sentinel-file" validate:"omitempty,gt=0"`
+
+	// Maximum duration of the wait. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+}
+
+// In-OS shutdown run on every group instance via SSM Run Command before the instances are stopped
+type GracefulShutdown struct {
+	// Services stopped with systemctl, or Stop-Service with PowerShell.
+	Services []string `validate:"required_without=Commands,dive,required"`
+
+	// Shell, or PowerShell, commands run after the services are stopped.
+	Commands []string `validate:"required_without=Services,dive,required"`
+
+	// Run the commands with PowerShell rather than a shell.
+	PowerShell bool `yaml:"powershell"`
+
+	// Maximum duration of the shutdown. Defaults to 10m
+	Timeout *time.Duration `validate:"omitempty,gte=1s"`
+
+	// Maximum number of instances shut down concurrently. Defaults to all instances
+	Parallelism *int32 `validate:"omitempty,gt=0"`
+}
+
+// DocumentDB or Neptune cluster
+type Cluster struct {
+	// The DB cluster identifier. Required
+	Identifier *string `validate:"required,gt=0"`
+
+	// The expected cluster engine, docdb or neptune. Required
+	Engine *string `validate:"required,oneof=docdb neptune"`
+}
+
+// Images created of the group instances before shutdown
+type Images struct {
+	// Create the image of one representative instance of the group only.
+	Representative bool
+
+	// Do not reboot the instances before creating the images.
+	NoReboot bool `yaml:"no-reboot"`
+}
+
+// Audit trail configuration
+type Audit struct {
+	// S3 bucket receiving the audit records. Required
+	Bucket *string `validate:"required,gt=0"`
+
+	// S3 key prefix of the audit records.
+	Prefix *string `validate:"omitempty,gt=0"`
+
+	// KMS HMAC key used to sign the audit records.
+	KMSKeyId *string `yaml:"kms-key-id" validate:"omitempty,gt=0"`
+}
+
+// CloudWatch Logs configuration
+type Logs struct {
+	// Log group receiving a log stream per run, created if it does not exist. Required
+	LogGroup *string `yaml:"log-group" validate:"required,gt=0,max=512"`
+
+	// The name of the CloudWatch Logs Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+}
+
+// State backend configuration, one of the backends
+type StateBackend struct {
+	// Files of a local directory.
+	File *FileStateBackend `validate:"omitempty"`
+
+	// Objects of an S3 bucket. The locks are written conditionally, unless the store does not support it.
+	S3 *S3StateBackend `yaml:"s3" validate:"omitempty,excluded_with=File"`
+
+	// Items of a DynamoDB table with the string partition key "key".
+	DynamoDB *DynamoDBStateBackend `yaml:"dynamodb" validate:"omitempty,excluded_with=File S3"`
+}
+
+// Local directory state backend configuration
+type FileStateBackend struct {
+	// Directory of the state files, created if it does not exist. Required
+	Dir *string `validate:"required,gt=0"`
+}
+
+// S3 state backend configuration
+type S3StateBackend struct {
+	// S3 bucket of the state objects. Required
+	Bucket *string `validate:"required,gt=0"`
+
+	// S3 key prefix of the state objects.
+	Prefix *string `validate:"omitempty,gt=0"`
+
+	// The name of the S3 Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+}
+
+// DynamoDB state backend configuration
+type DynamoDBStateBackend struct {
+	// Name of the DynamoDB table of the state items. Required
+	Table *string `validate:"required,min=3,max=255"`
+
+	// The name of the DynamoDB Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+}
+
+// Run metadata tags configuration
+type RunTags struct {
+	// Prefix of the run metadata tag keys, "curator:" by default.
+	Prefix *string `validate:"omitempty,gt=0"`
+}
+
+// Run notifications configuration
+type Notifications struct {
+	// Email summary of the run sent with SES.
+	Email *EmailNotification `validate:"omitempty"`
+
+	// HTTP webhooks receiving the run events.
+	Webhooks []WebhookNotification `validate:"omitempty,dive"`
+
+	// CloudWatch metrics of the finished run.
+	Metrics *MetricsNotification `validate:"omitempty"`
+
+	// EventBridge events of the stack and group transitions.
+	EventBridge *EventBridgeNotification `yaml:"eventbridge" validate:"omitempty"`
+}
+
+// EventBridge events configuration
+type EventBridgeNotification struct {
+	// Name or ARN of the event bus. Defaults to the default event bus
+	EventBus *string `yaml:"event-bus" validate:"omitempty,gt=0,max=1600"`
+
+	// Source of the events. Defaults to instance-stack-curator
+	Source *string `validate:"omitempty,gt=0,max=256"`
+
+	// The name of the EventBridge Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+}
+
+// CloudWatch metrics configuration
+type MetricsNotification struct {
+	// Namespace of the metrics. Defaults to InstanceStackCurator
+	Namespace *string `validate:"omitempty,gt=0,max=255"`
+
+	// The name of the CloudWatch Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+}
+
+// Email notification configuration
+type EmailNotification struct {
+	// Verified SES sender address. Required
+	From *string `validate:"required,email"`
+
+	// Recipient addresses. Required
+	To []string `validate:"required,gt=0,dive,email"`
+
+	// Carbon copy recipient addresses.
+	Cc []string `validate:"omitempty,dive,email"`
+
+	// The name of the SES Region. Defaults to the stack Region
+	Region *string `validate:"omitempty,gt=0"`
+
+	// Send the summary of failed runs only.
+	OnFailureOnly bool `yaml:"on-failure-only"`
+}
+
+// Webhook notification configuration
+type WebhookNotification struct {
+	// Webhook URL. Environment variables are expanded. Required
+	URL *string `yaml:"url" validate:"required,gt=0"`
+
+	// HTTP method. Defaults to POST
+	Method *string `validate:"omitempty,oneof=POST PUT"`
+
+	// HTTP headers. Environment variables in the values are expanded.
+	Headers map[string]string `validate:"omitempty,dive,keys,required,endkeys"`
+
+	// Payload template rendered with the event. Defaults to the JSON encoded event
+	Template *string `validate:"omitempty,gt=0"`
+
+	// Events sent to the webhook: run-started, group-started, group-completed, capacity-retry or run-finished. Defaults to all events
+	Events []string `validate:"omitempty,dive,oneof=run-started group-started group-completed capacity-retry run-finished"`
+
+	// Timeout of a single delivery. Defaults to 10s
+	Timeout *time.Duration `validate:"omitempty,gt=0"`
+}
+
+// Instance Stack configuration
+type Stack struct {
+	// The name of the stack. Required
+	Name *string `validate:"required,gt=0"`
+
+	// The name of the Region.
+	Region *string `validate:"omitempty,gt=0"`
+
+	// URL of the endpoint all AWS calls are sent to, e.g. LocalStack.
+	EndpointURL *string `yaml:"endpoint-url" validate:"omitempty,url"`
+
+	// IAM Role ARN to be assumed, of the partition of the Region.
+	RoleARN *string `yaml:"role-arn" validate:"required_with=RoleChain,omitempty,arn=iam:role"`
+
+	// Role assumed with a web identity token, e.g. of EKS IRSA or GitHub OIDC, providing the credentials
+	// the role chain and the role are assumed with. Defaults to the default credential chain
+	WebIdentity *WebIdentity `yaml:"web-identity"`
+
+	// Jump roles assumed in sequence before the role, each with the credentials of the previous one.
+	RoleChain []AssumeRole `yaml:"role-chain" validate:"omitempty,dive"`
+
+	// External ID required by the trust policy of the role.
+	ExternalID *string `yaml:"external-id" validate:"omitempty,excluded_without=RoleARN,min=2,max=1224"`
+
+	// Source identity set on the role session.
+	SourceIdentity *string `yaml:"source-identity" validate:"omitempty,excluded_without=RoleARN,min=2,max=64"`
+
+	// Tags of the role session.
+	SessionTags map[string]string `yaml:"session-tags" validate:"omitempty,excluded_without=RoleARN,max=50,dive,keys,min=1,max=128,endkeys,max=256"`
+
+	// Global Stack filters. Required
+	Filters Filters `validate:"required,gt=0,dive,required"`
+
+	// Shorthand tag filters: the tag values by the tag key, appended to the filters.
+	Tags map[string]TagValues
+
+	// Allow filter names unknown to the validator, e.g. filters recently added to DescribeInstances.
+	AllowUnknownFilters bool `yaml:"allow-unknown-filters"`
+
+	// Stack groups. Required
+	Groups []Group `validate:"required,gt=0,dive,required"`
+
+	// Execution strategy of the shutdown: sequential or two-phase. Defaults to sequential
+	ShutdownStrategy *ShutdownStrategy `yaml:"shutdown-strategy" validate:"omitempty,oneof=sequential two-phase"`
+
+	// Audit trail of the mutating calls.
+	Audit *Audit `validate:"omitempty"`
+
+	// CloudWatch Logs receiving the structured logs of every run.
+	Logs *Logs `validate:"omitempty"`
+
+	// Run metadata tags written to the affected instances and Auto Scaling Groups.
+	RunTags *RunTags `yaml:"run-tags" validate:"omitempty"`
+
+	// Notifications sent at the run completion.
+	Notifications *Notifications `validate:"omitempty"`
+
+	// Backend storing the run states, the recorded Auto Scaling Group sizes and the locks of the stack.
+	// Defaults to the files of the instance-stack-curator directory of the temporary directory
+	State *StateBackend `validate:"omitempty"`
+
+	// Time windows the actions may run in. The actions run at any time unless a window applies to them
+	OperationWindows []OperationWindow `yaml:"operation-windows" validate:"omitempty,dive"`
+}
+
+// Time window the stack may be operated in
+type OperationWindow struct {
+	// Actions the window applies to. Defaults to all the actions
+	Actions []Action `validate:"omitempty,dive,oneof=shutdown startup restart reboot freeze thaw"`
+
+	// Days of the week the window starts on, e.g. Sat. Defaults to every day
+	Days []string `validate:"omitempty,dive,oneof=Mon Tue Wed Thu Fri Sat Sun"`
+
+	// Time of the day the window starts at, e.g. 19:00. Required
+	Start *string `validate:"required,datetime=15:04"`
+
+	// Time of the day the window ends at, e.g. 07:00 of the next day for a window ending before its start. Required
+	End *string `validate:"required,datetime=15:04"`
+
+	// IANA time zone of the window, e.g. Europe/Kyiv. Defaults to UTC
+	TimeZone *string `yaml:"time-zone" validate:"omitempty,timezone"`
+}
+
+// Curator action
+type Action string
+
+const (
+	ActionShutdown Action = "shutdown"
+	ActionStartup  Action = "startup"
+	ActionRestart  Action = "restart"
+	ActionReboot   Action = "reboot"
+	ActionFreeze   Action = "freeze"
+	ActionThaw     Action = "thaw"
+)
+
+// Auto Scaling Group change
+type AutoScalingGroupChange struct {
+	// The name of the Auto Scaling group.
+	AutoScalingGroupName *string `yaml:"auto-scaling-group-name"`
+
+	// Instance IDs to be moved into or out of Standby.
+	InstanceIds []string `yaml:"instance-ids"`
+
+	// Current group sizes, used to detect drift.
+	MinSize         *int32 `yaml:"min-size"`
+	MaxSize         *int32 `yaml:"max-size"`
+	DesiredCapacity *int32 `yaml:"desired-capacity"`
+
+	// Updated group sizes, if any.
+	NewMinSize *int32 `yaml:"new-min-size,omitempty"`
+	NewMaxSize *int32 `yaml:"new-max-size,omitempty"`
+
+	// Scaling processes suspended before the shutdown or resumed after the startup, if any.
+	SuspendProcesses []string `yaml:"suspend-processes,omitempty"`
+	ResumeProcesses  []string `yaml:"resume-processes,omitempty"`
+
+	// Instance IDs protected from scale in.
+	ProtectedInstanceIds []string `yaml:"protected-instance-ids,omitempty"`
+
+	// ARNs of the target groups attached to the group.
+	TargetGroupARNs []string `yaml:"target-group-arns,omitempty"`
+}
+
+// Planned instance
+type PlannedInstance struct {
+	// The ID of the instance.
+	InstanceId *string `yaml:"instance-id"`
+
+	// The state of the instance at plan time.
+	State ec2Types.InstanceStateName
+}
+
+// Planned cluster
+type PlannedCluster struct {
+	// The DB cluster identifier.
+	Identifier *string
+
+	// The status of the cluster at plan time.
+	Status *string
+}
+
+// Planned instance group
+type GroupPlan struct {
+	// The name of the group.
+	Name *string
+
+	// Resolved group instances.
+	Instances []PlannedInstance
+
+	// Intended Auto Scaling Group changes.
+	AutoScalingGroups []AutoScalingGroupChange `yaml:"auto-scaling-groups"`
+
+	// Group clusters.
+	Clusters []PlannedCluster `yaml:",omitempty"`
+}
+
+// Instance Stack plan
+type Plan struct {
+	// The action to be applied.
+	Action Action
+
+	// The name of the stack.
+	Stack *string
+
+	// The time the plan was created.
+	CreatedAt time.Time `yaml:"created-at"`
+
+	// Planned groups in execution order.
+	Groups []GroupPlan
+}